@@ -0,0 +1,220 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"product/utils"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// generationTTL is how long a generation lives without being invalidated.
+// Its expiry only empties the cache.
+const generationTTL = 24 * time.Hour
+
+var errLoadPanicked = errors.New("cache load panicked")
+
+// Store keeps cached values until they expire.
+type Store interface {
+	// Get reports false when key is missing or expired at now.
+	Get(ctx context.Context, key string, now time.Time) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, expiresAt time.Time) error
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+// Run deletes expired entries from store every hour until ctx is done.
+func Run(ctx context.Context, store Store) {
+	utils.RunCleanup(ctx, "expired cache entries", store.DeleteExpired)
+}
+
+type Stats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// call is a load in progress, shared by the concurrent misses of a key.
+type call struct {
+	done  chan struct{}
+	value []byte
+	err   error
+}
+
+// Cache keeps values as JSON in a Store for TTL. The keys of a cache live in
+// a generation, and Invalidate starts a new one, which drops every entry at
+// once without having to find them. Concurrent misses of a key wait for a
+// single load. Failures of the store are logged and the values loaded
+// directly, so the cache never fails a read.
+type Cache struct {
+	name   string
+	store  Store
+	ttl    func() time.Duration
+	logger *slog.Logger
+	hits   atomic.Uint64
+	misses atomic.Uint64
+	mu     sync.Mutex
+	calls  map[string]*call
+}
+
+// New returns a cache storing its entries in store for ttl, read on every
+// call so it can be reloaded. A ttl of zero disables the cache.
+func New(name string, store Store, ttl func() time.Duration, logger *slog.Logger) *Cache {
+	return &Cache{
+		name:   name,
+		store:  store,
+		ttl:    ttl,
+		logger: logger,
+		calls:  map[string]*call{},
+	}
+}
+
+func (c *Cache) Name() string {
+	return c.name
+}
+
+func (c *Cache) Stats() Stats {
+	return Stats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+	}
+}
+
+// Invalidate drops every entry of the cache. It goes through even when ctx
+// is done, as the write it follows has already happened.
+func (c *Cache) Invalidate(ctx context.Context) {
+	ctx = context.WithoutCancel(ctx)
+
+	if _, err := c.newGeneration(ctx); err != nil {
+		c.logger.ErrorContext(ctx, "failed to invalidate cache", "error", err, "cache", c.name)
+	}
+}
+
+// Fetch returns the value cached under key, or loads it with load and caches
+// it.
+func Fetch[T any](ctx context.Context, c *Cache, key string, load func(ctx context.Context) (T, error)) (T, error) {
+	var value T
+
+	ttl := c.ttl()
+
+	if ttl <= 0 {
+		return load(ctx)
+	}
+
+	generation, err := c.generation(ctx)
+
+	if err != nil {
+		c.logger.ErrorContext(ctx, "failed to read cache", "error", err, "cache", c.name)
+		return load(ctx)
+	}
+
+	digest := sha256.Sum256([]byte(key))
+	storeKey := c.name + ":" + generation + ":" + hex.EncodeToString(digest[:16])
+
+	data, ok, err := c.store.Get(ctx, storeKey, time.Now())
+
+	if err != nil {
+		c.logger.ErrorContext(ctx, "failed to read cache", "error", err, "cache", c.name)
+	}
+
+	if ok && json.Unmarshal(data, &value) == nil {
+		c.hits.Add(1)
+		return value, nil
+	}
+
+	c.misses.Add(1)
+
+	data, err = c.do(ctx, storeKey, func() ([]byte, error) {
+		loaded, err := load(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := json.Marshal(loaded)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := c.store.Set(ctx, storeKey, data, time.Now().Add(ttl)); err != nil {
+			c.logger.ErrorContext(ctx, "failed to write cache", "error", err, "cache", c.name)
+		}
+
+		return data, nil
+	})
+
+	if err != nil {
+		return value, err
+	}
+
+	err = json.Unmarshal(data, &value)
+
+	return value, err
+}
+
+// do runs load unless a load of key is already in progress, in which case it
+// waits for that one instead.
+func (c *Cache) do(ctx context.Context, key string, load func() ([]byte, error)) ([]byte, error) {
+	c.mu.Lock()
+
+	if pending, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+
+		select {
+		case <-pending.done:
+			return pending.value, pending.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	pending := &call{done: make(chan struct{}), err: errLoadPanicked}
+	c.calls[key] = pending
+	c.mu.Unlock()
+
+	// The waiters are released even when load panics.
+	defer func() {
+		c.mu.Lock()
+		delete(c.calls, key)
+		c.mu.Unlock()
+		close(pending.done)
+	}()
+
+	pending.value, pending.err = load()
+
+	return pending.value, pending.err
+}
+
+// generation returns the current generation of the cache, starting one when
+// there is none.
+func (c *Cache) generation(ctx context.Context) (string, error) {
+	generation, ok, err := c.store.Get(ctx, c.name+":generation", time.Now())
+
+	if err != nil {
+		return "", err
+	}
+
+	if ok {
+		return string(generation), nil
+	}
+
+	return c.newGeneration(ctx)
+}
+
+func (c *Cache) newGeneration(ctx context.Context) (string, error) {
+	generation, err := utils.RandomToken(8)
+
+	if err != nil {
+		return "", err
+	}
+
+	if err := c.store.Set(ctx, c.name+":generation", []byte(generation), time.Now().Add(generationTTL)); err != nil {
+		return "", err
+	}
+
+	return generation, nil
+}
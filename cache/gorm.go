@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"context"
+	"product/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormStore keeps the entries in the database so every instance of the
+// application shares them, and sees the invalidations of the others.
+type GormStore struct {
+	db *gorm.DB
+}
+
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{
+		db: db,
+	}
+}
+
+func (gs *GormStore) Get(ctx context.Context, key string, now time.Time) ([]byte, bool, error) {
+	entry := models.CacheEntry{}
+
+	rec := gs.db.WithContext(ctx).Where("`key` = ? AND expires_at > ?", key, now).Limit(1).Find(&entry)
+
+	if rec.Error != nil {
+		return nil, false, rec.Error
+	}
+
+	return entry.Value, rec.RowsAffected == 1, nil
+}
+
+func (gs *GormStore) Set(ctx context.Context, key string, value []byte, expiresAt time.Time) error {
+	entry := models.CacheEntry{Key: key, Value: value, ExpiresAt: expiresAt}
+
+	return gs.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&entry).Error
+}
+
+func (gs *GormStore) DeleteExpired(ctx context.Context, now time.Time) error {
+	return gs.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&models.CacheEntry{}).Error
+}
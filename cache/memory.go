@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// MemoryStore keeps up to maxEntries entries in process, evicting the least
+// recently used first. The entries are not shared between instances.
+type MemoryStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	// order holds the entries from the most to the least recently used.
+	order *list.List
+}
+
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	return &MemoryStore{
+		maxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+	}
+}
+
+func (ms *MemoryStore) Get(ctx context.Context, key string, now time.Time) ([]byte, bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	element, ok := ms.entries[key]
+
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := element.Value.(*memoryEntry)
+
+	if !now.Before(entry.expiresAt) {
+		ms.remove(element)
+		return nil, false, nil
+	}
+
+	ms.order.MoveToFront(element)
+
+	return entry.value, true, nil
+}
+
+func (ms *MemoryStore) Set(ctx context.Context, key string, value []byte, expiresAt time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if element, ok := ms.entries[key]; ok {
+		entry := element.Value.(*memoryEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		ms.order.MoveToFront(element)
+
+		return nil
+	}
+
+	ms.entries[key] = ms.order.PushFront(&memoryEntry{key: key, value: value, expiresAt: expiresAt})
+
+	for ms.order.Len() > ms.maxEntries {
+		ms.remove(ms.order.Back())
+	}
+
+	return nil
+}
+
+func (ms *MemoryStore) DeleteExpired(ctx context.Context, now time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, element := range ms.entries {
+		if !now.Before(element.Value.(*memoryEntry).expiresAt) {
+			ms.remove(element)
+		}
+	}
+
+	return nil
+}
+
+func (ms *MemoryStore) remove(element *list.Element) {
+	ms.order.Remove(element)
+	delete(ms.entries, element.Value.(*memoryEntry).key)
+}
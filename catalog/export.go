@@ -0,0 +1,174 @@
+package catalog
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"product/models"
+	"strconv"
+	"strings"
+)
+
+const FormatXLSX = "xlsx"
+
+// ExportColumns lists the columns a product can be exported with, in their
+// default order.
+var ExportColumns = []string{"id", "sku", "name", "description", "price", "stock", "user_id"}
+
+// ContentTypes maps each export format to its media type.
+var ContentTypes = map[string]string{
+	FormatCSV:   "text/csv; charset=utf-8",
+	FormatJSONL: "application/x-ndjson",
+	FormatXLSX:  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// Writer writes products one at a time. Close must be called to complete
+// the file; it does not close the underlying io.Writer.
+type Writer interface {
+	Write(product models.Product) error
+	Close() error
+}
+
+// ParseColumns reads a comma separated list of columns. An empty list
+// selects every column.
+func ParseColumns(list string) ([]string, error) {
+	if strings.TrimSpace(list) == "" {
+		return ExportColumns, nil
+	}
+
+	var columns []string
+
+	for _, column := range strings.Split(list, ",") {
+		column = strings.ToLower(strings.TrimSpace(column))
+
+		if !isExportColumn(column) {
+			return nil, fmt.Errorf("unknown column %q", column)
+		}
+
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
+
+func isExportColumn(column string) bool {
+	for _, c := range ExportColumns {
+		if c == column {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewWriter returns a Writer of format writing columns to w.
+func NewWriter(w io.Writer, format string, columns []string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns)
+	case FormatJSONL:
+		return &jsonlWriter{encoder: json.NewEncoder(w), columns: columns}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w, columns)
+	default:
+		return nil, fmt.Errorf("unknown export format %q, expected csv, jsonl or xlsx", format)
+	}
+}
+
+// value returns the column of product, as a string or an int64.
+func value(product models.Product, column string) any {
+	switch column {
+	case "id":
+		return int64(product.ID)
+	case "sku":
+		return product.GetSKU()
+	case "name":
+		return product.Name
+	case "description":
+		return product.Description
+	case "price":
+		return int64(product.Price)
+	case "stock":
+		return int64(product.Stock)
+	case "user_id":
+		return int64(product.UserID)
+	default:
+		return nil
+	}
+}
+
+type csvWriter struct {
+	writer  *csv.Writer
+	columns []string
+	record  []string
+}
+
+func newCSVWriter(w io.Writer, columns []string) (*csvWriter, error) {
+	cw := &csvWriter{
+		writer:  csv.NewWriter(w),
+		columns: columns,
+		record:  make([]string, len(columns)),
+	}
+
+	return cw, cw.writer.Write(columns)
+}
+
+func (cw *csvWriter) Write(product models.Product) error {
+	for i, column := range cw.columns {
+		switch v := value(product, column).(type) {
+		case int64:
+			cw.record[i] = strconv.FormatInt(v, 10)
+		case string:
+			cw.record[i] = v
+		}
+	}
+
+	return cw.writer.Write(cw.record)
+}
+
+func (cw *csvWriter) Close() error {
+	cw.writer.Flush()
+
+	return cw.writer.Error()
+}
+
+type jsonlWriter struct {
+	encoder *json.Encoder
+	columns []string
+}
+
+func (jw *jsonlWriter) Write(product models.Product) error {
+	object := make(map[string]any, len(jw.columns))
+
+	for _, column := range jw.columns {
+		object[column] = value(product, column)
+	}
+
+	return jw.encoder.Encode(object)
+}
+
+func (jw *jsonlWriter) Close() error {
+	return nil
+}
+
+// ProductSource reads the products to export.
+type ProductSource interface {
+	Each(ctx context.Context, query string, fn func(models.Product) error) error
+}
+
+// Export writes the products of source matching query to w.
+func Export(ctx context.Context, source ProductSource, w io.Writer, format string, columns []string, query string) error {
+	writer, err := NewWriter(w, format, columns)
+
+	if err != nil {
+		return err
+	}
+
+	if err := source.Each(ctx, query, writer.Write); err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
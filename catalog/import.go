@@ -0,0 +1,247 @@
+// Package catalog reads and writes products in the file formats used by
+// bulk imports and exports.
+package catalog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"product/models"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// ErrUnknownFormat is returned for uploads that are neither CSV nor JSON Lines.
+var ErrUnknownFormat = errors.New("unknown file format, expected csv or jsonl")
+
+// Columns lists the CSV columns of a product, in the order they are exported.
+var Columns = []string{"sku", "name", "description", "price", "stock"}
+
+// DetectFormat picks the format of an upload from its content type, falling
+// back to the extension of its file name.
+func DetectFormat(contentType string, filename string) (string, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch mediaType {
+	case "text/csv":
+		return FormatCSV, nil
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return FormatJSONL, nil
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".jsonl", ".ndjson":
+		return FormatJSONL, nil
+	}
+
+	return "", ErrUnknownFormat
+}
+
+// Scan reads the products of an upload, passing them one at a time to fn.
+// Lines that cannot be read are passed with their error so they show up in
+// the import report; only a file that cannot be read at all fails. It stops
+// at the first error of fn.
+func Scan(r io.Reader, format string, fn func(row models.ImportRow) error) error {
+	switch format {
+	case FormatCSV:
+		return scanCSV(r, fn)
+	case FormatJSONL:
+		return scanJSONL(r, fn)
+	default:
+		return ErrUnknownFormat
+	}
+}
+
+// scanCSV reads a CSV file whose first record names the columns.
+func scanCSV(r io.Reader, fn func(row models.ImportRow) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+
+	if err == io.EOF {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("read csv header: %w", err)
+	}
+
+	index := map[string]int{}
+
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+
+		if !isColumn(column) {
+			return fmt.Errorf("unknown csv column %q", column)
+		}
+
+		index[column] = i
+	}
+
+	for {
+		record, err := reader.Read()
+
+		if err == io.EOF {
+			return nil
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := models.ImportRow{}
+
+		if err != nil {
+			var parseErr *csv.ParseError
+
+			if !errors.As(err, &parseErr) {
+				return err
+			}
+
+			row = models.ImportRow{Line: parseErr.StartLine, Error: parseErr.Err.Error()}
+		} else {
+			row = csvRow(line, record, index)
+		}
+
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+}
+
+func csvRow(line int, record []string, index map[string]int) models.ImportRow {
+	row := models.ImportRow{Line: line}
+
+	value := func(column string) string {
+		i, ok := index[column]
+
+		if !ok || i >= len(record) {
+			return ""
+		}
+
+		return strings.TrimSpace(record[i])
+	}
+
+	row.Request = models.ProductRequest{
+		SKU:         value("sku"),
+		Name:        value("name"),
+		Description: value("description"),
+	}
+
+	var err error
+
+	if price := value("price"); price != "" {
+		if row.Request.Price, err = strconv.Atoi(price); err != nil {
+			row.Error = "price must be an integer"
+		}
+	}
+
+	if stock := value("stock"); stock != "" {
+		if row.Request.Stock, err = strconv.Atoi(stock); err != nil {
+			row.Error = "stock must be an integer"
+		}
+	}
+
+	return row
+}
+
+func isColumn(column string) bool {
+	for _, c := range Columns {
+		if c == column {
+			return true
+		}
+	}
+
+	return false
+}
+
+// scanJSONL reads one JSON product per line, skipping blank lines.
+func scanJSONL(r io.Reader, fn func(row models.ImportRow) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+
+		if len(text) == 0 {
+			continue
+		}
+
+		row := models.ImportRow{Line: line}
+
+		decoder := json.NewDecoder(bytes.NewReader(text))
+		decoder.DisallowUnknownFields()
+
+		if err := decoder.Decode(&row.Request); err != nil {
+			row.Error = "invalid json: " + err.Error()
+		}
+
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read jsonl: %w", err)
+	}
+
+	return nil
+}
+
+// FileSource is an upload spooled to a file, read again on every Each so
+// that its rows are never all held in memory.
+type FileSource struct {
+	Path   string
+	Format string
+}
+
+// Spool copies the upload read from r to a temporary file. The caller has to
+// Remove it once the import is done.
+func Spool(r io.Reader, format string) (FileSource, error) {
+	file, err := os.CreateTemp("", "product-import-*")
+
+	if err != nil {
+		return FileSource{}, err
+	}
+
+	defer file.Close()
+
+	source := FileSource{Path: file.Name(), Format: format}
+
+	if _, err := io.Copy(file, r); err != nil {
+		source.Remove()
+		return FileSource{}, err
+	}
+
+	return source, nil
+}
+
+func (fs FileSource) Each(fn func(row models.ImportRow) error) error {
+	file, err := os.Open(fs.Path)
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	return Scan(bufio.NewReader(file), fs.Format, fn)
+}
+
+func (fs FileSource) Remove() error {
+	return os.Remove(fs.Path)
+}
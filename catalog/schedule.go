@@ -0,0 +1,85 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"product/config"
+	"time"
+)
+
+// Scheduler exports the whole catalog to a local directory at a fixed
+// interval, for partners collecting the files from there.
+type Scheduler struct {
+	source ProductSource
+	cfg    config.ExportConfig
+	logger *slog.Logger
+}
+
+func NewScheduler(source ProductSource, cfg config.ExportConfig, logger *slog.Logger) *Scheduler {
+	return &Scheduler{
+		source: source,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// Run exports every interval until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			path, err := s.Export(ctx, now)
+
+			if err != nil {
+				s.logger.ErrorContext(ctx, "scheduled export failed", "error", err)
+				continue
+			}
+
+			s.logger.InfoContext(ctx, "scheduled export written", "path", path)
+		}
+	}
+}
+
+// Export writes the catalog to a file named after now and returns its path.
+// The file is written under a temporary name and renamed once complete, so
+// readers of the directory never see a partial export.
+func (s *Scheduler) Export(ctx context.Context, now time.Time) (string, error) {
+	if err := os.MkdirAll(s.cfg.Directory, 0o755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(s.cfg.Directory, fmt.Sprintf("products-%s.%s", now.UTC().Format("20060102T150405Z"), s.cfg.Format))
+
+	file, err := os.CreateTemp(s.cfg.Directory, ".products-*.tmp")
+
+	if err != nil {
+		return "", err
+	}
+
+	defer os.Remove(file.Name())
+
+	columns := s.cfg.Columns
+
+	if len(columns) == 0 {
+		columns = ExportColumns
+	}
+
+	if err := Export(ctx, s.source, file, s.cfg.Format, columns, s.cfg.Query); err != nil {
+		file.Close()
+		return "", err
+	}
+
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+
+	return path, os.Rename(file.Name(), path)
+}
@@ -0,0 +1,117 @@
+package catalog
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"product/models"
+	"strconv"
+)
+
+// The fixed parts of a workbook holding a single sheet.
+var xlsxParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Products" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+}
+
+// xlsxWriter writes a workbook row by row. The sheet is the last entry of
+// the zip archive, so rows go straight to the output as they come.
+type xlsxWriter struct {
+	archive *zip.Writer
+	sheet   *bufio.Writer
+	columns []string
+}
+
+func newXLSXWriter(w io.Writer, columns []string) (*xlsxWriter, error) {
+	archive := zip.NewWriter(w)
+
+	for _, part := range xlsxParts {
+		entry, err := archive.Create(part.name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := io.WriteString(entry, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	entry, err := archive.Create("xl/worksheets/sheet1.xml")
+
+	if err != nil {
+		return nil, err
+	}
+
+	xw := &xlsxWriter{
+		archive: archive,
+		sheet:   bufio.NewWriter(entry),
+		columns: columns,
+	}
+
+	xw.sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	xw.sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	header := make([]any, len(columns))
+
+	for i, column := range columns {
+		header[i] = column
+	}
+
+	return xw, xw.row(header)
+}
+
+func (xw *xlsxWriter) Write(product models.Product) error {
+	cells := make([]any, len(xw.columns))
+
+	for i, column := range xw.columns {
+		cells[i] = value(product, column)
+	}
+
+	return xw.row(cells)
+}
+
+// row writes numbers as numbers and everything else as inline strings,
+// which spares the shared strings table.
+func (xw *xlsxWriter) row(cells []any) error {
+	xw.sheet.WriteString("<row>")
+
+	for _, cell := range cells {
+		switch v := cell.(type) {
+		case int64:
+			xw.sheet.WriteString(`<c><v>` + strconv.FormatInt(v, 10) + `</v></c>`)
+		case string:
+			xw.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+
+			if err := xml.EscapeText(xw.sheet, []byte(v)); err != nil {
+				return err
+			}
+
+			xw.sheet.WriteString(`</t></is></c>`)
+		}
+	}
+
+	_, err := xw.sheet.WriteString("</row>")
+
+	return err
+}
+
+func (xw *xlsxWriter) Close() error {
+	xw.sheet.WriteString(`</sheetData></worksheet>`)
+
+	if err := xw.sheet.Flush(); err != nil {
+		return err
+	}
+
+	return xw.archive.Close()
+}
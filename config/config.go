@@ -3,42 +3,510 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"product/money"
+	"product/ratelimit"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	DB_USERNAME    string
-	DB_PASSWORD    string
-	DB_HOST        string
-	DB_PORT        string
-	DB_NAME        string
-	DB_NAME_TEST   string
-	JWT_SECRET_KEY string
+	Server      ServerConfig      `mapstructure:"server"`
+	Database    DatabaseConfig    `mapstructure:"database"`
+	Auth        AuthConfig        `mapstructure:"auth"`
+	Logging     LoggingConfig     `mapstructure:"logging"`
+	Tracing     TracingConfig     `mapstructure:"tracing"`
+	RateLimit   RateLimitConfig   `mapstructure:"rate_limit"`
+	Security    SecurityConfig    `mapstructure:"security"`
+	API         APIConfig         `mapstructure:"api"`
+	Import      ImportConfig      `mapstructure:"import"`
+	Export      ExportConfig      `mapstructure:"export"`
+	Batch       BatchConfig       `mapstructure:"batch"`
+	Idempotency IdempotencyConfig `mapstructure:"idempotency"`
+	Events      EventsConfig      `mapstructure:"events"`
+	Webhooks    WebhooksConfig    `mapstructure:"webhooks"`
+	Outbox      OutboxConfig      `mapstructure:"outbox"`
+	Jobs        JobsConfig        `mapstructure:"jobs"`
+	Cache       CacheConfig       `mapstructure:"cache"`
+	Pricing     PricingConfig     `mapstructure:"pricing"`
+	Money       MoneyConfig       `mapstructure:"money"`
+
+	// file is the configuration file the values were read from, if any.
+	file string
+}
+
+type ServerConfig struct {
+	Host            string        `mapstructure:"host"`
+	Port            int           `mapstructure:"port" validate:"min=1,max=65535"`
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout" validate:"gt=0"`
+	// DrainDelay is how long /readyz reports 503 before the listener closes,
+	// so a load balancer can stop routing to the instance. It should be about
+	// one readiness probe period.
+	DrainDelay time.Duration `mapstructure:"drain_delay" validate:"gte=0"`
+	// RequestTimeout is the default deadline of a request.
+	RequestTimeout time.Duration `mapstructure:"request_timeout" validate:"gt=0" reload:"true"`
+	// RouteTimeouts overrides RequestTimeout per route group. From the
+	// environment it is written as "admin=15s,products=3s".
+	RouteTimeouts map[string]time.Duration `mapstructure:"route_timeouts" reload:"true"`
+	// BodyLimit is the largest request body accepted, in bytes. It bounds
+	// the size of import uploads.
+	BodyLimit int `mapstructure:"body_limit" validate:"gt=0"`
+}
+
+type DatabaseConfig struct {
+	Username string `mapstructure:"username" validate:"required"`
+	Password string `mapstructure:"password" secret:"true"`
+	Host     string `mapstructure:"host" validate:"required"`
+	Port     int    `mapstructure:"port" validate:"min=1,max=65535"`
+	Name     string `mapstructure:"name" validate:"required"`
+	NameTest string `mapstructure:"name_test"`
+}
+
+type AuthConfig struct {
+	JWTSecret string `mapstructure:"jwt_secret" validate:"required" secret:"true"`
+	// SessionCookie is the name of the cookie carrying the session of
+	// browser clients. Requests sending it are subject to CSRF checks.
+	SessionCookie string `mapstructure:"session_cookie" validate:"required"`
+	// SessionStore is "memory" for a single instance or "database" to keep
+	// sessions across restarts and instances.
+	SessionStore string `mapstructure:"session_store" validate:"oneof=memory database"`
+	// SessionTTL is how long a session lasts without being used.
+	SessionTTL            time.Duration `mapstructure:"session_ttl" validate:"gt=0"`
+	SessionCookieSecure   bool          `mapstructure:"session_cookie_secure"`
+	SessionCookieSameSite string        `mapstructure:"session_cookie_same_site" validate:"oneof=Lax Strict None"`
+}
+
+type LoggingConfig struct {
+	Level  string `mapstructure:"level" validate:"oneof=debug info warn error" reload:"true"`
+	Format string `mapstructure:"format" validate:"oneof=json text"`
+}
+
+type RateLimitConfig struct {
+	// Store is "memory" for a single instance or "database" to share the
+	// buckets between instances.
+	Store string `mapstructure:"store" validate:"oneof=memory database"`
+	// Policies are keyed by route group: auth, products and api.
+	Policies map[string]ratelimit.Policy `mapstructure:"policies" validate:"dive" reload:"true"`
+	// APIKeys are the SHA-256 digests, hex encoded, of the API keys requests
+	// can be limited by. Requests with other keys are limited by IP.
+	APIKeys []string `mapstructure:"api_keys" validate:"dive,len=64,hexadecimal" reload:"true"`
+}
+
+type SecurityConfig struct {
+	CORS    CORSConfig    `mapstructure:"cors" reload:"true"`
+	Headers HeadersConfig `mapstructure:"headers" reload:"true"`
+	CSRF    CSRFConfig    `mapstructure:"csrf" reload:"true"`
+}
+
+// CORSConfig lists what browsers on other origins may do. From the
+// environment the lists are written comma separated. CORS is disabled while
+// AllowedOrigins is empty.
+type CORSConfig struct {
+	AllowedOrigins   []string      `mapstructure:"allowed_origins" validate:"dive,required"`
+	AllowedMethods   []string      `mapstructure:"allowed_methods"`
+	AllowedHeaders   []string      `mapstructure:"allowed_headers"`
+	ExposedHeaders   []string      `mapstructure:"exposed_headers"`
+	AllowCredentials bool          `mapstructure:"allow_credentials"`
+	MaxAge           time.Duration `mapstructure:"max_age" validate:"min=0"`
+}
+
+type HeadersConfig struct {
+	// HSTSMaxAge is only sent over HTTPS; zero disables HSTS.
+	HSTSMaxAge            time.Duration `mapstructure:"hsts_max_age" validate:"min=0"`
+	HSTSIncludeSubdomains bool          `mapstructure:"hsts_include_subdomains"`
+	ContentSecurityPolicy string        `mapstructure:"content_security_policy"`
+	FrameOptions          string        `mapstructure:"frame_options" validate:"omitempty,oneof=DENY SAMEORIGIN"`
+	ContentTypeNosniff    bool          `mapstructure:"content_type_nosniff"`
+}
+
+// CSRFConfig protects requests authenticated by the session cookie with a
+// double submit token: the token is issued in CookieName and has to be sent
+// back in HeaderName. Bearer token requests are not affected.
+type CSRFConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	CookieName     string        `mapstructure:"cookie_name" validate:"required_if=Enabled true"`
+	HeaderName     string        `mapstructure:"header_name" validate:"required_if=Enabled true"`
+	CookieSecure   bool          `mapstructure:"cookie_secure"`
+	CookieSameSite string        `mapstructure:"cookie_same_site" validate:"oneof=Lax Strict None"`
+	Expiration     time.Duration `mapstructure:"expiration" validate:"gt=0"`
+}
+
+type APIConfig struct {
+	// Deprecations announces the retirement of API versions, keyed by
+	// version, e.g. "v1".
+	Deprecations map[string]DeprecationConfig `mapstructure:"deprecations" validate:"dive"`
+}
+
+// DeprecationConfig is sent in the Deprecation, Sunset and Link headers of
+// every response of a deprecated version.
+type DeprecationConfig struct {
+	// Deprecation is when the version was deprecated.
+	Deprecation time.Time `mapstructure:"deprecation" validate:"required"`
+	// Sunset is when the version stops working, if known.
+	Sunset time.Time `mapstructure:"sunset"`
+	// Link points to the migration guide.
+	Link string `mapstructure:"link" validate:"omitempty,url"`
+}
+
+type ImportConfig struct {
+	// AsyncRows is the number of rows above which an import runs as a
+	// background job even when the request did not ask for it.
+	AsyncRows int `mapstructure:"async_rows" validate:"gt=0"`
+}
+
+type IdempotencyConfig struct {
+	// Store is "memory" for a single instance or "database" to share the
+	// keys between instances.
+	Store string `mapstructure:"store" validate:"oneof=memory database"`
+	// TTL is how long a key is remembered.
+	TTL time.Duration `mapstructure:"ttl" validate:"gt=0" reload:"true"`
+	// Lease is how long a key is held by a request in progress. Past it,
+	// as after a crash, a retry runs the request again. It has to outlast
+	// the route timeouts.
+	Lease time.Duration `mapstructure:"lease" validate:"gt=0" reload:"true"`
+}
+
+type EventsConfig struct {
+	// LowStockThreshold raises stock.low when the stock of a product drops
+	// below it. 0 disables the event.
+	LowStockThreshold int `mapstructure:"low_stock_threshold" validate:"min=0"`
+}
+
+// WebhooksConfig tunes the delivery of events to webhooks. Every
+// PollInterval, up to BatchSize queued messages are delivered by Workers
+// workers. A failed delivery is attempted again after InitialBackoff, then
+// twice as long every time up to MaxBackoff, for MaxAttempts attempts in all.
+type WebhooksConfig struct {
+	Workers        int           `mapstructure:"workers" validate:"gt=0"`
+	PollInterval   time.Duration `mapstructure:"poll_interval" validate:"gt=0"`
+	BatchSize      int           `mapstructure:"batch_size" validate:"gt=0"`
+	Timeout        time.Duration `mapstructure:"timeout" validate:"gt=0"`
+	MaxAttempts    int           `mapstructure:"max_attempts" validate:"gt=0"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff" validate:"gt=0"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff" validate:"gtefield=InitialBackoff"`
+}
+
+// OutboxConfig tunes the relay of the events written to the outbox. Sink is
+// "bus" to hand them to the in-process subscribers, "http" to post them to
+// URL or "file" to append them to File. The webhooks are fed whatever the
+// sink.
+type OutboxConfig struct {
+	Sink   string `mapstructure:"sink" validate:"oneof=bus http file"`
+	URL    string `mapstructure:"url" validate:"required_if=Sink http,omitempty,url"`
+	Secret string `mapstructure:"secret" secret:"true"`
+	File   string `mapstructure:"file" validate:"required_if=Sink file"`
+	// Timeout bounds a post to URL.
+	Timeout      time.Duration `mapstructure:"timeout" validate:"gt=0"`
+	PollInterval time.Duration `mapstructure:"poll_interval" validate:"gt=0"`
+	BatchSize    int           `mapstructure:"batch_size" validate:"gt=0"`
+	// A message is retried after InitialBackoff, then twice as long every
+	// time up to MaxBackoff, and marked failed after MaxAttempts attempts.
+	MaxAttempts    int           `mapstructure:"max_attempts" validate:"gt=0"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff" validate:"gt=0"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff" validate:"gtefield=InitialBackoff"`
+	// Retention is how long published messages are kept.
+	Retention time.Duration `mapstructure:"retention" validate:"gt=0"`
+}
+
+type JobsConfig struct {
+	// Concurrency is the number of jobs an instance runs at once.
+	Concurrency  int           `mapstructure:"concurrency" validate:"gt=0"`
+	PollInterval time.Duration `mapstructure:"poll_interval" validate:"gt=0"`
+	// Timeout bounds a run of a job. A job running for twice as long is
+	// taken for abandoned by a crashed instance and queued again.
+	Timeout time.Duration `mapstructure:"timeout" validate:"gt=0"`
+	// A job is retried after InitialBackoff, then twice as long every time
+	// up to MaxBackoff, and moved to the dead letters after MaxAttempts
+	// attempts.
+	MaxAttempts    int           `mapstructure:"max_attempts" validate:"gt=0"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff" validate:"gt=0"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff" validate:"gtefield=InitialBackoff"`
+	// Retention is how long succeeded and cancelled jobs are kept.
+	Retention time.Duration `mapstructure:"retention" validate:"gt=0"`
+}
+
+// CacheConfig caches the product listings. Store is "memory" for an LRU of
+// MaxEntries entries per instance, or "database" to share the cache, and its
+// invalidations, between instances. With the memory store an instance only
+// sees the writes made through another once TTL has passed.
+type CacheConfig struct {
+	Store string `mapstructure:"store" validate:"oneof=memory database"`
+	// TTL of zero disables the cache.
+	TTL        time.Duration `mapstructure:"ttl" validate:"min=0" reload:"true"`
+	MaxEntries int           `mapstructure:"max_entries" validate:"gt=0"`
+	// MaxAge is how long clients may reuse a listing, sent in Cache-Control.
+	// It cannot exceed TTL, and zero makes clients check back every time.
+	MaxAge time.Duration `mapstructure:"max_age" validate:"min=0,ltefield=TTL" reload:"true"`
+}
+
+type PricingConfig struct {
+	// Interval is how often due price schedules are applied.
+	Interval time.Duration `mapstructure:"interval" validate:"gt=0"`
+}
+
+// MoneyConfig sets the currency of the prices of the products and how
+// amounts converted to other currencies are rounded.
+type MoneyConfig struct {
+	// BaseCurrency is the ISO 4217 currency the prices are in, counted in
+	// its minor units: a price of 1234 in USD is $12.34. It is stored with
+	// the prices, and cannot be changed once products are stored.
+	BaseCurrency string `mapstructure:"base_currency" validate:"iso4217"`
+	// Rounding is keyed by currency code. The other currencies are rounded
+	// half up to their minor unit.
+	Rounding map[string]money.Rounding `mapstructure:"rounding" validate:"dive"`
+}
+
+type BatchConfig struct {
+	// MaxOperations caps the operations of one POST /products/batch.
+	MaxOperations int `mapstructure:"max_operations" validate:"gt=0"`
+}
+
+// ExportConfig schedules exports of the catalog to Directory. They are
+// disabled while Interval is zero.
+type ExportConfig struct {
+	Interval  time.Duration `mapstructure:"interval" validate:"min=0"`
+	Directory string        `mapstructure:"directory" validate:"required_unless=Interval 0"`
+	Format    string        `mapstructure:"format" validate:"oneof=csv jsonl xlsx"`
+	// Columns defaults to every column.
+	Columns []string `mapstructure:"columns" validate:"dive,oneof=id sku name description price stock user_id"`
+	// Query filters the products like the q parameter of the listing.
+	Query string `mapstructure:"query"`
+}
+
+type TracingConfig struct {
+	Exporter string `mapstructure:"exporter" validate:"oneof=none otlp stdout file"`
+	File     string `mapstructure:"file" validate:"required_if=Exporter file"`
 }
 
 var Cfg *Config
 
-func InitConfig() {
+// settings lists every key with its default value and the environment
+// variables it is read from. The first variable is derived from the key, the
+// others are the names used before the configuration was nested.
+var settings = []struct {
+	key          string
+	defaultValue any
+	env          []string
+}{
+	{"server.host", "", []string{"SERVER_HOST"}},
+	{"server.port", 3000, []string{"SERVER_PORT"}},
+	{"server.shutdown_timeout", "10s", []string{"SERVER_SHUTDOWN_TIMEOUT", "SHUTDOWN_TIMEOUT"}},
+	{"server.drain_delay", "5s", []string{"SERVER_DRAIN_DELAY", "DRAIN_DELAY"}},
+	{"server.request_timeout", "5s", []string{"SERVER_REQUEST_TIMEOUT", "REQUEST_TIMEOUT"}},
+	{"server.route_timeouts", map[string]time.Duration{}, []string{"SERVER_ROUTE_TIMEOUTS", "ROUTE_TIMEOUTS"}},
+	{"server.body_limit", 32 << 20, []string{"SERVER_BODY_LIMIT", "BODY_LIMIT"}},
+	{"database.username", "", []string{"DATABASE_USERNAME", "DB_USERNAME"}},
+	{"database.password", "", []string{"DATABASE_PASSWORD", "DB_PASSWORD"}},
+	{"database.host", "127.0.0.1", []string{"DATABASE_HOST", "DB_HOST"}},
+	{"database.port", 3306, []string{"DATABASE_PORT", "DB_PORT"}},
+	{"database.name", "", []string{"DATABASE_NAME", "DB_NAME"}},
+	{"database.name_test", "", []string{"DATABASE_NAME_TEST", "DB_NAME_TEST"}},
+	{"auth.jwt_secret", "", []string{"AUTH_JWT_SECRET", "JWT_SECRET_KEY"}},
+	{"auth.session_cookie", "session", []string{"AUTH_SESSION_COOKIE"}},
+	{"auth.session_store", "memory", []string{"AUTH_SESSION_STORE", "SESSION_STORE"}},
+	{"auth.session_ttl", "24h", []string{"AUTH_SESSION_TTL", "SESSION_TTL"}},
+	{"auth.session_cookie_secure", true, []string{"AUTH_SESSION_COOKIE_SECURE"}},
+	{"auth.session_cookie_same_site", "Lax", []string{"AUTH_SESSION_COOKIE_SAME_SITE"}},
+	{"logging.level", "info", []string{"LOGGING_LEVEL", "LOG_LEVEL"}},
+	{"logging.format", "json", []string{"LOGGING_FORMAT", "LOG_FORMAT"}},
+	{"tracing.exporter", "none", []string{"TRACING_EXPORTER", "TRACE_EXPORTER"}},
+	{"tracing.file", "", []string{"TRACING_FILE", "TRACE_FILE"}},
+	{"rate_limit.store", "memory", []string{"RATE_LIMIT_STORE"}},
+	{"rate_limit.policies", map[string]any{
+		"auth":     map[string]any{"limit": 10, "period": "1m", "key": ratelimit.KeyIP},
+		"products": map[string]any{"limit": 120, "period": "1m", "key": ratelimit.KeyIP},
+		"api":      map[string]any{"limit": 300, "period": "1m", "key": ratelimit.KeyUser},
+	}, nil},
+	{"rate_limit.api_keys", []string{}, []string{"RATE_LIMIT_API_KEYS"}},
+	{"security.cors.allowed_origins", []string{}, []string{"SECURITY_CORS_ALLOWED_ORIGINS", "CORS_ALLOWED_ORIGINS"}},
+	{"security.cors.allowed_methods", []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}, []string{"SECURITY_CORS_ALLOWED_METHODS"}},
+	{"security.cors.allowed_headers", []string{"Authorization", "Content-Type", "X-Request-ID", "X-CSRF-Token", "X-API-Key", "API-Version", "Idempotency-Key", "Accept-Currency"}, []string{"SECURITY_CORS_ALLOWED_HEADERS"}},
+	{"security.cors.exposed_headers", []string{"X-Request-ID", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After", "API-Version", "Deprecation", "Sunset", "Link", "Idempotent-Replayed"}, []string{"SECURITY_CORS_EXPOSED_HEADERS"}},
+	{"security.cors.allow_credentials", false, []string{"SECURITY_CORS_ALLOW_CREDENTIALS", "CORS_ALLOW_CREDENTIALS"}},
+	{"security.cors.max_age", "10m", []string{"SECURITY_CORS_MAX_AGE"}},
+	{"security.headers.hsts_max_age", "8760h", []string{"SECURITY_HEADERS_HSTS_MAX_AGE"}},
+	{"security.headers.hsts_include_subdomains", true, []string{"SECURITY_HEADERS_HSTS_INCLUDE_SUBDOMAINS"}},
+	{"security.headers.content_security_policy", "default-src 'none'; frame-ancestors 'none'", []string{"SECURITY_HEADERS_CONTENT_SECURITY_POLICY"}},
+	{"security.headers.frame_options", "DENY", []string{"SECURITY_HEADERS_FRAME_OPTIONS"}},
+	{"security.headers.content_type_nosniff", true, []string{"SECURITY_HEADERS_CONTENT_TYPE_NOSNIFF"}},
+	{"security.csrf.enabled", false, []string{"SECURITY_CSRF_ENABLED", "CSRF_ENABLED"}},
+	{"security.csrf.cookie_name", "csrf_token", []string{"SECURITY_CSRF_COOKIE_NAME"}},
+	{"security.csrf.header_name", "X-CSRF-Token", []string{"SECURITY_CSRF_HEADER_NAME"}},
+	{"security.csrf.cookie_secure", true, []string{"SECURITY_CSRF_COOKIE_SECURE"}},
+	{"security.csrf.cookie_same_site", "Lax", []string{"SECURITY_CSRF_COOKIE_SAME_SITE"}},
+	{"security.csrf.expiration", "12h", []string{"SECURITY_CSRF_EXPIRATION"}},
+	{"api.deprecations", map[string]any{}, nil},
+	{"import.async_rows", 1000, []string{"IMPORT_ASYNC_ROWS"}},
+	{"idempotency.store", "memory", []string{"IDEMPOTENCY_STORE"}},
+	{"idempotency.ttl", "24h", []string{"IDEMPOTENCY_TTL"}},
+	{"idempotency.lease", "1m", []string{"IDEMPOTENCY_LEASE"}},
+	{"events.low_stock_threshold", 10, []string{"EVENTS_LOW_STOCK_THRESHOLD"}},
+	{"webhooks.workers", 4, []string{"WEBHOOKS_WORKERS"}},
+	{"webhooks.poll_interval", "1s", []string{"WEBHOOKS_POLL_INTERVAL"}},
+	{"webhooks.batch_size", 100, []string{"WEBHOOKS_BATCH_SIZE"}},
+	{"webhooks.timeout", "10s", []string{"WEBHOOKS_TIMEOUT"}},
+	{"webhooks.max_attempts", 6, []string{"WEBHOOKS_MAX_ATTEMPTS"}},
+	{"webhooks.initial_backoff", "30s", []string{"WEBHOOKS_INITIAL_BACKOFF"}},
+	{"webhooks.max_backoff", "1h", []string{"WEBHOOKS_MAX_BACKOFF"}},
+	{"outbox.sink", "bus", []string{"OUTBOX_SINK"}},
+	{"outbox.url", "", []string{"OUTBOX_URL"}},
+	{"outbox.secret", "", []string{"OUTBOX_SECRET"}},
+	{"outbox.file", "", []string{"OUTBOX_FILE"}},
+	{"outbox.timeout", "10s", []string{"OUTBOX_TIMEOUT"}},
+	{"outbox.poll_interval", "1s", []string{"OUTBOX_POLL_INTERVAL"}},
+	{"outbox.batch_size", 100, []string{"OUTBOX_BATCH_SIZE"}},
+	{"outbox.max_attempts", 10, []string{"OUTBOX_MAX_ATTEMPTS"}},
+	{"outbox.initial_backoff", "1s", []string{"OUTBOX_INITIAL_BACKOFF"}},
+	{"outbox.max_backoff", "10m", []string{"OUTBOX_MAX_BACKOFF"}},
+	{"outbox.retention", "168h", []string{"OUTBOX_RETENTION"}},
+	{"jobs.concurrency", 4, []string{"JOBS_CONCURRENCY"}},
+	{"jobs.poll_interval", "1s", []string{"JOBS_POLL_INTERVAL"}},
+	{"jobs.timeout", "5m", []string{"JOBS_TIMEOUT"}},
+	{"jobs.max_attempts", 5, []string{"JOBS_MAX_ATTEMPTS"}},
+	{"jobs.initial_backoff", "10s", []string{"JOBS_INITIAL_BACKOFF"}},
+	{"jobs.max_backoff", "1h", []string{"JOBS_MAX_BACKOFF"}},
+	{"jobs.retention", "168h", []string{"JOBS_RETENTION"}},
+	{"cache.store", "memory", []string{"CACHE_STORE"}},
+	{"cache.ttl", "1m", []string{"CACHE_TTL"}},
+	{"cache.max_entries", 10000, []string{"CACHE_MAX_ENTRIES"}},
+	{"cache.max_age", "0s", []string{"CACHE_MAX_AGE"}},
+	{"pricing.interval", "10s", []string{"PRICING_INTERVAL"}},
+	{"money.base_currency", "USD", []string{"MONEY_BASE_CURRENCY"}},
+	{"money.rounding", map[string]any{}, nil},
+	{"batch.max_operations", 1000, []string{"BATCH_MAX_OPERATIONS"}},
+	{"export.interval", "0s", []string{"EXPORT_INTERVAL"}},
+	{"export.directory", "exports", []string{"EXPORT_DIRECTORY"}},
+	{"export.format", "csv", []string{"EXPORT_FORMAT"}},
+	{"export.columns", []string{}, []string{"EXPORT_COLUMNS"}},
+	{"export.query", "", []string{"EXPORT_QUERY"}},
+}
+
+// flags maps command-line flags to configuration keys.
+var flags = []struct {
+	name  string
+	key   string
+	usage string
+}{
+	{"host", "server.host", "address to listen on"},
+	{"port", "server.port", "port to listen on"},
+	{"db-host", "database.host", "database host"},
+	{"db-port", "database.port", "database port"},
+	{"db-name", "database.name", "database name"},
+	{"log-level", "logging.level", "log level: debug, info, warn or error"},
+	{"log-format", "logging.format", "log format: json or text"},
+}
+
+// Load builds the configuration from, in increasing order of precedence,
+// the defaults, the YAML or TOML file given by --config, the environment
+// (including a .env file in the working directory) and the command-line
+// flags in args. It returns the positional arguments left after the flags.
+// The configuration is validated, so a missing secret fails here rather than
+// at the first request.
+func Load(args []string) (*Config, []string, error) {
+	v := viper.New()
+
+	for _, setting := range settings {
+		v.SetDefault(setting.key, setting.defaultValue)
+
+		if err := v.BindEnv(append([]string{setting.key}, setting.env...)...); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	flagSet := pflag.NewFlagSet("product", pflag.ContinueOnError)
+	configFile := flagSet.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML configuration file")
+
+	for _, flag := range flags {
+		switch v.Get(flag.key).(type) {
+		case int:
+			flagSet.Int(flag.name, 0, flag.usage)
+		default:
+			flagSet.String(flag.name, "", flag.usage)
+		}
+
+		if err := v.BindPFlag(flag.key, flagSet.Lookup(flag.name)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := flagSet.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if err := loadDotEnv(".env"); err != nil {
+		return nil, nil, err
+	}
+
+	if *configFile != "" {
+		v.SetConfigFile(*configFile)
+
+		if err := v.ReadInConfig(); err != nil {
+			return nil, nil, fmt.Errorf("read config file: %w", err)
+		}
+	}
+
 	cfg := &Config{
-		DB_USERNAME:    os.Getenv("DB_USERNAME"),
-		DB_PASSWORD:    os.Getenv("DB_PASSWORD"),
-		DB_HOST:        os.Getenv("DB_HOST"),
-		DB_PORT:        os.Getenv("DB_PORT"),
-		DB_NAME:        os.Getenv("DB_NAME"),
-		DB_NAME_TEST:   os.Getenv("DB_NAME_TEST"),
-		JWT_SECRET_KEY: os.Getenv("JWT_SECRET_KEY"),
+		file: *configFile,
+	}
+
+	err := v.Unmarshal(cfg, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		stringToDurationMapHook,
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		mapstructure.StringToTimeHookFunc(time.RFC3339),
+	)))
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("decode config: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	return cfg, flagSet.Args(), nil
+}
+
+func (c *Config) Validate() error {
+	if err := validator.New().Struct(c); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	// The keys of maps are lowercased, so the currencies are checked here.
+	if _, err := money.NewRules(c.Money.Rounding); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	return nil
+}
+
+// loadDotEnv exports the variables of a .env file that are not already set
+// in the environment. A missing file is not an error.
+func loadDotEnv(path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	dotEnv := viper.New()
+	dotEnv.SetConfigFile(path)
+	dotEnv.SetConfigType("env")
+
+	if err := dotEnv.ReadInConfig(); err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
 	}
 
-	viper.SetConfigName(".env")
-	viper.SetConfigType("env")
-	viper.AddConfigPath(".")
+	for _, key := range dotEnv.AllKeys() {
+		name := strings.ToUpper(key)
 
-	if err := viper.ReadInConfig(); err != nil {
-		fmt.Println(err)
-	} else {
-		_ = viper.Unmarshal(cfg)
+		if _, ok := os.LookupEnv(name); !ok {
+			os.Setenv(name, dotEnv.GetString(key))
+		}
 	}
 
-	Cfg = cfg
+	return nil
 }
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"io"
+	"reflect"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "******"
+
+// Print writes the configuration as YAML. Fields tagged secret:"true" are
+// redacted so the output can be shared safely.
+func (c *Config) Print(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	return encoder.Encode(printable(reflect.ValueOf(*c)))
+}
+
+func printable(v reflect.Value) any {
+	switch value := v.Interface().(type) {
+	case time.Duration:
+		return value.String()
+	case map[string]time.Duration:
+		result := map[string]string{}
+
+		for name, timeout := range value {
+			result[name] = timeout.String()
+		}
+
+		return result
+	}
+
+	if v.Kind() != reflect.Struct {
+		return v.Interface()
+	}
+
+	result := yaml.Node{Kind: yaml.MappingNode}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+
+		if !field.IsExported() {
+			continue
+		}
+
+		var value any = printable(v.Field(i))
+
+		if field.Tag.Get("secret") == "true" && v.Field(i).String() != "" {
+			value = redacted
+		}
+
+		valueNode := yaml.Node{}
+		valueNode.Encode(value)
+
+		result.Content = append(result.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: field.Tag.Get("mapstructure")},
+			&valueNode,
+		)
+	}
+
+	return &result
+}
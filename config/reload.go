@@ -0,0 +1,220 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const reloadDebounce = 200 * time.Millisecond
+
+// Reloader holds the live configuration. Only fields tagged reload:"true" are
+// replaced on reload; changes to any other field are reported and ignored
+// until the next restart.
+type Reloader struct {
+	args        []string
+	logger      *slog.Logger
+	current     atomic.Pointer[Config]
+	mu          sync.Mutex
+	subscribers []func(cfg *Config)
+}
+
+// NewReloader starts from cfg. args are the command-line arguments cfg was
+// loaded from, so flags keep their precedence on every reload.
+func NewReloader(cfg *Config, args []string, logger *slog.Logger) *Reloader {
+	r := &Reloader{
+		args:   args,
+		logger: logger,
+	}
+
+	r.current.Store(cfg)
+
+	return r
+}
+
+func (r *Reloader) Current() *Config {
+	return r.current.Load()
+}
+
+// Subscribe registers fn to be called with the new configuration after every
+// reload that changed something.
+func (r *Reloader) Subscribe(fn func(cfg *Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.subscribers = append(r.subscribers, fn)
+}
+
+// Reload loads the configuration again. An invalid configuration is rejected
+// and the current one is kept.
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loaded, _, err := Load(r.args)
+
+	if err != nil {
+		r.logger.Error("configuration reload rejected", "error", err)
+		return err
+	}
+
+	current := r.Current()
+	next := *current
+
+	copyReloadable(reflect.ValueOf(&next).Elem(), reflect.ValueOf(loaded).Elem())
+
+	if ignored := diff(&next, loaded); len(ignored) > 0 {
+		r.logger.Warn("configuration changes require a restart", "changes", ignored)
+	}
+
+	changes := diff(current, &next)
+
+	if len(changes) == 0 {
+		r.logger.Info("configuration reloaded without changes")
+		return nil
+	}
+
+	r.current.Store(&next)
+
+	r.logger.Info("configuration reloaded", "changes", changes)
+
+	for _, subscriber := range r.subscribers {
+		subscriber(&next)
+	}
+
+	return nil
+}
+
+// Run reloads the configuration on SIGHUP and whenever the configuration
+// file changes, until ctx is cancelled.
+func (r *Reloader) Run(ctx context.Context) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	var events chan fsnotify.Event
+	file := r.Current().file
+
+	if file != "" {
+		watcher, err := fsnotify.NewWatcher()
+
+		if err != nil {
+			r.logger.Error("failed to watch configuration file", "error", err)
+		} else {
+			defer watcher.Close()
+
+			// Editors often replace the file instead of writing to it, so the
+			// directory is watched rather than the file itself.
+			if err := watcher.Add(filepath.Dir(file)); err != nil {
+				r.logger.Error("failed to watch configuration file", "error", err)
+			}
+
+			events = watcher.Events
+		}
+	}
+
+	debounce := time.NewTimer(reloadDebounce)
+	debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			r.Reload()
+		case event := <-events:
+			if filepath.Clean(event.Name) == filepath.Clean(file) && event.Op&(fsnotify.Write|fsnotify.Create) != 0 {
+				debounce.Reset(reloadDebounce)
+			}
+		case <-debounce.C:
+			r.Reload()
+		}
+	}
+}
+
+// Change describes a configuration value that differs between two configurations.
+type Change struct {
+	Key string `json:"key"`
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Key, c.Old, c.New)
+}
+
+func copyReloadable(dst reflect.Value, src reflect.Value) {
+	for i := 0; i < dst.NumField(); i++ {
+		field := dst.Type().Field(i)
+
+		if !field.IsExported() {
+			continue
+		}
+
+		switch {
+		case field.Tag.Get("reload") == "true":
+			dst.Field(i).Set(src.Field(i))
+		case field.Type.Kind() == reflect.Struct:
+			copyReloadable(dst.Field(i), src.Field(i))
+		}
+	}
+}
+
+// diff lists the values that differ between old and new, with secrets redacted.
+func diff(old *Config, new *Config) []Change {
+	oldValues := map[string]string{}
+	newValues := map[string]string{}
+
+	flatten(reflect.ValueOf(*old), "", oldValues)
+	flatten(reflect.ValueOf(*new), "", newValues)
+
+	changes := []Change{}
+
+	for key, value := range newValues {
+		if oldValues[key] != value {
+			changes = append(changes, Change{Key: key, Old: oldValues[key], New: value})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+
+	return changes
+}
+
+func flatten(v reflect.Value, prefix string, values map[string]string) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+
+		if !field.IsExported() {
+			continue
+		}
+
+		key := prefix + field.Tag.Get("mapstructure")
+
+		if field.Type.Kind() == reflect.Struct {
+			flatten(v.Field(i), key+".", values)
+			continue
+		}
+
+		value := fmt.Sprint(printable(v.Field(i)))
+
+		if field.Tag.Get("secret") == "true" && v.Field(i).String() != "" {
+			value = redacted
+		}
+
+		values[key] = value
+	}
+}
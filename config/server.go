@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Address is the host and port the HTTP server listens on. An empty host
+// listens on every interface.
+func (c *Config) Address() string {
+	return net.JoinHostPort(c.Server.Host, strconv.Itoa(c.Server.Port))
+}
+
+// RouteTimeout returns the deadline of a route group, falling back to the
+// default request timeout.
+func (c *Config) RouteTimeout(group string) time.Duration {
+	if timeout, ok := c.Server.RouteTimeouts[group]; ok {
+		return timeout
+	}
+
+	return c.Server.RequestTimeout
+}
+
+// stringToDurationMapHook decodes "admin=15s,products=3s" into a map of
+// durations, which is how route timeouts are given in the environment.
+func stringToDurationMapHook(from reflect.Type, to reflect.Type, data any) (any, error) {
+	if from.Kind() != reflect.String || to != reflect.TypeOf(map[string]time.Duration{}) {
+		return data, nil
+	}
+
+	timeouts := map[string]time.Duration{}
+
+	for _, entry := range strings.Split(data.(string), ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+
+		if !ok {
+			return nil, fmt.Errorf("invalid route timeout %q", entry)
+		}
+
+		timeout, err := time.ParseDuration(value)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid route timeout %q: %w", entry, err)
+		}
+
+		timeouts[name] = timeout
+	}
+
+	return timeouts, nil
+}
@@ -2,35 +2,183 @@ package db
 
 import (
 	"fmt"
+	"math"
 	"product/config"
+	"strings"
 
 	"product/models"
+	"product/money"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// Models lists every model migrated at startup.
+var Models = []any{
+	&models.User{},
+	&models.Product{},
+	&models.AuditLog{},
+	&models.RateLimitBucket{},
+	&models.Session{},
+	&models.ImportJob{},
+	&models.IdempotencyKey{},
+	&models.Webhook{},
+	&models.WebhookDelivery{},
+	&models.WebhookMessage{},
+	&models.OutboxMessage{},
+	&models.Job{},
+	&models.CacheEntry{},
+	&models.PriceChange{},
+	&models.PriceSchedule{},
+	&models.ExchangeRate{},
+	&models.ProductPrice{},
+}
+
 func InitDB() *gorm.DB {
-	cfg := config.Cfg
-
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		cfg.DB_USERNAME,
-		cfg.DB_PASSWORD,
-		cfg.DB_HOST,
-		cfg.DB_PORT,
-		cfg.DB_NAME,
+	cfg := config.Cfg.Database
+
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		cfg.Username,
+		cfg.Password,
+		cfg.Host,
+		cfg.Port,
+		cfg.Name,
 	)
 
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+		// Duplicate keys are answered as conflicts with gorm.ErrDuplicatedKey.
+		TranslateError: true,
+	})
 
 	if err != nil {
 		panic(err)
 	}
 
-	db.AutoMigrate(
-		&models.User{},
-		&models.Product{},
-	)
+	if err := db.AutoMigrate(Models...); err != nil {
+		panic(err)
+	}
+
+	if err := protectAuditLogs(db); err != nil {
+		panic(err)
+	}
+
+	if err := MigrateCurrency(db, config.Cfg.Money.BaseCurrency); err != nil {
+		panic(err)
+	}
 
 	return db
 }
+
+// protectAuditLogs creates the missing models.AuditLogTriggers. The database
+// user needs the TRIGGER privilege.
+func protectAuditLogs(db *gorm.DB) error {
+	for name, event := range models.AuditLogTriggers {
+		var count int64
+
+		err := db.Raw("SELECT COUNT(*) FROM information_schema.triggers WHERE trigger_schema = DATABASE() AND trigger_name = ?", name).
+			Scan(&count).Error
+
+		if err != nil {
+			return err
+		}
+
+		if count > 0 {
+			continue
+		}
+
+		err = db.Exec(fmt.Sprintf("CREATE TRIGGER %s %s ON audit_logs FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = '%s'",
+			name, event, models.ErrAuditLogAppendOnly)).Error
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unconverted matches the products stored before prices had a currency.
+// Their column was added empty, so it is NULL.
+const unconverted = "currency IS NULL OR currency = ''"
+
+// MigrateCurrency makes sure the stored prices are in minor units of the base
+// currency. Products stored before their currency was have prices in whole
+// units of the base currency: those, their price history and their schedules
+// are scaled to minor units once, in the transaction that sets the currency.
+// A changed base currency is refused, since it would silently re-denominate
+// the prices.
+func MigrateCurrency(db *gorm.DB, baseCurrency string) error {
+	currency, err := money.Lookup(baseCurrency)
+
+	if err != nil {
+		return err
+	}
+
+	scale := int(math.Pow10(currency.Digits))
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+
+		// Locked so that instances starting together convert them once.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&models.Product{}).Where(unconverted).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count == 0 {
+			return nil
+		}
+
+		products := tx.Model(&models.Product{}).Select("id").Where(unconverted)
+
+		err := tx.Model(&models.PriceChange{}).Where("product_id IN (?)", products).UpdateColumns(map[string]any{
+			"old_price": gorm.Expr("old_price * ?", scale),
+			"price":     gorm.Expr("price * ?", scale),
+		}).Error
+
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&models.PriceSchedule{}).Where("product_id IN (?)", products).UpdateColumns(map[string]any{
+			"price":          gorm.Expr("price * ?", scale),
+			"previous_price": gorm.Expr("previous_price * ?", scale),
+		}).Error
+
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Product{}).Where(unconverted).UpdateColumns(map[string]any{
+			"price":    gorm.Expr("price * ?", scale),
+			"currency": currency.Code,
+		}).Error
+	})
+
+	if err != nil {
+		return err
+	}
+
+	var stored []string
+
+	if err := db.Model(&models.Product{}).Where("currency <> ?", currency.Code).Distinct().Pluck("currency", &stored).Error; err != nil {
+		return err
+	}
+
+	if len(stored) > 0 {
+		return fmt.Errorf("prices are stored in %s, not in the base currency %s: convert them before changing money.base_currency", strings.Join(stored, ", "), currency.Code)
+	}
+
+	return nil
+}
+
+// CloseDB closes the connection pool of db.
+func CloseDB(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.Close()
+}
@@ -0,0 +1,58 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// Handler reacts to an event. It runs on the goroutine of the publisher, so
+// slow work belongs on a goroutine of its own.
+type Handler func(ctx context.Context, event Event)
+
+type subscription struct {
+	handler Handler
+	types   map[string]bool
+}
+
+// Bus delivers events to the handlers subscribed to their type, in process.
+type Bus struct {
+	mu            sync.RWMutex
+	subscriptions []subscription
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe calls handler with the events of types, or every event when no
+// type is given.
+func (b *Bus) Subscribe(handler Handler, types ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := subscription{handler: handler}
+
+	if len(types) > 0 {
+		s.types = map[string]bool{}
+
+		for _, t := range types {
+			s.types[t] = true
+		}
+	}
+
+	b.subscriptions = append(b.subscriptions, s)
+}
+
+// Publish calls the subscribed handlers with each event, in order.
+func (b *Bus) Publish(ctx context.Context, events ...Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, event := range events {
+		for _, s := range b.subscriptions {
+			if s.types == nil || s.types[event.Type] {
+				s.handler(ctx, event)
+			}
+		}
+	}
+}
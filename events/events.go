@@ -0,0 +1,91 @@
+package events
+
+import (
+	"encoding/json"
+	"product/models"
+	"product/utils"
+	"strings"
+	"time"
+)
+
+const (
+	ProductCreated = "product.created"
+	ProductUpdated = "product.updated"
+	ProductDeleted = "product.deleted"
+	UserRegistered = "user.registered"
+	// StockLow is raised when the stock of a product drops below the low
+	// stock threshold.
+	StockLow = "stock.low"
+)
+
+// Types lists every event type.
+var Types = []string{ProductCreated, ProductUpdated, ProductDeleted, UserRegistered, StockLow}
+
+// Event is something that happened to an aggregate, a product or a user,
+// identified by Aggregate and AggregateID.
+type Event struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Aggregate   string          `json:"aggregate"`
+	AggregateID uint            `json:"aggregate_id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// ProductData is the data of the product events. Previous is only set on
+// product.updated.
+type ProductData struct {
+	Product  models.ProductResponse  `json:"product"`
+	Previous *models.ProductResponse `json:"previous,omitempty"`
+}
+
+type StockData struct {
+	Product   models.ProductResponse `json:"product"`
+	Threshold int                    `json:"threshold"`
+}
+
+type UserData struct {
+	User models.UserResponse `json:"user"`
+}
+
+// New returns an event of type about the aggregate named by the prefix of
+// the type, or product for stock.low.
+func New(eventType string, aggregateID uint, data any) (Event, error) {
+	id, err := utils.RandomToken(16)
+
+	if err != nil {
+		return Event{}, err
+	}
+
+	encoded, err := json.Marshal(data)
+
+	if err != nil {
+		return Event{}, err
+	}
+
+	aggregate, _, _ := strings.Cut(eventType, ".")
+
+	if eventType == StockLow {
+		aggregate = models.EntityProduct
+	}
+
+	return Event{
+		ID:          id,
+		Type:        eventType,
+		Aggregate:   aggregate,
+		AggregateID: aggregateID,
+		OccurredAt:  time.Now().UTC(),
+		Data:        encoded,
+	}, nil
+}
+
+// IsType reports whether eventType is one of Types.
+func IsType(eventType string) bool {
+	for _, t := range Types {
+		if t == eventType {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"product/middleware"
+	"product/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// actor describes the caller of a mutating request for the audit log. It is
+// anonymous on routes without authentication.
+func actor(c *fiber.Ctx) models.Actor {
+	user, _ := c.Locals(middleware.CurrentUserKey).(models.User)
+
+	return models.Actor{
+		ID:        user.ID,
+		Email:     user.Email,
+		IP:        c.IP(),
+		RequestID: middleware.GetRequestID(c),
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"product/models"
+	"product/services"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuditHandler struct {
+	auditService services.AuditService
+}
+
+func NewAuditHandler(auditService services.AuditService) AuditHandler {
+	return AuditHandler{
+		auditService,
+	}
+}
+
+func (ah *AuditHandler) GetAll(c *fiber.Ctx) error {
+	filter := models.AuditFilter{
+		EntityType: c.Query("entity_type"),
+		EntityID:   uint(c.QueryInt("entity_id")),
+		ActorID:    uint(c.QueryInt("actor_id")),
+	}
+
+	var err error
+
+	if from := c.Query("from"); from != "" {
+		if filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+			return response(c, fiber.StatusBadRequest, "from must be an RFC3339 time", nil)
+		}
+	}
+
+	if to := c.Query("to"); to != "" {
+		if filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			return response(c, fiber.StatusBadRequest, "to must be an RFC3339 time", nil)
+		}
+	}
+
+	page, limit := paginate(c)
+
+	logs, total, err := ah.auditService.Search(c.UserContext(), filter, page, limit)
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	if len(logs) == 0 {
+		return response(c, fiber.StatusNoContent, "", nil)
+	}
+
+	var logsResponse []models.AuditLogResponse
+	for _, log := range logs {
+		logsResponse = append(logsResponse, log.ConvertToResponse())
+	}
+
+	return response(c, fiber.StatusOK, "successfully get audit logs", pageResponse{
+		Items: logsResponse,
+		Page:  page,
+		Limit: limit,
+		Total: total,
+	})
+}
@@ -1,6 +1,11 @@
 package handlers
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
 
 func response(c *fiber.Ctx, statusCode int, message string, data any) error {
 	if data != nil {
@@ -14,3 +19,16 @@ func response(c *fiber.Ctx, statusCode int, message string, data any) error {
 		"message": message,
 	})
 }
+
+// serverError answers a failed service call. Requests that ran out of time or
+// were cancelled are reported as such instead of as a generic server error.
+func serverError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return response(c, fiber.StatusGatewayTimeout, "request timed out", nil)
+	case errors.Is(err, context.Canceled):
+		return response(c, fiber.StatusServiceUnavailable, "request cancelled", nil)
+	default:
+		return response(c, fiber.StatusInternalServerError, "Upps Sorry, There is something wrong in server", nil)
+	}
+}
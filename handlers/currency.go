@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"errors"
+	"product/middleware"
+	"product/money"
+	"product/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// requestedCurrency reads the currency of the currency query parameter, or
+// else of the Accept-Currency header, falling back to base when neither is
+// given.
+func requestedCurrency(c *fiber.Ctx, base money.Currency) (money.Currency, error) {
+	code := c.Query("currency", c.Get(middleware.HeaderAcceptCurrency))
+
+	if code == "" {
+		return base, nil
+	}
+
+	return money.Lookup(code)
+}
+
+// currencyError answers a failed conversion of prices to the currency asked
+// for.
+func currencyError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, services.ErrNoRate) {
+		return response(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+
+	return serverError(c, err)
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"errors"
+	"product/models"
+	"product/money"
+	"product/services"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// CurrencyHandler manages the exchange rates from baseCurrency and the
+// prices of the products in other currencies.
+type CurrencyHandler struct {
+	productService  services.ProductService
+	currencyService services.CurrencyService
+	baseCurrency    money.Currency
+}
+
+func NewCurrencyHandler(productService services.ProductService, currencyService services.CurrencyService, baseCurrency money.Currency) CurrencyHandler {
+	return CurrencyHandler{
+		productService,
+		currencyService,
+		baseCurrency,
+	}
+}
+
+func (ch *CurrencyHandler) GetRates(c *fiber.Ctx) error {
+	rates, err := ch.currencyService.GetRates(c.UserContext())
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	ratesResponse := []models.ExchangeRateResponse{}
+	for _, rate := range rates {
+		ratesResponse = append(ratesResponse, rate.ConvertToResponse())
+	}
+
+	return response(c, fiber.StatusOK, "successfully get exchange rates", ratesResponse)
+}
+
+// SetRate sets how many units of the currency one unit of the base currency
+// is worth.
+func (ch *CurrencyHandler) SetRate(c *fiber.Ctx) error {
+	currency, err := ch.otherCurrency(c)
+
+	if err != nil {
+		return response(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+
+	rateRequest := models.ExchangeRateRequest{}
+	c.BodyParser(&rateRequest)
+
+	if err := rateRequest.Validate(); err != nil {
+		return response(c, fiber.StatusBadRequest, "invalid request", nil)
+	}
+
+	rate, _ := money.ParseRate(rateRequest.Rate.String())
+
+	exchangeRate, err := ch.currencyService.SetRate(c.UserContext(), actor(c), models.ExchangeRate{
+		Currency: currency.Code,
+		Rate:     money.FormatRate(rate),
+	})
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	return response(c, fiber.StatusOK, "successfully set exchange rate", exchangeRate.ConvertToResponse())
+}
+
+func (ch *CurrencyHandler) DeleteRate(c *fiber.Ctx) error {
+	currency, err := ch.otherCurrency(c)
+
+	if err != nil {
+		return response(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+
+	err = ch.currencyService.DeleteRate(c.UserContext(), actor(c), currency.Code)
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response(c, fiber.StatusNotFound, "exchange rate is not found", nil)
+	}
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	return response(c, fiber.StatusOK, "successfully delete exchange rate", nil)
+}
+
+// GetPriceList lists the prices of a product in the currencies it is not
+// converted to.
+func (ch *CurrencyHandler) GetPriceList(c *fiber.Ctx) error {
+	product, err := ch.productService.GetByCondition(c.UserContext(), "id", c.Params("id"))
+
+	if err != nil {
+		return response(c, fiber.StatusNotFound, "product is not found", nil)
+	}
+
+	prices, err := ch.currencyService.GetPriceList(c.UserContext(), product.ID)
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	pricesResponse := []models.ProductPriceResponse{}
+	for _, price := range prices {
+		// The currencies were checked when the prices were set.
+		currency, _ := money.Lookup(price.Currency)
+		pricesResponse = append(pricesResponse, price.ConvertToResponse(currency))
+	}
+
+	return response(c, fiber.StatusOK, "successfully get price list", pricesResponse)
+}
+
+// SetPrice sets the price of a product in a currency, in its minor units,
+// used instead of converting the price of the product.
+func (ch *CurrencyHandler) SetPrice(c *fiber.Ctx) error {
+	currency, err := ch.otherCurrency(c)
+
+	if err != nil {
+		return response(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+
+	priceRequest := models.ProductPriceRequest{}
+	c.BodyParser(&priceRequest)
+
+	if err := priceRequest.Validate(); err != nil {
+		return response(c, fiber.StatusBadRequest, "invalid request", nil)
+	}
+
+	product, err := ch.productService.GetByCondition(c.UserContext(), "id", c.Params("id"))
+
+	if err != nil {
+		return response(c, fiber.StatusNotFound, "product is not found", nil)
+	}
+
+	price, err := ch.currencyService.SetPrice(c.UserContext(), actor(c), models.ProductPrice{
+		ProductID: product.ID,
+		Currency:  currency.Code,
+		Amount:    priceRequest.Amount,
+	})
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	return response(c, fiber.StatusOK, "successfully set price", price.ConvertToResponse(currency))
+}
+
+// DeletePrice goes back to converting the price of a product to a currency.
+func (ch *CurrencyHandler) DeletePrice(c *fiber.Ctx) error {
+	currency, err := ch.otherCurrency(c)
+
+	if err != nil {
+		return response(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+
+	product, err := ch.productService.GetByCondition(c.UserContext(), "id", c.Params("id"))
+
+	if err != nil {
+		return response(c, fiber.StatusNotFound, "product is not found", nil)
+	}
+
+	err = ch.currencyService.DeletePrice(c.UserContext(), actor(c), product.ID, currency.Code)
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response(c, fiber.StatusNotFound, "price is not found", nil)
+	}
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	return response(c, fiber.StatusOK, "successfully delete price", nil)
+}
+
+// otherCurrency reads the currency route parameter, which cannot be the base
+// currency: prices in it are the prices of the products.
+func (ch *CurrencyHandler) otherCurrency(c *fiber.Ctx) (money.Currency, error) {
+	currency, err := money.Lookup(c.Params("currency"))
+
+	if err == nil && currency == ch.baseCurrency {
+		err = errors.New("currency is the base currency")
+	}
+
+	return currency, err
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"product/services"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const readinessTimeout = 2 * time.Second
+
+type HealthHandler struct {
+	healthService services.HealthService
+	draining      *atomic.Bool
+}
+
+func NewHealthHandler(healthService services.HealthService) HealthHandler {
+	return HealthHandler{
+		healthService,
+		&atomic.Bool{},
+	}
+}
+
+// Drain makes the readiness probe fail so no new traffic is routed to the
+// instance while it shuts down.
+func (hh *HealthHandler) Drain() {
+	hh.draining.Store(true)
+}
+
+func (hh *HealthHandler) Live(c *fiber.Ctx) error {
+	return response(c, fiber.StatusOK, "ok", nil)
+}
+
+func (hh *HealthHandler) Ready(c *fiber.Ctx) error {
+	if hh.draining.Load() {
+		return response(c, fiber.StatusServiceUnavailable, "shutting down", nil)
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), readinessTimeout)
+	defer cancel()
+
+	if err := hh.healthService.Ping(ctx); err != nil {
+		return response(c, fiber.StatusServiceUnavailable, "database is unreachable", nil)
+	}
+
+	if err := hh.healthService.CheckMigrations(ctx); err != nil {
+		return response(c, fiber.StatusServiceUnavailable, "database is not migrated", nil)
+	}
+
+	return response(c, fiber.StatusOK, "ready", nil)
+}
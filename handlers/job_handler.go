@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"product/models"
+	"product/services"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// JobHandler lets admins follow the background jobs, retry the dead ones
+// and cancel the ones not started yet.
+type JobHandler struct {
+	jobService services.JobService
+}
+
+func NewJobHandler(jobService services.JobService) JobHandler {
+	return JobHandler{
+		jobService,
+	}
+}
+
+func (jh *JobHandler) GetAll(c *fiber.Ctx) error {
+	filter := models.JobFilter{}
+	c.QueryParser(&filter)
+
+	if err := filter.Validate(); err != nil {
+		return response(c, fiber.StatusBadRequest, "status must be queued, running, succeeded, dead or cancelled", nil)
+	}
+
+	page, limit := paginate(c)
+
+	jobs, total, err := jh.jobService.Search(c.UserContext(), filter, page, limit)
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	jobsResponse := []models.JobResponse{}
+	for _, job := range jobs {
+		jobsResponse = append(jobsResponse, job.ConvertToResponse())
+	}
+
+	return response(c, fiber.StatusOK, "successfully get jobs", pageResponse{
+		Items: jobsResponse,
+		Page:  page,
+		Limit: limit,
+		Total: total,
+	})
+}
+
+func (jh *JobHandler) Get(c *fiber.Ctx) error {
+	job, err := jh.jobService.GetByID(c.UserContext(), c.Params("id"))
+
+	if err != nil {
+		return response(c, fiber.StatusNotFound, "job is not found", nil)
+	}
+
+	return response(c, fiber.StatusOK, "successfully get job", job.ConvertToResponse())
+}
+
+func (jh *JobHandler) Retry(c *fiber.Ctx) error {
+	job, err := jh.jobService.Retry(c.UserContext(), c.Params("id"))
+
+	if err != nil {
+		return jh.transitionError(c, err)
+	}
+
+	return response(c, fiber.StatusOK, "successfully retry job", job.ConvertToResponse())
+}
+
+func (jh *JobHandler) Cancel(c *fiber.Ctx) error {
+	job, err := jh.jobService.Cancel(c.UserContext(), c.Params("id"))
+
+	if err != nil {
+		return jh.transitionError(c, err)
+	}
+
+	return response(c, fiber.StatusOK, "successfully cancel job", job.ConvertToResponse())
+}
+
+func (jh *JobHandler) transitionError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return response(c, fiber.StatusNotFound, "job is not found", nil)
+	case errors.Is(err, services.ErrJobStatus):
+		return response(c, fiber.StatusConflict, err.Error(), nil)
+	default:
+		return serverError(c, err)
+	}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"product/models"
+	"product/services"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// OutboxHandler lets admins watch the events waiting to be relayed and
+// unblock the messages that ran out of attempts.
+type OutboxHandler struct {
+	outboxService services.OutboxService
+}
+
+func NewOutboxHandler(outboxService services.OutboxService) OutboxHandler {
+	return OutboxHandler{
+		outboxService,
+	}
+}
+
+func (oh *OutboxHandler) GetAll(c *fiber.Ctx) error {
+	filter := models.OutboxFilter{}
+	c.QueryParser(&filter)
+
+	if err := filter.Validate(); err != nil {
+		return response(c, fiber.StatusBadRequest, "status must be pending, published, failed or discarded", nil)
+	}
+
+	page, limit := paginate(c)
+
+	messages, total, err := oh.outboxService.Search(c.UserContext(), filter, page, limit)
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	messagesResponse := []models.OutboxMessageResponse{}
+	for _, message := range messages {
+		messagesResponse = append(messagesResponse, message.ConvertToResponse())
+	}
+
+	return response(c, fiber.StatusOK, "successfully get outbox messages", pageResponse{
+		Items: messagesResponse,
+		Page:  page,
+		Limit: limit,
+		Total: total,
+	})
+}
+
+func (oh *OutboxHandler) GetStats(c *fiber.Ctx) error {
+	stats, err := oh.outboxService.GetStats(c.UserContext())
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	return response(c, fiber.StatusOK, "successfully get outbox stats", stats)
+}
+
+func (oh *OutboxHandler) Retry(c *fiber.Ctx) error {
+	message, err := oh.outboxService.Retry(c.UserContext(), c.Params("id"))
+
+	if err != nil {
+		return oh.transitionError(c, err)
+	}
+
+	return response(c, fiber.StatusOK, "successfully retry outbox message", message.ConvertToResponse())
+}
+
+func (oh *OutboxHandler) Discard(c *fiber.Ctx) error {
+	message, err := oh.outboxService.Discard(c.UserContext(), c.Params("id"))
+
+	if err != nil {
+		return oh.transitionError(c, err)
+	}
+
+	return response(c, fiber.StatusOK, "successfully discard outbox message", message.ConvertToResponse())
+}
+
+func (oh *OutboxHandler) transitionError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return response(c, fiber.StatusNotFound, "outbox message is not found", nil)
+	case errors.Is(err, services.ErrOutboxStatus):
+		return response(c, fiber.StatusConflict, err.Error(), nil)
+	default:
+		return serverError(c, err)
+	}
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import "github.com/gofiber/fiber/v2"
+
+const (
+	defaultLimit = 10
+	maxLimit     = 100
+)
+
+type pageResponse struct {
+	Items any   `json:"items"`
+	Page  int   `json:"page"`
+	Limit int   `json:"limit"`
+	Total int64 `json:"total"`
+}
+
+// paginate reads the page and limit query parameters, falling back to the
+// first page and defaultLimit when they are missing or out of range.
+func paginate(c *fiber.Ctx) (int, int) {
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", defaultLimit)
+
+	if page < 1 {
+		page = 1
+	}
+
+	if limit < 1 || limit > maxLimit {
+		limit = defaultLimit
+	}
+
+	return page, limit
+}
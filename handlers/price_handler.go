@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"errors"
+	"product/models"
+	"product/services"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// PriceHandler serves the price history of products and their scheduled
+// price changes.
+type PriceHandler struct {
+	productService services.ProductService
+	priceService   services.PriceService
+}
+
+func NewPriceHandler(productService services.ProductService, priceService services.PriceService) PriceHandler {
+	return PriceHandler{
+		productService,
+		priceService,
+	}
+}
+
+// GetHistory pages through the price changes of a product, latest first.
+func (ph *PriceHandler) GetHistory(c *fiber.Ctx) error {
+	product, err := ph.productService.GetByCondition(c.UserContext(), "id", c.Params("id"))
+
+	if err != nil {
+		return response(c, fiber.StatusNotFound, "product is not found", nil)
+	}
+
+	page, limit := paginate(c)
+
+	changes, total, err := ph.priceService.GetHistory(c.UserContext(), product.ID, page, limit)
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	changesResponse := []models.PriceChangeResponse{}
+	for _, change := range changes {
+		changesResponse = append(changesResponse, change.ConvertToResponse())
+	}
+
+	return response(c, fiber.StatusOK, "successfully get price history", pageResponse{
+		Items: changesResponse,
+		Page:  page,
+		Limit: limit,
+		Total: total,
+	})
+}
+
+// GetPriceAt answers the price of a product at the RFC 3339 time of the at
+// query parameter, now by default. Future times account for the scheduled
+// changes.
+func (ph *PriceHandler) GetPriceAt(c *fiber.Ctx) error {
+	at := time.Now()
+
+	if value := c.Query("at"); value != "" {
+		var err error
+
+		if at, err = time.Parse(time.RFC3339, value); err != nil {
+			return response(c, fiber.StatusBadRequest, "at must be an RFC3339 time", nil)
+		}
+	}
+
+	product, err := ph.productService.GetByCondition(c.UserContext(), "id", c.Params("id"))
+
+	if err != nil {
+		return response(c, fiber.StatusNotFound, "product is not found", nil)
+	}
+
+	price, err := ph.priceService.GetPriceAt(c.UserContext(), product, at)
+
+	if errors.Is(err, services.ErrNoPrice) {
+		return response(c, fiber.StatusNotFound, err.Error(), nil)
+	}
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	return response(c, fiber.StatusOK, "successfully get price", models.PriceAtResponse{
+		ProductID: product.ID,
+		At:        at,
+		Price:     price,
+	})
+}
+
+func (ph *PriceHandler) GetSchedules(c *fiber.Ctx) error {
+	product, err := ph.productService.GetByCondition(c.UserContext(), "id", c.Params("id"))
+
+	if err != nil {
+		return response(c, fiber.StatusNotFound, "product is not found", nil)
+	}
+
+	schedules, err := ph.priceService.GetSchedules(c.UserContext(), product.ID)
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	schedulesResponse := []models.PriceScheduleResponse{}
+	for _, schedule := range schedules {
+		schedulesResponse = append(schedulesResponse, schedule.ConvertToResponse())
+	}
+
+	return response(c, fiber.StatusOK, "successfully get price schedules", schedulesResponse)
+}
+
+// CreateSchedule plans a price change. Without ends_at the change is
+// permanent; with it the price goes back at ends_at, as for a promotion.
+func (ph *PriceHandler) CreateSchedule(c *fiber.Ctx) error {
+	scheduleRequest := models.PriceScheduleRequest{}
+	c.BodyParser(&scheduleRequest)
+
+	if err := scheduleRequest.Validate(); err != nil {
+		return response(c, fiber.StatusBadRequest, "invalid request", nil)
+	}
+
+	if !scheduleRequest.StartsAt.After(time.Now()) {
+		return response(c, fiber.StatusBadRequest, "starts_at must be in the future", nil)
+	}
+
+	product, err := ph.productService.GetByCondition(c.UserContext(), "id", c.Params("id"))
+
+	if err != nil {
+		return response(c, fiber.StatusNotFound, "product is not found", nil)
+	}
+
+	schedule := scheduleRequest.ConvertToSchedule()
+	schedule.ProductID = product.ID
+
+	schedule, err = ph.priceService.CreateSchedule(c.UserContext(), actor(c), schedule)
+
+	if errors.Is(err, services.ErrScheduleConflict) {
+		return response(c, fiber.StatusConflict, err.Error(), nil)
+	}
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	return response(c, fiber.StatusCreated, "successfully schedule price", schedule.ConvertToResponse())
+}
+
+// CancelSchedule drops a price change that has not started yet.
+func (ph *PriceHandler) CancelSchedule(c *fiber.Ctx) error {
+	product, err := ph.productService.GetByCondition(c.UserContext(), "id", c.Params("id"))
+
+	if err != nil {
+		return response(c, fiber.StatusNotFound, "product is not found", nil)
+	}
+
+	schedule, err := ph.priceService.CancelSchedule(c.UserContext(), actor(c), product.ID, c.Params("scheduleId"))
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return response(c, fiber.StatusNotFound, "price schedule is not found", nil)
+	case errors.Is(err, services.ErrScheduleStatus):
+		return response(c, fiber.StatusConflict, err.Error(), nil)
+	case err != nil:
+		return serverError(c, err)
+	}
+
+	return response(c, fiber.StatusOK, "successfully cancel price schedule", schedule.ConvertToResponse())
+}
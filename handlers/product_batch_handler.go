@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"fmt"
+	"product/models"
+	"product/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ProductBatchHandler struct {
+	productService services.ProductService
+	maxOperations  int
+}
+
+// NewProductBatchHandler accepts batches of up to maxOperations operations.
+func NewProductBatchHandler(productService services.ProductService, maxOperations int) ProductBatchHandler {
+	return ProductBatchHandler{
+		productService,
+		maxOperations,
+	}
+}
+
+// Apply runs a JSON array of create, update and delete operations. The mode
+// query parameter picks all_or_nothing (the default), answered with 200 or
+// 422, or per_item, answered with 207 Multi-Status and a result per item.
+func (bh *ProductBatchHandler) Apply(c *fiber.Ctx) error {
+	options := models.BatchOptions{Mode: models.BatchAllOrNothing}
+	c.QueryParser(&options)
+
+	if err := options.Validate(); err != nil {
+		return response(c, fiber.StatusBadRequest, "mode must be all_or_nothing or per_item", nil)
+	}
+
+	var operations []models.BatchOperation
+
+	if err := c.BodyParser(&operations); err != nil {
+		return response(c, fiber.StatusBadRequest, "invalid request", nil)
+	}
+
+	if len(operations) == 0 {
+		return response(c, fiber.StatusBadRequest, "batch has no operations", nil)
+	}
+
+	if len(operations) > bh.maxOperations {
+		return response(c, fiber.StatusRequestEntityTooLarge, fmt.Sprintf("batch is limited to %d operations", bh.maxOperations), nil)
+	}
+
+	report, err := bh.productService.Batch(c.UserContext(), actor(c), operations, options.Mode)
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	if options.Mode == models.BatchPerItem {
+		return response(c, fiber.StatusMultiStatus, fmt.Sprintf("%d of %d operations applied", report.Succeeded, len(operations)), report)
+	}
+
+	if report.Failed > 0 {
+		return response(c, fiber.StatusUnprocessableEntity, "batch rejected, no operation was applied", report)
+	}
+
+	return response(c, fiber.StatusOK, "successfully apply batch", report)
+}
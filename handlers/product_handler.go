@@ -1,41 +1,128 @@
 package handlers
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"product/catalog"
+	"product/middleware"
 	"product/models"
+	"product/money"
 	"product/services"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
+// ProductHandler renders the prices of the listings in the currency asked
+// for, and those of the products it writes in baseCurrency.
 type ProductHandler struct {
-	productService services.ProductService
+	productService  services.ProductService
+	currencyService services.CurrencyService
+	baseCurrency    money.Currency
 }
 
-func NewProductHandler(productService services.ProductService) ProductHandler {
+func NewProductHandler(productService services.ProductService, currencyService services.CurrencyService, baseCurrency money.Currency) ProductHandler {
 	return ProductHandler{
 		productService,
+		currencyService,
+		baseCurrency,
 	}
 }
 
 func (ph *ProductHandler) GetAll(c *fiber.Ctx) error {
-	products, err := ph.productService.GetAll()
+	currency, err := requestedCurrency(c, ph.baseCurrency)
 
 	if err != nil {
-		return response(c, fiber.StatusInternalServerError, "Upps Sorry, There is something wrong in server", nil)
+		return response(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+
+	products, err := ph.productService.GetAll(c.UserContext())
+
+	if err != nil {
+		return serverError(c, err)
 	}
 
 	if len(products) == 0 {
 		return response(c, fiber.StatusNoContent, "", nil)
 	}
 
-	var productsResponse []models.ProductResponse
-	for _, product := range products {
-		productsResponse = append(productsResponse, product.ConvertToResponse())
+	productsResponse, err := ph.productsResponse(c, currency, products)
+
+	if err != nil {
+		return currencyError(c, err)
 	}
 
 	return response(c, fiber.StatusOK, "successfully get all products", productsResponse)
 }
 
+// Search is the v2 listing: a page of the products matching q. Unlike GetAll
+// it answers an empty page rather than 204 No Content.
+func (ph *ProductHandler) Search(c *fiber.Ctx) error {
+	page, limit := paginate(c)
+
+	currency, err := requestedCurrency(c, ph.baseCurrency)
+
+	if err != nil {
+		return response(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+
+	products, total, err := ph.productService.Search(c.UserContext(), c.Query("q"), page, limit)
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	productsResponse, err := ph.productsResponse(c, currency, products)
+
+	if err != nil {
+		return currencyError(c, err)
+	}
+
+	return response(c, fiber.StatusOK, "successfully get products", pageResponse{
+		Items: productsResponse,
+		Page:  page,
+		Limit: limit,
+		Total: total,
+	})
+}
+
+// Export streams the products matching q as CSV, JSON Lines or XLSX. The
+// columns parameter selects and orders the exported columns.
+func (ph *ProductHandler) Export(c *fiber.Ctx) error {
+	format := c.Query("format", catalog.FormatCSV)
+	contentType, ok := catalog.ContentTypes[format]
+
+	if !ok {
+		return response(c, fiber.StatusBadRequest, "format must be csv, jsonl or xlsx", nil)
+	}
+
+	columns, err := catalog.ParseColumns(c.Query("columns"))
+
+	if err != nil {
+		return response(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+
+	// The body is written after the handler returns, once the route timeout
+	// has already cancelled the request context.
+	ctx := context.WithoutCancel(c.UserContext())
+	query := c.Query("q")
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="products-%s.%s"`, time.Now().UTC().Format("20060102"), format))
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := catalog.Export(ctx, ph.productService, w, format, columns, query); err != nil {
+			slog.ErrorContext(ctx, "export failed", "error", err)
+		}
+	})
+
+	return nil
+}
+
 func (ph *ProductHandler) Create(c *fiber.Ctx) error {
 	productRequest := models.ProductRequest{}
 	c.BodyParser(&productRequest)
@@ -44,13 +131,22 @@ func (ph *ProductHandler) Create(c *fiber.Ctx) error {
 		return response(c, fiber.StatusBadRequest, "invalid request", nil)
 	}
 
-	product, err := ph.productService.Create(productRequest.ConvertToProduct())
+	user, _ := c.Locals(middleware.CurrentUserKey).(models.User)
+
+	product := productRequest.ConvertToProduct()
+	product.UserID = user.ID
+
+	product, err := ph.productService.Create(c.UserContext(), actor(c), product)
+
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return response(c, fiber.StatusConflict, "sku has been used", nil)
+	}
 
 	if product.ID == 0 || err != nil {
-		return response(c, fiber.StatusInternalServerError, "Upps Sorry, There is something wrong in server", nil)
+		return serverError(c, err)
 	}
 
-	return response(c, fiber.StatusOK, "successfully create product", product.ConvertToResponse())
+	return response(c, fiber.StatusOK, "successfully create product", ph.productResponse(product))
 }
 
 func (ph *ProductHandler) Update(c *fiber.Ctx) error {
@@ -63,38 +159,74 @@ func (ph *ProductHandler) Update(c *fiber.Ctx) error {
 		return response(c, fiber.StatusBadRequest, "invalid request", nil)
 	}
 
-	product, err := ph.productService.GetByCondition("id", id)
+	product, err := ph.productService.Update(c.UserContext(), actor(c), id, productRequest)
 
-	if err != nil {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return response(c, fiber.StatusBadRequest, "product is not found", nil)
 	}
 
-	product.Name = productRequest.Name
-	product.Description = productRequest.Description
-	product.Price = productRequest.Price
-	product.Stock = productRequest.Stock
-
-	product, err = ph.productService.Update(id, product)
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return response(c, fiber.StatusConflict, "sku has been used", nil)
+	}
 
 	if err != nil {
-		return response(c, fiber.StatusInternalServerError, "Upps Sorry, There is something wrong in server", nil)
+		return serverError(c, err)
 	}
 
-	return response(c, fiber.StatusOK, "successfully update product", product.ConvertToResponse())
+	return response(c, fiber.StatusOK, "successfully update product", ph.productResponse(product))
 }
 
 func (ph *ProductHandler) Delete(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	product, _ := ph.productService.GetByCondition("id", id)
+	product, _ := ph.productService.GetByCondition(c.UserContext(), "id", id)
 
 	if product.ID == 0 {
 		return response(c, fiber.StatusBadRequest, "product is not found", nil)
 	}
 
-	if err := ph.productService.Delete(product); err != nil {
-		return response(c, fiber.StatusInternalServerError, "Upps Sorry, There is something wrong in server", nil)
+	if err := ph.productService.Delete(c.UserContext(), actor(c), product); err != nil {
+		return serverError(c, err)
 	}
 
 	return response(c, fiber.StatusOK, "successfully delete product", nil)
 }
+
+// productsResponse renders products with their price in currency.
+func (ph *ProductHandler) productsResponse(c *fiber.Ctx, currency money.Currency, products []models.Product) ([]models.ProductResponse, error) {
+	productsResponse := []models.ProductResponse{}
+
+	// Prices are in the base currency already.
+	if currency == ph.baseCurrency {
+		for _, product := range products {
+			productsResponse = append(productsResponse, ph.productResponse(product))
+		}
+
+		return productsResponse, nil
+	}
+
+	prices, err := ph.currencyService.Convert(c.UserContext(), currency, products)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i, product := range products {
+		productResponse := product.ConvertToResponse()
+		priceResponse := models.NewMoneyResponse(prices[i])
+		productResponse.PriceMoney = &priceResponse
+
+		productsResponse = append(productsResponse, productResponse)
+	}
+
+	return productsResponse, nil
+}
+
+// productResponse renders product with its price in the base currency.
+func (ph *ProductHandler) productResponse(product models.Product) models.ProductResponse {
+	productResponse := product.ConvertToResponse()
+	priceResponse := models.NewMoneyResponse(money.New(int64(product.Price), ph.baseCurrency))
+	productResponse.PriceMoney = &priceResponse
+
+	return productResponse
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"product/catalog"
+	"product/middleware"
+	"product/models"
+	"product/services"
+	"product/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// progressEvery is how many rows a background import handles between
+	// saves of its progress, at most.
+	progressEvery = 100
+	// progressInterval is how long a background import waits between saves
+	// of its progress, at most.
+	progressInterval = time.Second
+)
+
+type ProductImportHandler struct {
+	importService services.ProductImportService
+	background    *utils.Background
+	asyncRows     int
+}
+
+// NewProductImportHandler runs imports of more than asyncRows rows as
+// background jobs on background.
+func NewProductImportHandler(importService services.ProductImportService, background *utils.Background, asyncRows int) ProductImportHandler {
+	return ProductImportHandler{
+		importService,
+		background,
+		asyncRows,
+	}
+}
+
+// Import reads a CSV or JSON Lines upload, either as the "file" field of a
+// multipart form or as the request body. The mode query parameter picks
+// all_or_nothing (the default) or per_row, dry_run=true only reports, and
+// async=true runs the import as a background job whatever its size.
+func (ih *ProductImportHandler) Import(c *fiber.Ctx) error {
+	options := models.ImportOptions{Mode: models.ImportAllOrNothing}
+	c.QueryParser(&options)
+
+	if err := options.Validate(); err != nil {
+		return response(c, fiber.StatusBadRequest, "mode must be all_or_nothing or per_row", nil)
+	}
+
+	source, err := spoolUpload(c)
+
+	if err != nil {
+		return response(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+
+	// Only counted, the rows are read again by the import.
+	total := 0
+
+	if err := source.Each(func(models.ImportRow) error { total++; return nil }); err != nil {
+		source.Remove()
+		return response(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+
+	if total == 0 {
+		source.Remove()
+		return response(c, fiber.StatusBadRequest, "file has no products", nil)
+	}
+
+	if c.QueryBool("async") || total > ih.asyncRows {
+		return ih.startJob(c, source, total, options)
+	}
+
+	defer source.Remove()
+
+	report, err := ih.importService.Import(c.UserContext(), actor(c), source, options, func(int) {})
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	if options.Mode == models.ImportAllOrNothing && report.Failed > 0 {
+		return response(c, fiber.StatusUnprocessableEntity, "import rejected, no product was saved", report)
+	}
+
+	if options.DryRun {
+		return response(c, fiber.StatusOK, "import checked, no product was saved", report)
+	}
+
+	return response(c, fiber.StatusOK, "successfully import products", report)
+}
+
+// GetJob reports the progress of a background import. Users only see their
+// own imports, admins see every import.
+func (ih *ProductImportHandler) GetJob(c *fiber.Ctx) error {
+	user, _ := c.Locals(middleware.CurrentUserKey).(models.User)
+
+	job, err := ih.importService.GetJob(c.UserContext(), c.Params("id"))
+
+	if err != nil || (job.UserID != user.ID && user.Role != models.RoleAdmin) {
+		return response(c, fiber.StatusNotFound, "import is not found", nil)
+	}
+
+	return response(c, fiber.StatusOK, "successfully get import", job.ConvertToResponse())
+}
+
+// spoolUpload copies the upload to a temporary file, which outlives the
+// request for background imports. A streamed body is copied as it arrives;
+// multipart uploads are already spooled by the form parser.
+func spoolUpload(c *fiber.Ctx) (catalog.FileSource, error) {
+	var (
+		reader      io.Reader
+		contentType = c.Get(fiber.HeaderContentType)
+		filename    string
+	)
+
+	if file, err := c.FormFile("file"); err == nil {
+		opened, err := file.Open()
+
+		if err != nil {
+			return catalog.FileSource{}, err
+		}
+
+		defer opened.Close()
+
+		reader = opened
+		contentType = file.Header.Get(fiber.HeaderContentType)
+		filename = file.Filename
+	} else if stream := c.Request().BodyStream(); stream != nil {
+		reader = stream
+	} else {
+		reader = bytes.NewReader(c.Body())
+	}
+
+	format := c.Query("format")
+
+	if format == "" {
+		var err error
+
+		if format, err = catalog.DetectFormat(contentType, filename); err != nil {
+			return catalog.FileSource{}, err
+		}
+	}
+
+	return catalog.Spool(reader, format)
+}
+
+// startJob imports the total rows of source in the background. The job
+// removes source when it is done.
+func (ih *ProductImportHandler) startJob(c *fiber.Ctx, source catalog.FileSource, total int, options models.ImportOptions) error {
+	jobActor := actor(c)
+
+	job, err := ih.importService.CreateJob(c.UserContext(), models.ImportJob{
+		UserID: jobActor.ID,
+		Status: models.ImportPending,
+		Report: models.ImportReport{Mode: options.Mode, DryRun: options.DryRun, Total: total},
+	})
+
+	if err != nil {
+		source.Remove()
+		return serverError(c, err)
+	}
+
+	ih.background.Go(func(ctx context.Context) {
+		defer source.Remove()
+
+		ih.runJob(ctx, job, jobActor, source, options)
+	})
+
+	c.Location(strings.TrimSuffix(c.Path(), "/import") + "/imports/" + strconv.FormatUint(uint64(job.ID), 10))
+
+	return response(c, fiber.StatusAccepted, "import started", job.ConvertToResponse())
+}
+
+// runJob imports rows and keeps job up to date. The job outlives the
+// request, so it only stops early when the application shuts down.
+func (ih *ProductImportHandler) runJob(ctx context.Context, job models.ImportJob, jobActor models.Actor, rows models.ImportSource, options models.ImportOptions) {
+	job.Status = models.ImportRunning
+	ih.importService.UpdateJob(ctx, job)
+
+	saved, savedAt := 0, time.Now()
+
+	report, err := ih.importService.Import(ctx, jobActor, rows, options, func(processed int) {
+		job.Processed = processed
+
+		if processed-saved >= progressEvery || time.Since(savedAt) >= progressInterval {
+			saved, savedAt = processed, time.Now()
+			ih.importService.UpdateJob(ctx, job)
+		}
+	})
+
+	finishedAt := time.Now()
+	job.FinishedAt = &finishedAt
+	job.Report = report
+
+	switch {
+	case err != nil && options.Mode == models.ImportPerRow:
+		job.Status = models.ImportFailed
+		job.Error = "import stopped before the end"
+	case err != nil:
+		job.Status = models.ImportFailed
+		job.Error = "import failed, no product was saved"
+	case options.Mode == models.ImportAllOrNothing && report.Failed > 0:
+		job.Status = models.ImportFailed
+		job.Error = "import rejected, no product was saved"
+	default:
+		job.Status = models.ImportSucceeded
+		job.Processed = report.Total
+	}
+
+	// The final state is saved even when ctx was cancelled by a shutdown.
+	ih.importService.UpdateJob(context.WithoutCancel(ctx), job)
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"product/config"
+	"product/middleware"
+	"product/models"
+	"product/services"
+	"product/session"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SessionHandler serves the cookie based login of browser clients and the
+// listing and revocation of their sessions.
+type SessionHandler struct {
+	userService services.UserService
+	sessions    *session.Manager
+	cfg         config.AuthConfig
+}
+
+func NewSessionHandler(userService services.UserService, sessions *session.Manager, cfg config.AuthConfig) SessionHandler {
+	return SessionHandler{
+		userService,
+		sessions,
+		cfg,
+	}
+}
+
+// Login checks the credentials like UserHandler.Login but answers with a
+// session cookie instead of a token.
+func (sh *SessionHandler) Login(c *fiber.Ctx) error {
+	user, ok, err := checkCredentials(c, sh.userService)
+
+	if !ok {
+		return err
+	}
+
+	token, s, err := sh.sessions.Create(c.UserContext(), user, c.IP(), c.Get(fiber.HeaderUserAgent))
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	middleware.SetSessionCookie(c, sh.cfg, token, s.ExpiresAt)
+
+	return response(c, fiber.StatusOK, "login success", user.ConvertToResponse())
+}
+
+// Logout ends the session of the request. Bearer tokens cannot be ended one
+// by one, so for them it does nothing.
+func (sh *SessionHandler) Logout(c *fiber.Ctx) error {
+	if current, ok := middleware.GetSession(c); ok {
+		if err := sh.sessions.Revoke(c.UserContext(), current.UserID, current.ID); err != nil && !errors.Is(err, session.ErrNotFound) {
+			return serverError(c, err)
+		}
+
+		middleware.ClearSessionCookie(c, sh.cfg)
+	}
+
+	return response(c, fiber.StatusOK, "logout success", nil)
+}
+
+func (sh *SessionHandler) GetMine(c *fiber.Ctx) error {
+	user := c.Locals(middleware.CurrentUserKey).(models.User)
+
+	return sh.list(c, user.ID)
+}
+
+func (sh *SessionHandler) Revoke(c *fiber.Ctx) error {
+	user := c.Locals(middleware.CurrentUserKey).(models.User)
+	id := c.Params("id")
+
+	err := sh.sessions.Revoke(c.UserContext(), user.ID, id)
+
+	if errors.Is(err, session.ErrNotFound) {
+		return response(c, fiber.StatusNotFound, "session is not found", nil)
+	}
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	if current, ok := middleware.GetSession(c); ok && current.ID == id {
+		middleware.ClearSessionCookie(c, sh.cfg)
+	}
+
+	return response(c, fiber.StatusOK, "successfully revoke session", nil)
+}
+
+func (sh *SessionHandler) RevokeMine(c *fiber.Ctx) error {
+	user := c.Locals(middleware.CurrentUserKey).(models.User)
+
+	if err := sh.sessions.RevokeAll(c.UserContext(), user.ID); err != nil {
+		return serverError(c, err)
+	}
+
+	if _, ok := middleware.GetSession(c); ok {
+		middleware.ClearSessionCookie(c, sh.cfg)
+	}
+
+	return response(c, fiber.StatusOK, "successfully revoke sessions", nil)
+}
+
+func (sh *SessionHandler) GetByUser(c *fiber.Ctx) error {
+	user, err := sh.userService.GetByCondition(c.UserContext(), "id", c.Params("id"))
+
+	if err != nil || user.ID == 0 {
+		return response(c, fiber.StatusNotFound, "user is not found", nil)
+	}
+
+	return sh.list(c, user.ID)
+}
+
+func (sh *SessionHandler) RevokeByUser(c *fiber.Ctx) error {
+	user, err := sh.userService.GetByCondition(c.UserContext(), "id", c.Params("id"))
+
+	if err != nil || user.ID == 0 {
+		return response(c, fiber.StatusNotFound, "user is not found", nil)
+	}
+
+	if err := sh.sessions.RevokeAll(c.UserContext(), user.ID); err != nil {
+		return serverError(c, err)
+	}
+
+	return response(c, fiber.StatusOK, "successfully revoke sessions", nil)
+}
+
+func (sh *SessionHandler) list(c *fiber.Ctx, userID uint) error {
+	sessions, err := sh.sessions.List(c.UserContext(), userID)
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	current, _ := middleware.GetSession(c)
+
+	sessionsResponse := []models.SessionResponse{}
+	for _, s := range sessions {
+		sessionResponse := s.ConvertToResponse()
+		sessionResponse.Current = s.ID == current.ID
+
+		sessionsResponse = append(sessionsResponse, sessionResponse)
+	}
+
+	return response(c, fiber.StatusOK, "successfully get sessions", sessionsResponse)
+}
@@ -1,45 +1,68 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"product/middleware"
 	"product/models"
 	"product/services"
+	"product/utils"
 
 	"github.com/gofiber/fiber/v2"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type UserHandler struct {
 	userService services.UserService
+	mailer      services.UserMailer
 }
 
-func NewUserHandler(userService services.UserService) UserHandler {
+func NewUserHandler(userService services.UserService, mailer services.UserMailer) UserHandler {
 	return UserHandler{
 		userService,
+		mailer,
 	}
 }
 
 func (uh *UserHandler) Login(c *fiber.Ctx) error {
+	user, ok, err := checkCredentials(c, uh.userService)
+
+	if !ok {
+		return err
+	}
+
+	token, err := middleware.GenerateToken(user, 6)
+
+	if err != nil {
+		return response(c, fiber.StatusInternalServerError, "failed to generate token", nil)
+	}
+
+	return response(c, fiber.StatusOK, "login success", fiber.Map{"token": token})
+}
+
+// checkCredentials finds the user logging in with the email and password of
+// the request. When they are rejected the request has been answered and ok
+// is false.
+func checkCredentials(c *fiber.Ctx, userService services.UserService) (user models.User, ok bool, err error) {
 	userRequest := models.UserRequest{}
 	c.BodyParser(&userRequest)
 
-	user, _ := uh.userService.GetByCondition("email", userRequest.Email)
+	user, _ = userService.GetByCondition(c.UserContext(), "email", userRequest.Email)
 
 	if user.ID == 0 {
-		return response(c, fiber.StatusBadRequest, "email is not registered", nil)
+		return user, false, response(c, fiber.StatusBadRequest, "email is not registered", nil)
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(userRequest.Password)); err != nil {
-		return response(c, fiber.StatusBadRequest, "password invalid", nil)
+		return user, false, response(c, fiber.StatusBadRequest, "password invalid", nil)
 	}
 
-	token, err := middleware.GenerateToken(user, 6)
-
-	if err != nil {
-		return response(c, fiber.StatusInternalServerError, "failed to generate token", nil)
+	if user.Deactivated {
+		return user, false, response(c, fiber.StatusForbidden, "user is deactivated", nil)
 	}
 
-	return response(c, fiber.StatusOK, "login success", fiber.Map{"token": token})
+	return user, true, nil
 }
 
 func (uh *UserHandler) Register(c *fiber.Ctx) error {
@@ -54,7 +77,7 @@ func (uh *UserHandler) Register(c *fiber.Ctx) error {
 		return response(c, fiber.StatusBadRequest, "password must be at least 6 character", nil)
 	}
 
-	user, _ := uh.userService.GetByCondition("email", userRequest.Email)
+	user, _ := uh.userService.GetByCondition(c.UserContext(), "email", userRequest.Email)
 
 	if user.ID != 0 {
 		return response(c, fiber.StatusConflict, "email has been registered", nil)
@@ -64,20 +87,22 @@ func (uh *UserHandler) Register(c *fiber.Ctx) error {
 
 	userRequest.Password = string(password)
 
-	user, err := uh.userService.Create(userRequest.ConvertToUser())
+	user, err := uh.userService.Create(c.UserContext(), actor(c), userRequest.ConvertToUser())
 
 	if user.ID == 0 || err != nil {
-		return response(c, fiber.StatusInternalServerError, "Upps Sorry, There is something wrong in server", nil)
+		return serverError(c, err)
 	}
 
 	return response(c, fiber.StatusOK, "successfully regist user", user.ConvertToResponse())
 }
 
 func (uh *UserHandler) GetAll(c *fiber.Ctx) error {
-	users, err := uh.userService.GetAll()
+	page, limit := paginate(c)
+
+	users, total, err := uh.userService.Search(c.UserContext(), c.Query("q"), page, limit)
 
 	if err != nil {
-		return response(c, fiber.StatusInternalServerError, "Upps Sorry, There is something wrong in server", nil)
+		return serverError(c, err)
 	}
 
 	if len(users) == 0 {
@@ -89,7 +114,42 @@ func (uh *UserHandler) GetAll(c *fiber.Ctx) error {
 		usersResponse = append(usersResponse, user.ConvertToResponse())
 	}
 
-	return response(c, fiber.StatusOK, "successfully get all users", usersResponse)
+	return response(c, fiber.StatusOK, "successfully get all users", pageResponse{
+		Items: usersResponse,
+		Page:  page,
+		Limit: limit,
+		Total: total,
+	})
+}
+
+// Create registers a user on behalf of an admin. The user receives a
+// temporary password by mail and has to change it on first use.
+func (uh *UserHandler) Create(c *fiber.Ctx) error {
+	userRequest := models.CreateUserRequest{}
+	c.BodyParser(&userRequest)
+
+	if err := userRequest.Validate(); err != nil {
+		return response(c, fiber.StatusBadRequest, "invalid request", nil)
+	}
+
+	user, _ := uh.userService.GetByCondition(c.UserContext(), "email", userRequest.Email)
+
+	if user.ID != 0 {
+		return response(c, fiber.StatusConflict, "email has been registered", nil)
+	}
+
+	// The user has no password until the temporary one is mailed.
+	user, err := uh.userService.Create(c.UserContext(), actor(c), userRequest.ConvertToUser())
+
+	if user.ID == 0 || err != nil {
+		return serverError(c, err)
+	}
+
+	if err := uh.mailer.SendTemporaryPassword(c.UserContext(), user.ID); err != nil {
+		return response(c, fiber.StatusInternalServerError, "failed to send temporary password", nil)
+	}
+
+	return response(c, fiber.StatusOK, "successfully create user", user.ConvertToResponse())
 }
 
 func (uh *UserHandler) Update(c *fiber.Ctx) error {
@@ -98,24 +158,299 @@ func (uh *UserHandler) Update(c *fiber.Ctx) error {
 
 	c.BodyParser(&userRequest)
 
-	if err := userRequest.Validate(); err != nil || len(userRequest.Password) < 6 {
+	if err := userRequest.Validate(); err != nil {
 		return response(c, fiber.StatusBadRequest, "invalid request", nil)
 	}
 
-	user, err := uh.userService.GetByCondition("id", id)
+	user, err := uh.userService.Update(c.UserContext(), actor(c), id, func(user *models.User) error {
+		user.Email = userRequest.Email
+		user.Name = userRequest.Name
+
+		return nil
+	})
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response(c, fiber.StatusBadRequest, "user is not found", nil)
+	}
+
+	if errors.Is(err, services.ErrEmailRegistered) {
+		return response(c, fiber.StatusConflict, "email has been registered", nil)
+	}
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	return response(c, fiber.StatusOK, "successfully update user", user.ConvertToResponse())
+}
+
+func (uh *UserHandler) SetPassword(c *fiber.Ctx) error {
+	passwordRequest := models.SetPasswordRequest{}
+	id := c.Params("id")
+
+	c.BodyParser(&passwordRequest)
+
+	if err := passwordRequest.Validate(); err != nil {
+		return response(c, fiber.StatusBadRequest, "password must be at least 6 character", nil)
+	}
+
+	password, _ := bcrypt.GenerateFromPassword([]byte(passwordRequest.Password), bcrypt.DefaultCost)
+
+	// The password set by the admin replaces any reset the user was forced
+	// into.
+	_, err := uh.userService.Update(c.UserContext(), actor(c), id, func(user *models.User) error {
+		user.Password = string(password)
+		user.MustResetPassword = false
+		user.TokenVersion++
+
+		return nil
+	})
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response(c, fiber.StatusBadRequest, "user is not found", nil)
+	}
 
 	if err != nil {
+		return serverError(c, err)
+	}
+
+	return response(c, fiber.StatusOK, "successfully update user password", nil)
+}
+
+// Delete removes a user. Their products are reassigned to the user given by
+// the reassign_to query parameter, or left without an owner when it is absent.
+func (uh *UserHandler) Delete(c *fiber.Ctx) error {
+	id := c.Params("id")
+	reassignTo := c.Query("reassign_to")
+
+	user, _ := uh.userService.GetByCondition(c.UserContext(), "id", id)
+
+	if user.ID == 0 {
 		return response(c, fiber.StatusBadRequest, "user is not found", nil)
 	}
 
-	user.Email = userRequest.Email
-	user.Name = userRequest.Name
+	var newOwner models.User
+
+	if reassignTo != "" {
+		newOwner, _ = uh.userService.GetByCondition(c.UserContext(), "id", reassignTo)
+
+		if newOwner.ID == 0 || newOwner.ID == user.ID {
+			return response(c, fiber.StatusBadRequest, "reassigned user is not found", nil)
+		}
+	}
+
+	if err := uh.userService.Delete(c.UserContext(), actor(c), user, newOwner.ID); err != nil {
+		return serverError(c, err)
+	}
+
+	return response(c, fiber.StatusOK, "successfully delete user", nil)
+}
+
+func (uh *UserHandler) Deactivate(c *fiber.Ctx) error {
+	return uh.setDeactivated(c, true, "successfully deactivate user")
+}
 
-	user, err = uh.userService.Update(id, user)
+func (uh *UserHandler) Reactivate(c *fiber.Ctx) error {
+	return uh.setDeactivated(c, false, "successfully reactivate user")
+}
+
+func (uh *UserHandler) setDeactivated(c *fiber.Ctx, deactivated bool, message string) error {
+	id := c.Params("id")
+
+	user, err := uh.userService.Update(c.UserContext(), actor(c), id, func(user *models.User) error {
+		user.Deactivated = deactivated
+
+		return nil
+	})
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response(c, fiber.StatusBadRequest, "user is not found", nil)
+	}
 
 	if err != nil {
-		return response(c, fiber.StatusInternalServerError, "Upps Sorry, There is something wrong in server", nil)
+		return serverError(c, err)
 	}
 
-	return response(c, fiber.StatusOK, "successfully update user", user.ConvertToResponse())
+	return response(c, fiber.StatusOK, message, user.ConvertToResponse())
+}
+
+// ForcePasswordReset logs the user out everywhere and makes them choose a new
+// password before they can use the API again.
+func (uh *UserHandler) ForcePasswordReset(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	user, err := uh.userService.Update(c.UserContext(), actor(c), id, func(user *models.User) error {
+		user.MustResetPassword = true
+		user.TokenVersion++
+
+		return nil
+	})
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response(c, fiber.StatusBadRequest, "user is not found", nil)
+	}
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	return response(c, fiber.StatusOK, "successfully force password reset", user.ConvertToResponse())
+}
+
+func (uh *UserHandler) GetProfile(c *fiber.Ctx) error {
+	user := c.Locals(middleware.CurrentUserKey).(models.User)
+
+	return response(c, fiber.StatusOK, "successfully get profile", user.ConvertToResponse())
+}
+
+func (uh *UserHandler) UpdateProfile(c *fiber.Ctx) error {
+	profileRequest := models.ProfileRequest{}
+	user := c.Locals(middleware.CurrentUserKey).(models.User)
+
+	c.BodyParser(&profileRequest)
+
+	if err := profileRequest.Validate(); err != nil {
+		return response(c, fiber.StatusBadRequest, "invalid request", nil)
+	}
+
+	user, err := uh.userService.Update(c.UserContext(), actor(c), fmt.Sprint(user.ID), func(user *models.User) error {
+		user.Name = profileRequest.Name
+
+		return nil
+	})
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	return response(c, fiber.StatusOK, "successfully update profile", user.ConvertToResponse())
+}
+
+// ChangePassword re-hashes the password of the current user and revokes every
+// other session by bumping the token version. A fresh token is returned so the
+// caller stays logged in.
+func (uh *UserHandler) ChangePassword(c *fiber.Ctx) error {
+	passwordRequest := models.ChangePasswordRequest{}
+	user := c.Locals(middleware.CurrentUserKey).(models.User)
+
+	c.BodyParser(&passwordRequest)
+
+	if err := passwordRequest.Validate(); err != nil {
+		return response(c, fiber.StatusBadRequest, "invalid request", nil)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(passwordRequest.CurrentPassword)); err != nil {
+		return response(c, fiber.StatusBadRequest, "password invalid", nil)
+	}
+
+	password, _ := bcrypt.GenerateFromPassword([]byte(passwordRequest.NewPassword), bcrypt.DefaultCost)
+
+	user, err := uh.userService.Update(c.UserContext(), actor(c), fmt.Sprint(user.ID), func(user *models.User) error {
+		user.Password = string(password)
+		user.MustResetPassword = false
+		user.TokenVersion++
+
+		return nil
+	})
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	token, err := middleware.GenerateToken(user, 6)
+
+	if err != nil {
+		return response(c, fiber.StatusInternalServerError, "failed to generate token", nil)
+	}
+
+	return response(c, fiber.StatusOK, "successfully change password", fiber.Map{"token": token})
+}
+
+// ChangeEmail stores the requested address as pending and mails a
+// verification token to it. The email is only switched by VerifyEmail.
+func (uh *UserHandler) ChangeEmail(c *fiber.Ctx) error {
+	emailRequest := models.ChangeEmailRequest{}
+	user := c.Locals(middleware.CurrentUserKey).(models.User)
+
+	c.BodyParser(&emailRequest)
+
+	if err := emailRequest.Validate(); err != nil {
+		return response(c, fiber.StatusBadRequest, "invalid request", nil)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(emailRequest.Password)); err != nil {
+		return response(c, fiber.StatusBadRequest, "password invalid", nil)
+	}
+
+	registered, _ := uh.userService.GetByCondition(c.UserContext(), "email", emailRequest.Email)
+
+	if registered.ID != 0 {
+		return response(c, fiber.StatusConflict, "email has been registered", nil)
+	}
+
+	// Any earlier token is revoked; the new one is made and mailed by the
+	// mailer.
+	user, err := uh.userService.Update(c.UserContext(), actor(c), fmt.Sprint(user.ID), func(user *models.User) error {
+		user.PendingEmail = emailRequest.Email
+		user.EmailToken = ""
+		user.EmailTokenExpiresAt = nil
+
+		return nil
+	})
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	if err := uh.mailer.SendEmailVerification(c.UserContext(), user.ID); err != nil {
+		return response(c, fiber.StatusInternalServerError, "failed to send verification email", nil)
+	}
+
+	return response(c, fiber.StatusOK, "verification email has been sent", user.ConvertToResponse())
+}
+
+func (uh *UserHandler) VerifyEmail(c *fiber.Ctx) error {
+	verifyRequest := models.VerifyEmailRequest{}
+	c.BodyParser(&verifyRequest)
+
+	if err := verifyRequest.Validate(); err != nil {
+		return response(c, fiber.StatusBadRequest, "invalid request", nil)
+	}
+
+	user, err := uh.userService.VerifyEmail(c.UserContext(), actor(c), utils.HashToken(verifyRequest.Token))
+
+	if errors.Is(err, services.ErrTokenInvalid) {
+		return response(c, fiber.StatusBadRequest, "token invalid", nil)
+	}
+
+	if errors.Is(err, services.ErrEmailRegistered) {
+		return response(c, fiber.StatusConflict, "email has been registered", nil)
+	}
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	return response(c, fiber.StatusOK, "successfully verify email", user.ConvertToResponse())
+}
+
+func (uh *UserHandler) DeleteAccount(c *fiber.Ctx) error {
+	deleteRequest := models.DeleteAccountRequest{}
+	user := c.Locals(middleware.CurrentUserKey).(models.User)
+
+	c.BodyParser(&deleteRequest)
+
+	if err := deleteRequest.Validate(); err != nil {
+		return response(c, fiber.StatusBadRequest, "invalid request", nil)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(deleteRequest.Password)); err != nil {
+		return response(c, fiber.StatusBadRequest, "password invalid", nil)
+	}
+
+	if err := uh.userService.Delete(c.UserContext(), actor(c), user, 0); err != nil {
+		return serverError(c, err)
+	}
+
+	return response(c, fiber.StatusOK, "successfully delete account", nil)
 }
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"product/models"
+	"product/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// WebhookHandler lets admins register the endpoints receiving events and
+// read their delivery logs.
+type WebhookHandler struct {
+	webhookService services.WebhookService
+}
+
+func NewWebhookHandler(webhookService services.WebhookService) WebhookHandler {
+	return WebhookHandler{
+		webhookService,
+	}
+}
+
+func (wh *WebhookHandler) GetAll(c *fiber.Ctx) error {
+	webhooks, err := wh.webhookService.GetAll(c.UserContext())
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	webhooksResponse := []models.WebhookResponse{}
+	for _, webhook := range webhooks {
+		webhooksResponse = append(webhooksResponse, webhook.ConvertToResponse())
+	}
+
+	return response(c, fiber.StatusOK, "successfully get webhooks", webhooksResponse)
+}
+
+func (wh *WebhookHandler) Get(c *fiber.Ctx) error {
+	webhook, err := wh.webhookService.GetByID(c.UserContext(), c.Params("id"))
+
+	if err != nil {
+		return response(c, fiber.StatusNotFound, "webhook is not found", nil)
+	}
+
+	return response(c, fiber.StatusOK, "successfully get webhook", webhook.ConvertToResponse())
+}
+
+func (wh *WebhookHandler) Create(c *fiber.Ctx) error {
+	webhookRequest := models.WebhookRequest{}
+	c.BodyParser(&webhookRequest)
+
+	if err := webhookRequest.Validate(); err != nil {
+		return response(c, fiber.StatusBadRequest, "invalid request", nil)
+	}
+
+	webhook := models.Webhook{}
+	webhookRequest.ApplyTo(&webhook)
+
+	webhook, err := wh.webhookService.Create(c.UserContext(), actor(c), webhook)
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	return response(c, fiber.StatusCreated, "successfully create webhook", webhook.ConvertToResponse())
+}
+
+func (wh *WebhookHandler) Update(c *fiber.Ctx) error {
+	webhookRequest := models.WebhookRequest{}
+	c.BodyParser(&webhookRequest)
+
+	if err := webhookRequest.Validate(); err != nil {
+		return response(c, fiber.StatusBadRequest, "invalid request", nil)
+	}
+
+	webhook, err := wh.webhookService.GetByID(c.UserContext(), c.Params("id"))
+
+	if err != nil {
+		return response(c, fiber.StatusNotFound, "webhook is not found", nil)
+	}
+
+	webhookRequest.ApplyTo(&webhook)
+
+	webhook, err = wh.webhookService.Update(c.UserContext(), actor(c), webhook)
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	return response(c, fiber.StatusOK, "successfully update webhook", webhook.ConvertToResponse())
+}
+
+func (wh *WebhookHandler) Delete(c *fiber.Ctx) error {
+	webhook, err := wh.webhookService.GetByID(c.UserContext(), c.Params("id"))
+
+	if err != nil {
+		return response(c, fiber.StatusNotFound, "webhook is not found", nil)
+	}
+
+	if err := wh.webhookService.Delete(c.UserContext(), actor(c), webhook); err != nil {
+		return serverError(c, err)
+	}
+
+	return response(c, fiber.StatusOK, "successfully delete webhook", nil)
+}
+
+// GetDeliveries pages through the delivery attempts of a webhook, latest
+// first.
+func (wh *WebhookHandler) GetDeliveries(c *fiber.Ctx) error {
+	webhook, err := wh.webhookService.GetByID(c.UserContext(), c.Params("id"))
+
+	if err != nil {
+		return response(c, fiber.StatusNotFound, "webhook is not found", nil)
+	}
+
+	page, limit := paginate(c)
+
+	deliveries, total, err := wh.webhookService.GetDeliveries(c.UserContext(), webhook.ID, page, limit)
+
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	deliveriesResponse := []models.WebhookDeliveryResponse{}
+	for _, delivery := range deliveries {
+		deliveriesResponse = append(deliveriesResponse, delivery.ConvertToResponse())
+	}
+
+	return response(c, fiber.StatusOK, "successfully get webhook deliveries", pageResponse{
+		Items: deliveriesResponse,
+		Page:  page,
+		Limit: limit,
+		Total: total,
+	})
+}
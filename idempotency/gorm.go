@@ -0,0 +1,94 @@
+package idempotency
+
+import (
+	"context"
+	"product/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormStore keeps the keys in the database so every instance of the
+// application shares them.
+type GormStore struct {
+	db *gorm.DB
+}
+
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{
+		db: db,
+	}
+}
+
+// Reserve inserts the key, or takes over an expired one. Both are single
+// statements, so of two concurrent requests only one reserves the key.
+func (gs *GormStore) Reserve(ctx context.Context, record models.IdempotencyKey, now time.Time) (models.IdempotencyKey, bool, error) {
+	db := gs.db.WithContext(ctx)
+
+	// CreatedAt tells reservations apart, so it is kept to the millisecond
+	// the column stores for Complete and Release to match it exactly.
+	record.CreatedAt = record.CreatedAt.Truncate(time.Millisecond)
+
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+
+	if result.Error != nil {
+		return models.IdempotencyKey{}, false, result.Error
+	}
+
+	if result.RowsAffected == 1 {
+		return record, true, nil
+	}
+
+	result = db.Model(&models.IdempotencyKey{}).Where("`key` = ? AND expires_at <= ?", record.Key, now).Select("*").Updates(&record)
+
+	if result.Error != nil {
+		return models.IdempotencyKey{}, false, result.Error
+	}
+
+	if result.RowsAffected == 1 {
+		return record, true, nil
+	}
+
+	existing := models.IdempotencyKey{}
+
+	if err := db.First(&existing, "`key` = ?", record.Key).Error; err != nil {
+		return models.IdempotencyKey{}, false, err
+	}
+
+	return existing, false, nil
+}
+
+func (gs *GormStore) Complete(ctx context.Context, record models.IdempotencyKey) error {
+	result := gs.reservation(ctx, record).Select("*").Updates(&record)
+
+	return reservedResult(result)
+}
+
+func (gs *GormStore) Release(ctx context.Context, record models.IdempotencyKey) error {
+	result := gs.reservation(ctx, record).Delete(&models.IdempotencyKey{})
+
+	return reservedResult(result)
+}
+
+// reservation scopes a statement to the reservation of record, which is
+// gone once another request has taken the key over.
+func (gs *GormStore) reservation(ctx context.Context, record models.IdempotencyKey) *gorm.DB {
+	return gs.db.WithContext(ctx).Model(&models.IdempotencyKey{}).Where("`key` = ? AND created_at = ? AND status = 0", record.Key, record.CreatedAt)
+}
+
+func reservedResult(result *gorm.DB) error {
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrNotReserved
+	}
+
+	return nil
+}
+
+func (gs *GormStore) DeleteExpired(ctx context.Context, now time.Time) error {
+	return gs.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&models.IdempotencyKey{}).Error
+}
@@ -0,0 +1,35 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"product/models"
+	"product/utils"
+	"time"
+)
+
+// ErrNotReserved is returned by Complete and Release when the reservation of
+// the record is no longer held: its lease ran out and another request took
+// the key over.
+var ErrNotReserved = errors.New("idempotency key is no longer reserved")
+
+// Store keeps the responses to idempotent requests. A reservation is told
+// apart from a later one of the same key by its CreatedAt.
+type Store interface {
+	// Reserve claims the key of record for its request. When the key is
+	// already claimed and not expired, it returns the existing record and
+	// false instead.
+	Reserve(ctx context.Context, record models.IdempotencyKey, now time.Time) (models.IdempotencyKey, bool, error)
+	// Complete saves the response of the reservation record was returned
+	// for by Reserve.
+	Complete(ctx context.Context, record models.IdempotencyKey) error
+	// Release forgets the reservation record was returned for by Reserve,
+	// when its request has not completed, so it can be retried.
+	Release(ctx context.Context, record models.IdempotencyKey) error
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+// Run deletes expired keys from store every hour until ctx is done.
+func Run(ctx context.Context, store Store) {
+	utils.RunCleanup(ctx, "expired idempotency keys", store.DeleteExpired)
+}
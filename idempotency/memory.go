@@ -0,0 +1,80 @@
+package idempotency
+
+import (
+	"context"
+	"product/models"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps the keys in process. They are lost on restart and not
+// shared between instances.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]models.IdempotencyKey
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records: map[string]models.IdempotencyKey{},
+	}
+}
+
+func (ms *MemoryStore) Reserve(ctx context.Context, record models.IdempotencyKey, now time.Time) (models.IdempotencyKey, bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if existing, ok := ms.records[record.Key]; ok && now.Before(existing.ExpiresAt) {
+		return existing, false, nil
+	}
+
+	ms.records[record.Key] = record
+
+	return record, true, nil
+}
+
+func (ms *MemoryStore) Complete(ctx context.Context, record models.IdempotencyKey) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if !ms.reserved(record) {
+		return ErrNotReserved
+	}
+
+	ms.records[record.Key] = record
+
+	return nil
+}
+
+func (ms *MemoryStore) Release(ctx context.Context, record models.IdempotencyKey) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if !ms.reserved(record) {
+		return ErrNotReserved
+	}
+
+	delete(ms.records, record.Key)
+
+	return nil
+}
+
+// reserved reports whether the reservation of record is still held.
+func (ms *MemoryStore) reserved(record models.IdempotencyKey) bool {
+	existing, ok := ms.records[record.Key]
+
+	return ok && existing.Status == 0 && existing.CreatedAt.Equal(record.CreatedAt)
+}
+
+func (ms *MemoryStore) DeleteExpired(ctx context.Context, now time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for key, record := range ms.records {
+		if !now.Before(record.ExpiresAt) {
+			delete(ms.records, key)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"product/models"
+	"product/services"
+	"product/utils"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// SendUserMail sends a user the mail of a template outside of the request
+// that asked for it. The payload only names the user: the temporary password
+// or token the mail carries is made when the job runs, so no secret is ever
+// stored in a job.
+const SendUserMail Type[UserMail] = "user.mail"
+
+const (
+	MailTemporaryPassword = "temporary_password"
+	MailEmailVerification = "email_verification"
+)
+
+// EmailTokenTTL is how long an email verification token stays valid.
+const EmailTokenTTL = 24 * time.Hour
+
+type UserMail struct {
+	UserID   uint   `json:"user_id"`
+	Template string `json:"template"`
+}
+
+// UserMailer queues user mails as SendUserMail jobs, so a slow or failing
+// mail provider neither delays nor fails requests. It implements
+// services.UserMailer.
+type UserMailer struct {
+	queue *Queue
+}
+
+func NewUserMailer(queue *Queue) *UserMailer {
+	return &UserMailer{
+		queue: queue,
+	}
+}
+
+func (um *UserMailer) SendTemporaryPassword(ctx context.Context, userID uint) error {
+	_, err := Enqueue(ctx, um.queue, SendUserMail, UserMail{UserID: userID, Template: MailTemporaryPassword}, time.Time{})
+
+	return err
+}
+
+func (um *UserMailer) SendEmailVerification(ctx context.Context, userID uint) error {
+	_, err := Enqueue(ctx, um.queue, SendUserMail, UserMail{UserID: userID, Template: MailEmailVerification}, time.Time{})
+
+	return err
+}
+
+// errMailUnwanted skips a mail the user no longer needs.
+var errMailUnwanted = errors.New("mail no longer wanted")
+
+// SendUserMails returns the handler of SendUserMail. It saves the hash of a
+// fresh secret on the user and mails the secret with mailer, so a retry
+// mails a new secret that replaces the last one. A mail no longer wanted,
+// because the password was already changed or the email verified, is
+// skipped. The changes are audited without an actor, as made by the system.
+func SendUserMails(users services.UserService, mailer services.Mailer) func(ctx context.Context, mail UserMail) error {
+	return func(ctx context.Context, mail UserMail) error {
+		var change func(user *models.User) error
+		var to func(user models.User) string
+		var subject, body string
+
+		switch mail.Template {
+		case MailTemporaryPassword:
+			password, err := utils.RandomToken(6)
+
+			if err != nil {
+				return err
+			}
+
+			hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+
+			if err != nil {
+				return err
+			}
+
+			change = func(user *models.User) error {
+				if !user.MustResetPassword {
+					return errMailUnwanted
+				}
+
+				user.Password = string(hash)
+
+				return nil
+			}
+			to = func(user models.User) string { return user.Email }
+			subject, body = "Your account has been created", "Your temporary password: "+password
+		case MailEmailVerification:
+			token, err := utils.RandomToken(32)
+
+			if err != nil {
+				return err
+			}
+
+			expiresAt := time.Now().Add(EmailTokenTTL)
+
+			change = func(user *models.User) error {
+				if user.PendingEmail == "" {
+					return errMailUnwanted
+				}
+
+				user.EmailToken = utils.HashToken(token)
+				user.EmailTokenExpiresAt = &expiresAt
+
+				return nil
+			}
+			to = func(user models.User) string { return user.PendingEmail }
+			subject, body = "Verify your new email", "Your verification token: "+token
+		default:
+			return Permanent(fmt.Errorf("unknown mail template %q", mail.Template))
+		}
+
+		user, err := users.Update(ctx, models.Actor{}, fmt.Sprint(mail.UserID), change)
+
+		if errors.Is(err, errMailUnwanted) {
+			return nil
+		}
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Permanent(err)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return mailer.Send(ctx, to(user), subject, body)
+	}
+}
@@ -0,0 +1,252 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"product/config"
+	"product/models"
+	"product/services"
+	"product/utils"
+	"sync"
+	"time"
+)
+
+// maintenanceInterval is how often stale jobs are queued again and old jobs
+// deleted.
+const maintenanceInterval = time.Minute
+
+// ErrUnknownType rejects a job of a type no handler is registered for.
+var ErrUnknownType = errors.New("no handler is registered for the job type")
+
+// Type names a kind of job and the payload its handler takes, so that a job
+// cannot be enqueued with the payload of another.
+type Type[T any] string
+
+// permanentError marks a failure that retrying cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent wraps an error returned by a handler to move its job to the dead
+// letters at once instead of retrying it.
+func Permanent(err error) error {
+	return &permanentError{err}
+}
+
+type handler func(ctx context.Context, payload []byte) error
+
+// Queue runs the jobs stored in the database with a fixed number of workers.
+// A failing job is retried with exponential backoff and moved to the dead
+// letters once out of attempts. Jobs are claimed with row locks, so any
+// number of instances can share the queue. On shutdown the running jobs are
+// interrupted and queued again.
+type Queue struct {
+	jobs     services.JobService
+	cfg      config.JobsConfig
+	logger   *slog.Logger
+	handlers map[string]handler
+	types    []string
+	name     string
+	wake     chan struct{}
+	now      func() time.Time
+}
+
+func NewQueue(jobService services.JobService, cfg config.JobsConfig, logger *slog.Logger) *Queue {
+	host, _ := os.Hostname()
+
+	return &Queue{
+		jobs:     jobService,
+		cfg:      cfg,
+		logger:   logger,
+		handlers: map[string]handler{},
+		name:     fmt.Sprintf("%s:%d", host, os.Getpid()),
+		wake:     make(chan struct{}, 1),
+		now:      time.Now,
+	}
+}
+
+// Register sets the handler of a job type. Handlers are registered before
+// the queue runs.
+func Register[T any](q *Queue, jobType Type[T], fn func(ctx context.Context, payload T) error) {
+	if _, ok := q.handlers[string(jobType)]; !ok {
+		q.types = append(q.types, string(jobType))
+	}
+
+	q.handlers[string(jobType)] = func(ctx context.Context, payload []byte) error {
+		var value T
+
+		if err := json.Unmarshal(payload, &value); err != nil {
+			return Permanent(err)
+		}
+
+		return fn(ctx, value)
+	}
+}
+
+// Enqueue stores a job to run at runAt, or as soon as a worker is free when
+// runAt is zero.
+func Enqueue[T any](ctx context.Context, q *Queue, jobType Type[T], payload T, runAt time.Time) (models.Job, error) {
+	if _, ok := q.handlers[string(jobType)]; !ok {
+		return models.Job{}, ErrUnknownType
+	}
+
+	body, err := json.Marshal(payload)
+
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	if runAt.IsZero() {
+		runAt = q.now()
+	}
+
+	job, err := q.jobs.Enqueue(ctx, models.Job{
+		Type:        string(jobType),
+		Payload:     string(body),
+		RunAt:       runAt,
+		MaxAttempts: q.cfg.MaxAttempts,
+	})
+
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	// Wake an idle worker rather than leave the job to the next poll.
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+
+	return job, nil
+}
+
+// Run starts the workers and returns once ctx is done and they have all
+// stopped.
+func (q *Queue) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for i := 1; i <= q.cfg.Concurrency; i++ {
+		wg.Add(1)
+
+		go func(worker string) {
+			defer wg.Done()
+			q.work(ctx, worker)
+		}(fmt.Sprintf("%s/%d", q.name, i))
+	}
+
+	q.maintain(ctx)
+
+	wg.Wait()
+}
+
+func (q *Queue) work(ctx context.Context, worker string) {
+	for ctx.Err() == nil {
+		job, ok, err := q.jobs.Claim(ctx, worker, q.types, q.now())
+
+		if err == nil && ok {
+			q.Process(ctx, job)
+			continue
+		}
+
+		timer := time.NewTimer(q.cfg.PollInterval)
+
+		select {
+		case <-ctx.Done():
+		case <-q.wake:
+		case <-timer.C:
+		}
+
+		timer.Stop()
+	}
+}
+
+// maintain queues again the jobs abandoned by crashed instances and deletes
+// the finished jobs past their retention, until ctx is done.
+func (q *Queue) maintain(ctx context.Context) {
+	ticker := time.NewTicker(maintenanceInterval)
+	defer ticker.Stop()
+
+	for {
+		now := q.now()
+
+		if count, err := q.jobs.RequeueStale(ctx, now.Add(-2*q.cfg.Timeout)); err == nil && count > 0 {
+			q.logger.WarnContext(ctx, "queued abandoned jobs again", "count", count)
+		}
+
+		q.jobs.DeleteFinished(ctx, now.Add(-q.cfg.Retention))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Process runs a claimed job and records the outcome. A job interrupted
+// because ctx is done is queued again without counting the attempt.
+func (q *Queue) Process(ctx context.Context, job models.Job) {
+	runCtx, cancel := context.WithTimeout(ctx, q.cfg.Timeout)
+	err := q.run(runCtx, job)
+	cancel()
+
+	now := q.now()
+	job.LockedBy = ""
+	job.LockedAt = nil
+
+	var permanent *permanentError
+
+	switch {
+	case err == nil:
+		job.Status = models.JobSucceeded
+		job.LastError = ""
+		job.FinishedAt = &now
+	case ctx.Err() != nil:
+		job.Status = models.JobQueued
+		job.Attempts--
+		job.RunAt = now
+	case errors.As(err, &permanent) || job.Attempts >= job.MaxAttempts:
+		job.Status = models.JobDead
+		job.LastError = err.Error()
+		job.FinishedAt = &now
+		q.logger.ErrorContext(ctx, "job moved to the dead letters", "error", err, "job_id", job.ID, "job_type", job.Type, "attempts", job.Attempts)
+	default:
+		job.Status = models.JobQueued
+		job.LastError = err.Error()
+		job.RunAt = now.Add(utils.Backoff(q.cfg.InitialBackoff, q.cfg.MaxBackoff, job.Attempts))
+	}
+
+	// The outcome is saved even when ctx was cancelled by a shutdown.
+	if err := q.jobs.Update(context.WithoutCancel(ctx), job); err != nil {
+		q.logger.ErrorContext(ctx, "failed to save job outcome", "error", err, "job_id", job.ID)
+	}
+}
+
+// run calls the handler of job, turning a panic into an error.
+func (q *Queue) run(ctx context.Context, job models.Job) (err error) {
+	handle, ok := q.handlers[job.Type]
+
+	if !ok {
+		return Permanent(ErrUnknownType)
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("job panicked: %v", recovered)
+		}
+	}()
+
+	return handle(ctx, []byte(job.Payload))
+}
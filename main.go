@@ -1,39 +1,260 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"product/cache"
+	"product/catalog"
 	"product/config"
 	"product/db"
+	"product/events"
 	"product/handlers"
+	"product/idempotency"
+	"product/jobs"
+	"product/metrics"
+	"product/middleware"
+	"product/money"
+	"product/outbox"
+	"product/pricing"
+	"product/ratelimit"
 	"product/router"
 	"product/services"
+	"product/session"
+	"product/tracing"
+	"product/utils"
+	"product/webhook"
+	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 )
 
 func main() {
-	config.InitConfig()
+	cfg, args, err := config.Load(os.Args[1:])
 
-	db := db.InitDB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	config.Cfg = cfg
+
+	if len(args) == 2 && args[0] == "config" && args[1] == "print" {
+		if err := cfg.Print(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	logLevel := &slog.LevelVar{}
+	logLevel.Set(utils.ParseLogLevel(cfg.Logging.Level))
+
+	logger := utils.NewLogger(os.Stdout, logLevel, cfg.Logging.Format)
+	slog.SetDefault(logger)
+
+	reloader := config.NewReloader(cfg, os.Args[1:], logger)
+	reloader.Subscribe(func(cfg *config.Config) {
+		logLevel.Set(utils.ParseLogLevel(cfg.Logging.Level))
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Init(ctx, cfg.Tracing.Exporter, cfg.Tracing.File)
+
+	if err != nil {
+		panic(err)
+	}
+
+	gormDB := db.InitDB()
+
+	if err := tracing.InstrumentDB(gormDB); err != nil {
+		panic(err)
+	}
+
+	appMetrics := metrics.New()
+
+	if err := appMetrics.InstrumentDB(gormDB, cfg.Database.Name); err != nil {
+		panic(err)
+	}
+
+	var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+
+	if cfg.RateLimit.Store == "database" {
+		rateLimitStore = ratelimit.NewGormStore(gormDB)
+	}
+
+	var sessionStore session.Store = session.NewMemoryStore()
+
+	if cfg.Auth.SessionStore == "database" {
+		sessionStore = session.NewGormStore(gormDB)
+	}
+
+	var idempotencyStore idempotency.Store = idempotency.NewMemoryStore()
+
+	if cfg.Idempotency.Store == "database" {
+		idempotencyStore = idempotency.NewGormStore(gormDB)
+	}
+
+	var cacheStore cache.Store = cache.NewMemoryStore(cfg.Cache.MaxEntries)
+
+	if cfg.Cache.Store == "database" {
+		cacheStore = cache.NewGormStore(gormDB)
+	}
+
+	sessions := session.NewManager(sessionStore, cfg.Auth.SessionTTL)
+
+	background := utils.NewBackground()
+	background.Go(reloader.Run)
+	background.Go(sessions.Run)
+	background.Go(func(ctx context.Context) {
+		ratelimit.Run(ctx, rateLimitStore)
+	})
+	background.Go(func(ctx context.Context) {
+		idempotency.Run(ctx, idempotencyStore)
+	})
+	background.Go(func(ctx context.Context) {
+		cache.Run(ctx, cacheStore)
+	})
+
+	productCache := cache.New("products", cacheStore, func() time.Duration {
+		return reloader.Current().Cache.TTL
+	}, logger)
+
+	if err := appMetrics.RegisterCacheStats(productCache); err != nil {
+		panic(err)
+	}
+
+	// The currencies were validated with the configuration.
+	baseCurrency, _ := money.Lookup(cfg.Money.BaseCurrency)
+	roundingRules, _ := money.NewRules(cfg.Money.Rounding)
+
+	// Every write to the products goes through these services, which drop
+	// the cached listings.
+	userService := services.NewCachedUserService(services.NewUserService(gormDB, logger), productCache)
+	productService := services.NewCachedProductService(services.NewProductService(gormDB, logger, cfg.Events.LowStockThreshold, baseCurrency), productCache)
+	importService := services.NewCachedProductImportService(services.NewProductImportService(gormDB, logger, cfg.Events.LowStockThreshold, baseCurrency), productCache)
+	auditService := services.NewAuditService(gormDB, logger)
+	healthService := services.NewHealthService(gormDB, logger, db.Models)
+	webhookService := services.NewWebhookService(gormDB, logger)
+	outboxService := services.NewOutboxService(gormDB, logger)
+	jobService := services.NewJobService(gormDB, logger)
+	priceService := services.NewCachedPriceService(services.NewPriceService(gormDB, logger, cfg.Events.LowStockThreshold), productCache)
+	currencyService := services.NewCachedCurrencyService(services.NewCurrencyService(gormDB, logger, baseCurrency, roundingRules), productCache)
 
-	userService := services.NewUserService(db)
-	productService := services.NewProductService(db)
+	// Events reach the sink through the outbox relay, once their change has
+	// been committed, and the relay queues them for the webhooks.
+	bus := events.NewBus()
+	background.Go(webhook.NewDispatcher(webhookService, cfg.Webhooks, logger).Run)
+	background.Go(outbox.NewRelay(outboxService, outbox.NewSink(cfg.Outbox, bus), cfg.Outbox, logger).Run)
+
+	background.Go(pricing.NewScheduler(priceService, cfg.Pricing, logger).Run)
+
+	if cfg.Export.Interval > 0 {
+		background.Go(catalog.NewScheduler(productService, cfg.Export, logger).Run)
+	}
+
+	mailer := services.NewMailer(logger)
+
+	// Job handlers have to be registered before the queue runs.
+	queue := jobs.NewQueue(jobService, cfg.Jobs, logger)
+	jobs.Register(queue, jobs.SendUserMail, jobs.SendUserMails(userService, mailer))
+	background.Go(queue.Run)
+
+	if err := appMetrics.RegisterProductStats(productService); err != nil {
+		panic(err)
+	}
 
-	userHandler := handlers.NewUserHandler(userService)
-	productHandler := handlers.NewProductHandler(productService)
+	userHandler := handlers.NewUserHandler(userService, jobs.NewUserMailer(queue))
+	productHandler := handlers.NewProductHandler(productService, currencyService, baseCurrency)
+	importHandler := handlers.NewProductImportHandler(importService, background, cfg.Import.AsyncRows)
+	batchHandler := handlers.NewProductBatchHandler(productService, cfg.Batch.MaxOperations)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	healthHandler := handlers.NewHealthHandler(healthService)
+	sessionHandler := handlers.NewSessionHandler(userService, sessions, cfg.Auth)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	outboxHandler := handlers.NewOutboxHandler(outboxService)
+	jobHandler := handlers.NewJobHandler(jobService)
+	priceHandler := handlers.NewPriceHandler(productService, priceService)
+	currencyHandler := handlers.NewCurrencyHandler(productService, currencyService, baseCurrency)
 
 	route := router.HandlerList{
-		UserHandler:    userHandler,
-		ProductHandler: productHandler,
+		UserHandler:      userHandler,
+		ProductHandler:   productHandler,
+		ImportHandler:    importHandler,
+		BatchHandler:     batchHandler,
+		AuditHandler:     auditHandler,
+		HealthHandler:    healthHandler,
+		SessionHandler:   sessionHandler,
+		WebhookHandler:   webhookHandler,
+		OutboxHandler:    outboxHandler,
+		JobHandler:       jobHandler,
+		PriceHandler:     priceHandler,
+		CurrencyHandler:  currencyHandler,
+		UserService:      userService,
+		MetricsHandler:   appMetrics.Handler(),
+		RateLimitStore:   rateLimitStore,
+		IdempotencyStore: idempotencyStore,
+		Sessions:         sessions,
+		Config:           reloader,
 	}
 
-	app := fiber.New()
+	// Request bodies are streamed so that import uploads are spooled to a
+	// file as they arrive; BodyLimit bounds them instead of the server.
+	app := fiber.New(fiber.Config{
+		DisableStartupMessage:        true,
+		BodyLimit:                    cfg.Server.BodyLimit,
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
+	})
 
-	app.Use(logger.New(logger.Config{
-		Format: "[${ip}]:${port} ${status} - ${method} ${path}\n",
-	}))
+	app.Use(middleware.BodyLimit(cfg.Server.BodyLimit))
+	app.Use(middleware.RequestID())
+	app.Use(middleware.Tracing())
+	app.Use(middleware.Logger(logger))
+	app.Use(appMetrics.Middleware())
 
 	route.InitRoute(app)
 
-	app.Listen(":3000")
+	go func() {
+		logger.Info("server started", "address", cfg.Address())
+
+		if err := app.Listen(cfg.Address()); err != nil {
+			logger.Error("server stopped", "error", err)
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+
+	logger.Info("shutting down", "drain_delay", cfg.Server.DrainDelay, "timeout", cfg.Server.ShutdownTimeout)
+
+	healthHandler.Drain()
+	time.Sleep(cfg.Server.DrainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := app.ShutdownWithTimeout(cfg.Server.ShutdownTimeout); err != nil {
+		logger.Error("failed to drain requests", "error", err)
+	}
+
+	if err := background.Shutdown(shutdownCtx); err != nil {
+		logger.Error("failed to stop background workers", "error", err)
+	}
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("failed to flush traces", "error", err)
+	}
+
+	if err := db.CloseDB(gormDB); err != nil {
+		logger.Error("failed to close database", "error", err)
+	}
+
+	logger.Info("shutdown complete")
 }
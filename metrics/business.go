@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"context"
+	"product/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type ProductStatsSource interface {
+	GetStats(ctx context.Context) (models.ProductStats, error)
+}
+
+// productCollector reads the catalog statistics on every scrape so the
+// gauges never go stale.
+type productCollector struct {
+	source     ProductStatsSource
+	count      *prometheus.Desc
+	stockValue *prometheus.Desc
+}
+
+// RegisterProductStats exposes the product count and total stock value.
+func (m *Metrics) RegisterProductStats(source ProductStatsSource) error {
+	return m.registry.Register(&productCollector{
+		source:     source,
+		count:      prometheus.NewDesc(namespace+"_products", "Number of products in the catalog.", nil, nil),
+		stockValue: prometheus.NewDesc(namespace+"_stock_value", "Sum of price times stock over all products.", nil, nil),
+	})
+}
+
+func (pc *productCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- pc.count
+	ch <- pc.stockValue
+}
+
+func (pc *productCollector) Collect(ch chan<- prometheus.Metric) {
+	stats, err := pc.source.GetStats(context.Background())
+
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(pc.count, err)
+		ch <- prometheus.NewInvalidMetric(pc.stockValue, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(pc.count, prometheus.GaugeValue, float64(stats.Count))
+	ch <- prometheus.MustNewConstMetric(pc.stockValue, prometheus.GaugeValue, float64(stats.StockValue))
+}
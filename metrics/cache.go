@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"product/cache"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type CacheStatsSource interface {
+	Name() string
+	Stats() cache.Stats
+}
+
+// cacheCollector reads the counters of a cache on every scrape.
+type cacheCollector struct {
+	source CacheStatsSource
+	hits   *prometheus.Desc
+	misses *prometheus.Desc
+}
+
+// RegisterCacheStats exposes the hits and misses of a cache, labelled with
+// its name.
+func (m *Metrics) RegisterCacheStats(source CacheStatsSource) error {
+	labels := prometheus.Labels{"cache": source.Name()}
+
+	return m.registry.Register(&cacheCollector{
+		source: source,
+		hits:   prometheus.NewDesc(namespace+"_cache_hits_total", "Number of reads served from the cache.", nil, labels),
+		misses: prometheus.NewDesc(namespace+"_cache_misses_total", "Number of reads the cache had to load.", nil, labels),
+	})
+}
+
+func (cc *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.hits
+	ch <- cc.misses
+}
+
+func (cc *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := cc.source.Stats()
+
+	ch <- prometheus.MustNewConstMetric(cc.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(cc.misses, prometheus.CounterValue, float64(stats.Misses))
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+const startKey = "metrics:start"
+
+// InstrumentDB times every GORM operation and exposes the connection pool
+// statistics of db under the given database name.
+func (m *Metrics) InstrumentDB(db *gorm.DB, name string) error {
+	sqlDB, err := db.DB()
+
+	if err != nil {
+		return err
+	}
+
+	if err := m.registry.Register(collectors.NewDBStatsCollector(sqlDB, name)); err != nil {
+		return err
+	}
+
+	cb := db.Callback()
+
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("metrics:before_create", m.start),
+		cb.Create().After("gorm:create").Register("metrics:after_create", m.observe("create")),
+		cb.Query().Before("gorm:query").Register("metrics:before_query", m.start),
+		cb.Query().After("gorm:query").Register("metrics:after_query", m.observe("query")),
+		cb.Update().Before("gorm:update").Register("metrics:before_update", m.start),
+		cb.Update().After("gorm:update").Register("metrics:after_update", m.observe("update")),
+		cb.Delete().Before("gorm:delete").Register("metrics:before_delete", m.start),
+		cb.Delete().After("gorm:delete").Register("metrics:after_delete", m.observe("delete")),
+		cb.Row().Before("gorm:row").Register("metrics:before_row", m.start),
+		cb.Row().After("gorm:row").Register("metrics:after_row", m.observe("row")),
+		cb.Raw().Before("gorm:raw").Register("metrics:before_raw", m.start),
+		cb.Raw().After("gorm:raw").Register("metrics:after_raw", m.observe("raw")),
+	)
+}
+
+func (m *Metrics) start(db *gorm.DB) {
+	db.InstanceSet(startKey, time.Now())
+}
+
+func (m *Metrics) observe(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(startKey)
+
+		if !ok {
+			return
+		}
+
+		start, ok := value.(time.Time)
+
+		if !ok {
+			return
+		}
+
+		m.queryDuration.WithLabelValues(operation, db.Statement.Table).Observe(time.Since(start).Seconds())
+	}
+}
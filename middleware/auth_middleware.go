@@ -3,22 +3,129 @@ package middleware
 import (
 	"product/config"
 	"product/models"
+	"product/services"
+	"strconv"
 	"time"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// CurrentUserKey is the fiber.Ctx locals key holding the authenticated models.User.
+const CurrentUserKey = "currentUser"
+
 func GenerateToken(user models.User, expLimit time.Duration) (string, error) {
 	claims := jwt.MapClaims{
+		"sub":   strconv.FormatUint(uint64(user.ID), 10),
 		"id":    user.ID,
 		"name":  user.Name,
 		"email": user.Email,
+		"role":  user.Role,
+		"ver":   user.TokenVersion,
 		"exp":   time.Now().Add(time.Hour * expLimit).Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
-	t, err := token.SignedString([]byte(config.Cfg.JWT_SECRET_KEY))
+	t, err := token.SignedString([]byte(config.Cfg.Auth.JWTSecret))
 
 	return t, err
 }
+
+// CurrentUser loads the user named by the session or the JWT subject and
+// rejects sessions and tokens that were issued before the user's sessions
+// were revoked. It must run after Authenticate or the jwt middleware.
+func CurrentUser(userService services.UserService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		sub, version, ok := subject(c)
+
+		if !ok {
+			return unauthorized(c, "invalid token")
+		}
+
+		user, err := userService.GetByCondition(c.UserContext(), "id", sub)
+
+		if err != nil || user.ID == 0 {
+			return unauthorized(c, "invalid token")
+		}
+
+		if version != user.TokenVersion {
+			return unauthorized(c, "token has been revoked")
+		}
+
+		if user.Deactivated {
+			return unauthorized(c, "user is deactivated")
+		}
+
+		c.Locals(CurrentUserKey, user)
+
+		return c.Next()
+	}
+}
+
+// subject returns the user ID and token version of the session or the JWT
+// authenticating the request.
+func subject(c *fiber.Ctx) (string, int, bool) {
+	if s, ok := GetSession(c); ok {
+		return strconv.FormatUint(uint64(s.UserID), 10), s.TokenVersion, true
+	}
+
+	token, ok := c.Locals("user").(*jwt.Token)
+
+	if !ok {
+		return "", 0, false
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+
+	if !ok {
+		return "", 0, false
+	}
+
+	sub, err := claims.GetSubject()
+
+	if err != nil || sub == "" {
+		return "", 0, false
+	}
+
+	version, _ := claims["ver"].(float64)
+
+	return sub, int(version), true
+}
+
+// RequireRole only lets through users loaded by CurrentUser having the given role.
+func RequireRole(role string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, ok := c.Locals(CurrentUserKey).(models.User)
+
+		if !ok || user.Role != role {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"message": "forbidden",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// PasswordResetGuard blocks users flagged with MustResetPassword. Routes
+// that let the user change their password must not use it.
+func PasswordResetGuard() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, ok := c.Locals(CurrentUserKey).(models.User)
+
+		if ok && user.MustResetPassword {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"message": "password must be changed before continuing",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+func unauthorized(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"message": message,
+	})
+}
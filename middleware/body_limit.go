@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// BodyLimit rejects request bodies over limit bytes before they are read.
+// The server streams request bodies so that uploads can be spooled instead
+// of held in memory, which leaves bounding them to this middleware. Bodies
+// of unknown length are refused, as their size is only known once read.
+func BodyLimit(limit int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		length := c.Request().Header.ContentLength()
+
+		if length == -1 {
+			c.Context().SetConnectionClose()
+
+			return c.Status(fiber.StatusLengthRequired).JSON(fiber.Map{
+				"message": "content length is required",
+			})
+		}
+
+		if length > limit {
+			// The body is left unread, so the connection cannot be reused.
+			c.Context().SetConnectionClose()
+
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+				"message": "request body is too large",
+			})
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HeaderAcceptCurrency asks for the prices of the product listings in a
+// currency, e.g. "Accept-Currency: EUR". The currency query parameter takes
+// precedence over it.
+const HeaderAcceptCurrency = "Accept-Currency"
+
+// CacheControl lets clients and shared caches reuse successful responses for
+// maxAge, read on every request so it can be reloaded. With a maxAge of zero
+// they have to check back every time. Responses differ by API version and
+// currency, and say so in Vary.
+func CacheControl(maxAge func() time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+
+		if err != nil || c.Response().StatusCode() != fiber.StatusOK {
+			return err
+		}
+
+		if age := maxAge(); age > 0 {
+			c.Set(fiber.HeaderCacheControl, "public, max-age="+strconv.Itoa(int(age.Seconds())))
+		} else {
+			c.Set(fiber.HeaderCacheControl, "no-cache")
+		}
+
+		c.Vary(HeaderAPIVersion, HeaderAcceptCurrency)
+
+		return nil
+	}
+}
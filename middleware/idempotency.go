@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"product/config"
+	"product/idempotency"
+	"product/models"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed marks a response replayed from the store.
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+	// maxIdempotencyKeyLength bounds the keys clients may send.
+	maxIdempotencyKeyLength = 255
+)
+
+// replayedHeaders are the response headers kept with an idempotent response.
+var replayedHeaders = []string{fiber.HeaderContentType, fiber.HeaderLocation}
+
+// Idempotency replays the response to the first request sent with the same
+// Idempotency-Key header by the same user, or the same IP before login, for
+// the TTL current returns. Reusing a key for a different request is refused
+// with 422, and while the first request is still running with 409, for at
+// most the lease so that a crashed request does not hold the key. Server
+// errors are not kept, so they can be retried. Requests go through when the
+// store fails.
+func Idempotency(store idempotency.Store, current func() config.IdempotencyConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(HeaderIdempotencyKey)
+
+		if key == "" {
+			return c.Next()
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": "idempotency key must be at most 255 characters",
+			})
+		}
+
+		cfg := current()
+		now := time.Now()
+		record, reserved, err := store.Reserve(c.UserContext(), models.IdempotencyKey{
+			Key:         digest(idempotencyScope(c), key),
+			Fingerprint: digest(c.Method(), c.Path(), string(c.Body())),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(cfg.Lease),
+		}, now)
+
+		if err != nil {
+			slog.ErrorContext(c.UserContext(), "idempotency store failed", "error", err, "request_id", GetRequestID(c))
+			return c.Next()
+		}
+
+		if !reserved {
+			return replay(c, record)
+		}
+
+		completed := false
+
+		// The request context may be past its deadline by now, the key has
+		// to be released or completed all the same.
+		ctx := context.WithoutCancel(c.UserContext())
+
+		// Release the key when the handler fails or panics, so the request
+		// can be retried.
+		defer func() {
+			if !completed {
+				if err := store.Release(ctx, record); err != nil && !errors.Is(err, idempotency.ErrNotReserved) {
+					slog.ErrorContext(c.UserContext(), "failed to release idempotency key", "error", err, "request_id", GetRequestID(c))
+				}
+			}
+		}()
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		status := c.Response().StatusCode()
+
+		if status >= fiber.StatusInternalServerError {
+			return nil
+		}
+
+		record.Status = status
+		record.ExpiresAt = time.Now().Add(cfg.TTL)
+		record.Body = bytes.Clone(c.Response().Body())
+		record.Headers = map[string]string{}
+
+		for _, header := range replayedHeaders {
+			if value := c.GetRespHeader(header); value != "" {
+				record.Headers[header] = value
+			}
+		}
+
+		if err := store.Complete(ctx, record); errors.Is(err, idempotency.ErrNotReserved) {
+			// The lease ran out and the request that took the key over
+			// stores its own response.
+			slog.WarnContext(c.UserContext(), "idempotency key was taken over before the response was saved", "request_id", GetRequestID(c))
+			return nil
+		} else if err != nil {
+			slog.ErrorContext(c.UserContext(), "failed to save idempotent response", "error", err, "request_id", GetRequestID(c))
+			return nil
+		}
+
+		completed = true
+
+		return nil
+	}
+}
+
+// replay answers with the stored response of record, provided it was
+// stored for the same request.
+func replay(c *fiber.Ctx, record models.IdempotencyKey) error {
+	if record.Fingerprint != digest(c.Method(), c.Path(), string(c.Body())) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"message": "idempotency key was already used for a different request",
+		})
+	}
+
+	if record.Status == 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"message": "a request with this idempotency key is in progress",
+		})
+	}
+
+	for header, value := range record.Headers {
+		c.Set(header, value)
+	}
+
+	c.Set(HeaderIdempotentReplayed, "true")
+
+	return c.Status(record.Status).Send(record.Body)
+}
+
+// idempotencyScope names who a key belongs to, so that clients cannot read
+// each other's responses by guessing keys.
+func idempotencyScope(c *fiber.Ctx) string {
+	if user, ok := c.Locals(CurrentUserKey).(models.User); ok {
+		return "user:" + strconv.FormatUint(uint64(user.ID), 10)
+	}
+
+	return "ip:" + c.IP()
+}
+
+// digest hashes parts, separated so that they cannot run into each other.
+func digest(parts ...string) string {
+	h := sha256.New()
+
+	for _, part := range parts {
+		h.Write([]byte(strconv.Itoa(len(part))))
+		h.Write([]byte{':'})
+		h.Write([]byte(part))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"log/slog"
+	"product/models"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Logger writes one structured entry per request. Server errors are logged at
+// error level and client errors at warn level.
+func Logger(logger *slog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		err := c.Next()
+
+		if err != nil {
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				c.Status(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		user, _ := c.Locals(CurrentUserKey).(models.User)
+
+		attrs := []any{
+			"request_id", GetRequestID(c),
+			"method", c.Method(),
+			"path", c.Path(),
+			"status", status,
+			"latency", time.Since(start),
+			"ip", c.IP(),
+			"user_id", user.ID,
+		}
+
+		if spanContext := trace.SpanContextFromContext(c.UserContext()); spanContext.IsValid() {
+			attrs = append(attrs, "trace_id", spanContext.TraceID().String())
+		}
+
+		if err != nil {
+			attrs = append(attrs, "error", err)
+		}
+
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			logger.Error("request", attrs...)
+		case status >= fiber.StatusBadRequest:
+			logger.Warn("request", attrs...)
+		default:
+			logger.Info("request", attrs...)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"product/models"
+	"product/ratelimit"
+	"product/utils"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const HeaderAPIKey = "X-API-Key"
+
+// APIKeyDigestKey holds the digest of the API key of the request once APIKey
+// has recognised it.
+const APIKeyDigestKey = "apiKeyDigest"
+
+// APIKey recognises the API key of the request when its SHA-256 digest, hex
+// encoded, is one of digests. Unknown keys are ignored rather than rejected,
+// the requests are then limited by IP.
+func APIKey(digests func() []string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if apiKey := c.Get(HeaderAPIKey); apiKey != "" {
+			if digest := utils.HashToken(apiKey); slices.Contains(digests(), digest) {
+				c.Locals(APIKeyDigestKey, digest)
+			}
+		}
+
+		return c.Next()
+	}
+}
+
+// RateLimit counts requests against the policy returned by policy, which is
+// looked up on every request so policies follow configuration reloads. Buckets
+// are named after name and the policy key. Requests are let through when the
+// store fails, so an outage of the store does not take the API down with it.
+func RateLimit(store ratelimit.Store, name string, policy func() ratelimit.Policy) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		p := policy()
+
+		if !p.Enabled() {
+			return c.Next()
+		}
+
+		result, err := store.Take(c.UserContext(), name+":"+rateLimitKey(c, p.Key), p)
+
+		if err != nil {
+			slog.ErrorContext(c.UserContext(), "rate limit store failed", "error", err, "request_id", GetRequestID(c))
+			return c.Next()
+		}
+
+		c.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Set("RateLimit-Reset", ceilSeconds(result.Reset))
+
+		if !result.Allowed {
+			c.Set(fiber.HeaderRetryAfter, ceilSeconds(result.RetryAfter))
+
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"message": "too many requests",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// rateLimitKey identifies the client by the kind of key asked for, falling
+// back to the IP when the request has no user or recognised API key.
+func rateLimitKey(c *fiber.Ctx, key string) string {
+	switch key {
+	case ratelimit.KeyUser:
+		if user, ok := c.Locals(CurrentUserKey).(models.User); ok {
+			return "user:" + strconv.FormatUint(uint64(user.ID), 10)
+		}
+	case ratelimit.KeyAPIKey:
+		// Only a digest is kept so the shared store never holds API keys.
+		if digest, ok := c.Locals(APIKeyDigestKey).(string); ok {
+			return "api_key:" + digest[:32]
+		}
+	}
+
+	return "ip:" + c.IP()
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"product/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestIDKey is the fiber.Ctx locals key holding the id of the current request.
+const RequestIDKey = "requestID"
+
+const maxRequestIDLength = 100
+
+// RequestID reuses the X-Request-ID sent by the client or generates a new one,
+// stores it in the locals and echoes it back in the response headers.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(fiber.HeaderXRequestID)
+
+		if id == "" || len(id) > maxRequestIDLength {
+			generated, err := utils.RandomToken(16)
+
+			if err != nil {
+				return err
+			}
+
+			id = generated
+		}
+
+		c.Locals(RequestIDKey, id)
+		c.Set(fiber.HeaderXRequestID, id)
+
+		return c.Next()
+	}
+}
+
+// GetRequestID returns the id assigned by RequestID, or an empty string.
+func GetRequestID(c *fiber.Ctx) string {
+	id, _ := c.Locals(RequestIDKey).(string)
+
+	return id
+}
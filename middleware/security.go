@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"product/config"
+	"product/utils"
+	"reflect"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+)
+
+// CORS answers preflight requests and adds the CORS headers for the origins
+// current returns. It does nothing when no origin is allowed. The handler is
+// rebuilt whenever the configuration changes.
+func CORS(current func() config.CORSConfig) fiber.Handler {
+	type built struct {
+		cfg     config.CORSConfig
+		handler fiber.Handler
+	}
+
+	var last atomic.Pointer[built]
+
+	return func(c *fiber.Ctx) error {
+		cfg := current()
+		b := last.Load()
+
+		if b == nil || !reflect.DeepEqual(b.cfg, cfg) {
+			b = &built{cfg: cfg, handler: newCORS(cfg)}
+			last.Store(b)
+		}
+
+		return b.handler(c)
+	}
+}
+
+func newCORS(cfg config.CORSConfig) fiber.Handler {
+	if len(cfg.AllowedOrigins) == 0 {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	return cors.New(cors.Config{
+		AllowOrigins:     strings.Join(cfg.AllowedOrigins, ","),
+		AllowMethods:     strings.Join(cfg.AllowedMethods, ","),
+		AllowHeaders:     strings.Join(cfg.AllowedHeaders, ","),
+		ExposeHeaders:    strings.Join(cfg.ExposedHeaders, ","),
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           int(cfg.MaxAge.Seconds()),
+	})
+}
+
+// SecurityHeaders sets the headers hardening browsers against the responses,
+// as current returns them. HSTS is only sent on HTTPS requests.
+func SecurityHeaders(current func() config.HeadersConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		cfg := current()
+		hsts := ""
+
+		if cfg.HSTSMaxAge > 0 {
+			hsts = fmt.Sprintf("max-age=%d", int(cfg.HSTSMaxAge.Seconds()))
+
+			if cfg.HSTSIncludeSubdomains {
+				hsts += "; includeSubDomains"
+			}
+		}
+
+		if cfg.ContentTypeNosniff {
+			c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+		}
+
+		if cfg.FrameOptions != "" {
+			c.Set(fiber.HeaderXFrameOptions, cfg.FrameOptions)
+		}
+
+		if cfg.ContentSecurityPolicy != "" {
+			c.Set(fiber.HeaderContentSecurityPolicy, cfg.ContentSecurityPolicy)
+		}
+
+		if hsts != "" && c.Secure() {
+			c.Set(fiber.HeaderStrictTransportSecurity, hsts)
+		}
+
+		return c.Next()
+	}
+}
+
+// CSRF guards requests carrying the session cookie with a double submit
+// token. Safe requests receive the token in a cookie readable by scripts;
+// other requests must echo it in the configured header. Requests without the
+// session cookie, such as bearer token requests, are let through since a
+// browser cannot be tricked into sending their credentials.
+func CSRF(current func() config.CSRFConfig, sessionCookie string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		cfg := current()
+
+		if !cfg.Enabled || c.Cookies(sessionCookie) == "" {
+			return c.Next()
+		}
+
+		token := c.Cookies(cfg.CookieName)
+
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions, fiber.MethodTrace:
+			if token == "" {
+				token, err := utils.RandomToken(32)
+
+				if err != nil {
+					return err
+				}
+
+				c.Cookie(&fiber.Cookie{
+					Name:     cfg.CookieName,
+					Value:    token,
+					Path:     "/",
+					Secure:   cfg.CookieSecure,
+					SameSite: cfg.CookieSameSite,
+					MaxAge:   int(cfg.Expiration.Seconds()),
+				})
+			}
+
+			return c.Next()
+		}
+
+		header := c.Get(cfg.HeaderName)
+
+		if token == "" || subtle.ConstantTimeCompare([]byte(header), []byte(token)) != 1 {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"message": "invalid csrf token",
+			})
+		}
+
+		return c.Next()
+	}
+}
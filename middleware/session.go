@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"product/config"
+	"product/models"
+	"product/session"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SessionKey is the fiber.Ctx locals key holding the models.Session of
+// requests authenticated by the session cookie.
+const SessionKey = "session"
+
+// Authenticate accepts the session cookie or a bearer token checked by jwt.
+// A request sending both is authenticated by the bearer token. It must run
+// before CurrentUser.
+func Authenticate(sessions *session.Manager, cfg config.AuthConfig, jwt fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := c.Cookies(cfg.SessionCookie)
+
+		if token == "" || c.Get(fiber.HeaderAuthorization) != "" {
+			return jwt(c)
+		}
+
+		s, refreshed, err := sessions.Authenticate(c.UserContext(), token)
+
+		if errors.Is(err, session.ErrNotFound) {
+			ClearSessionCookie(c, cfg)
+			return unauthorized(c, "invalid session")
+		}
+
+		if err != nil {
+			slog.ErrorContext(c.UserContext(), "session store failed", "error", err, "request_id", GetRequestID(c))
+			return unauthorized(c, "invalid session")
+		}
+
+		if refreshed {
+			SetSessionCookie(c, cfg, token, s.ExpiresAt)
+		}
+
+		c.Locals(SessionKey, s)
+
+		return c.Next()
+	}
+}
+
+// GetSession returns the session of a request authenticated by cookie.
+func GetSession(c *fiber.Ctx) (models.Session, bool) {
+	s, ok := c.Locals(SessionKey).(models.Session)
+
+	return s, ok
+}
+
+func SetSessionCookie(c *fiber.Ctx, cfg config.AuthConfig, token string, expires time.Time) {
+	c.Cookie(&fiber.Cookie{
+		Name:     cfg.SessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		Secure:   cfg.SessionCookieSecure,
+		HTTPOnly: true,
+		SameSite: cfg.SessionCookieSameSite,
+	})
+}
+
+func ClearSessionCookie(c *fiber.Ctx, cfg config.AuthConfig) {
+	c.Cookie(&fiber.Cookie{
+		Name:     cfg.SessionCookie,
+		Path:     "/",
+		Expires:  time.Unix(0, 0),
+		Secure:   cfg.SessionCookieSecure,
+		HTTPOnly: true,
+		SameSite: cfg.SessionCookieSameSite,
+	})
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Timeout puts a deadline on the user context of the request. Services receive
+// that context, so database calls still running when it expires are cancelled.
+// timeout is called on every request so the deadline follows configuration
+// reloads.
+func Timeout(timeout func() time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		timeout := timeout()
+
+		if timeout <= 0 {
+			return c.Next()
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+		defer cancel()
+
+		c.SetUserContext(ctx)
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("product/http")
+
+// Tracing starts a server span for every request, continuing the trace from
+// an incoming traceparent header, and stores the span in the user context so
+// handlers can pass it on to the services.
+func Tracing() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		header := http.Header{}
+		c.Request().Header.VisitAll(func(key []byte, value []byte) {
+			header.Add(string(key), string(value))
+		})
+
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), propagation.HeaderCarrier(header))
+
+		ctx, span := tracer.Start(ctx, c.Method()+" "+c.Path(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethod(c.Method()),
+				semconv.URLPath(c.Path()),
+				attribute.String("http.request_id", GetRequestID(c)),
+			),
+		)
+		defer span.End()
+
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		// The route is only known once the router has matched it.
+		route := c.Route().Path
+		span.SetName(c.Method() + " " + route)
+
+		status := c.Response().StatusCode()
+
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			status = fiberErr.Code
+		} else if err != nil {
+			status = fiber.StatusInternalServerError
+		}
+
+		span.SetAttributes(semconv.HTTPRoute(route), semconv.HTTPStatusCode(status))
+
+		if err != nil {
+			span.RecordError(err)
+		}
+
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+
+		return err
+	}
+}
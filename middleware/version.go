@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"net/http"
+	"product/config"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HeaderAPIVersion selects the version of unprefixed routes, e.g.
+// "API-Version: v2" or "API-Version: 2". Every API response carries it too.
+const HeaderAPIVersion = "API-Version"
+
+// APIVersionKey is the fiber.Ctx locals key holding the API version serving
+// the request.
+const APIVersionKey = "apiVersion"
+
+// NegotiateVersion routes requests for unprefixed paths asking for one of
+// versions in the API-Version header to that version's prefix. Requests
+// without the header keep their path. It must be registered before the
+// versioned routes.
+func NegotiateVersion(versions []string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requested := c.Get(HeaderAPIVersion)
+
+		if requested == "" || hasVersionPrefix(c.Path(), versions) {
+			return c.Next()
+		}
+
+		if !strings.HasPrefix(requested, "v") {
+			requested = "v" + requested
+		}
+
+		for _, version := range versions {
+			if version == requested {
+				c.Path("/" + version + c.Path())
+				return c.Next()
+			}
+		}
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "unsupported api version",
+		})
+	}
+}
+
+func hasVersionPrefix(path string, versions []string) bool {
+	for _, version := range versions {
+		if path == "/"+version || strings.HasPrefix(path, "/"+version+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// APIVersion marks the responses of version and, when deprecations lists it,
+// adds the Deprecation, Sunset and Link headers.
+func APIVersion(version string, deprecations map[string]config.DeprecationConfig) fiber.Handler {
+	deprecation, deprecated := deprecations[version]
+
+	return func(c *fiber.Ctx) error {
+		c.Locals(APIVersionKey, version)
+		c.Set(HeaderAPIVersion, version)
+
+		if deprecated {
+			c.Set("Deprecation", "@"+strconv.FormatInt(deprecation.Deprecation.Unix(), 10))
+
+			if !deprecation.Sunset.IsZero() {
+				c.Set("Sunset", deprecation.Sunset.UTC().Format(http.TimeFormat))
+			}
+
+			if deprecation.Link != "" {
+				c.Append(fiber.HeaderLink, "<"+deprecation.Link+`>; rel="deprecation"`)
+			}
+		}
+
+		return c.Next()
+	}
+}
+
+// GetAPIVersion returns the API version serving the request.
+func GetAPIVersion(c *fiber.Ctx) string {
+	version, _ := c.Locals(APIVersionKey).(string)
+
+	return version
+}
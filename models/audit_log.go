@@ -0,0 +1,107 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+const (
+	EntityProduct = "product"
+	EntityUser    = "user"
+	EntityWebhook = "webhook"
+	// EntityPriceSchedule is a planned price change of a product.
+	EntityPriceSchedule = "price_schedule"
+	EntityExchangeRate  = "exchange_rate"
+	// EntityProductPrice is the price of a product in another currency.
+	EntityProductPrice = "product_price"
+)
+
+var ErrAuditLogAppendOnly = errors.New("audit logs are append-only")
+
+// AuditLogTriggers reject updates and deletes of audit logs in the database,
+// keyed by trigger name, so that raw statements are refused like the hooks
+// below refuse them through GORM.
+var AuditLogTriggers = map[string]string{
+	"audit_logs_no_update": "BEFORE UPDATE",
+	"audit_logs_no_delete": "BEFORE DELETE",
+}
+
+// Actor describes who performs a mutating operation and where the request came from.
+type Actor struct {
+	ID        uint
+	Email     string
+	IP        string
+	RequestID string
+}
+
+type AuditLog struct {
+	ID         uint      `gorm:"primaryKey"`
+	ActorID    uint      `gorm:"index"`
+	ActorEmail string    `gorm:"type:varchar(100)"`
+	Action     string    `gorm:"type:varchar(20)"`
+	EntityType string    `gorm:"type:varchar(50);index:idx_audit_logs_entity"`
+	EntityID   uint      `gorm:"index:idx_audit_logs_entity"`
+	Changes    string    `gorm:"type:text"`
+	IP         string    `gorm:"type:varchar(45)"`
+	RequestID  string    `gorm:"type:varchar(100)"`
+	CreatedAt  time.Time `gorm:"index"`
+}
+
+// Change is the before and after value of a single field.
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+type AuditLogResponse struct {
+	ID         uint            `json:"id"`
+	ActorID    uint            `json:"actor_id"`
+	ActorEmail string          `json:"actor_email"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   uint            `json:"entity_id"`
+	Changes    json.RawMessage `json:"changes"`
+	IP         string          `json:"ip"`
+	RequestID  string          `json:"request_id"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type AuditFilter struct {
+	EntityType string
+	EntityID   uint
+	ActorID    uint
+	From       time.Time
+	To         time.Time
+}
+
+func (a *AuditLog) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditLogAppendOnly
+}
+
+func (a *AuditLog) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditLogAppendOnly
+}
+
+func (a *AuditLog) ConvertToResponse() AuditLogResponse {
+	return AuditLogResponse{
+		ID:         a.ID,
+		ActorID:    a.ActorID,
+		ActorEmail: a.ActorEmail,
+		Action:     a.Action,
+		EntityType: a.EntityType,
+		EntityID:   a.EntityID,
+		Changes:    json.RawMessage(a.Changes),
+		IP:         a.IP,
+		RequestID:  a.RequestID,
+		CreatedAt:  a.CreatedAt,
+	}
+}
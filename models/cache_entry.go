@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// CacheEntry is a value of the shared cache, as JSON.
+type CacheEntry struct {
+	Key       string    `gorm:"primaryKey;type:varchar(255)"`
+	Value     []byte    `gorm:"type:mediumblob"`
+	ExpiresAt time.Time `gorm:"index"`
+}
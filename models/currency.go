@@ -0,0 +1,103 @@
+package models
+
+import (
+	"encoding/json"
+	"product/money"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ExchangeRate is the number of units of Currency one unit of the base
+// currency is worth, kept as a decimal to convert amounts exactly.
+type ExchangeRate struct {
+	ID        uint   `gorm:"primaryKey"`
+	Currency  string `gorm:"type:char(3);uniqueIndex"`
+	Rate      string `gorm:"type:decimal(24,10)"`
+	UpdatedAt time.Time
+}
+
+type ExchangeRateRequest struct {
+	Rate json.Number `json:"rate" validate:"required"`
+}
+
+// Validate also checks that the rate is a positive number.
+func (e *ExchangeRateRequest) Validate() error {
+	if err := validator.New().Struct(e); err != nil {
+		return err
+	}
+
+	_, err := money.ParseRate(e.Rate.String())
+
+	return err
+}
+
+type ExchangeRateResponse struct {
+	Currency  string    `json:"currency"`
+	Rate      string    `json:"rate"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ConvertToResponse drops the trailing zeros the database pads the rate
+// with.
+func (e *ExchangeRate) ConvertToResponse() ExchangeRateResponse {
+	rate := e.Rate
+
+	if parsed, err := money.ParseRate(rate); err == nil {
+		rate = money.FormatRate(parsed)
+	}
+
+	return ExchangeRateResponse{
+		Currency:  e.Currency,
+		Rate:      rate,
+		UpdatedAt: e.UpdatedAt,
+	}
+}
+
+// ProductPrice is the price of a product in a currency other than the base
+// currency, used instead of converting its base price.
+type ProductPrice struct {
+	ID        uint   `gorm:"primaryKey"`
+	ProductID uint   `gorm:"uniqueIndex:idx_product_prices_currency"`
+	Currency  string `gorm:"type:char(3);uniqueIndex:idx_product_prices_currency"`
+	Amount    int64
+	UpdatedAt time.Time
+}
+
+type ProductPriceRequest struct {
+	// Amount is in minor units of the currency.
+	Amount int64 `json:"amount" validate:"required,min=1"`
+}
+
+func (p *ProductPriceRequest) Validate() error {
+	return validator.New().Struct(p)
+}
+
+type ProductPriceResponse struct {
+	MoneyResponse
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ConvertToResponse needs the currency of the price to format it.
+func (p *ProductPrice) ConvertToResponse(currency money.Currency) ProductPriceResponse {
+	return ProductPriceResponse{
+		MoneyResponse: NewMoneyResponse(money.New(p.Amount, currency)),
+		UpdatedAt:     p.UpdatedAt,
+	}
+}
+
+// MoneyResponse is an amount in minor units of currency, with its rendering
+// for display.
+type MoneyResponse struct {
+	Amount    int64  `json:"amount"`
+	Currency  string `json:"currency"`
+	Formatted string `json:"formatted"`
+}
+
+func NewMoneyResponse(m money.Money) MoneyResponse {
+	return MoneyResponse{
+		Amount:    m.Amount,
+		Currency:  m.Currency.Code,
+		Formatted: m.String(),
+	}
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// IdempotencyKey holds the response to the first request sent with an
+// Idempotency-Key header. Status is zero while that request is in progress;
+// ExpiresAt is then the end of its lease.
+type IdempotencyKey struct {
+	// Key is a digest of the client key and who sent it.
+	Key string `gorm:"primaryKey;type:varchar(64)"`
+	// Fingerprint is a digest of the method, path and body of the request.
+	Fingerprint string `gorm:"type:varchar(64)"`
+	Status      int
+	Headers     map[string]string `gorm:"serializer:json;type:text"`
+	Body        []byte            `gorm:"type:mediumblob"`
+	CreatedAt   time.Time
+	ExpiresAt   time.Time `gorm:"index"`
+}
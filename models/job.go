@@ -0,0 +1,78 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	// JobDead jobs ran out of attempts or failed permanently. They stay
+	// until an admin retries them.
+	JobDead      = "dead"
+	JobCancelled = "cancelled"
+)
+
+// Job is a unit of background work run by the queue once RunAt has passed.
+// Payload is the argument of the job handler as JSON.
+type Job struct {
+	ID          uint      `gorm:"primaryKey"`
+	Type        string    `gorm:"type:varchar(100);index"`
+	Payload     string    `gorm:"type:mediumtext"`
+	Status      string    `gorm:"type:varchar(20);index:idx_jobs_status_run_at"`
+	RunAt       time.Time `gorm:"index:idx_jobs_status_run_at"`
+	Attempts    int
+	MaxAttempts int
+	LastError   string `gorm:"type:text"`
+	// LockedBy names the worker running the job.
+	LockedBy   string `gorm:"type:varchar(100)"`
+	LockedAt   *time.Time
+	FinishedAt *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type JobResponse struct {
+	ID          uint            `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	RunAt       time.Time       `json:"run_at"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+	LockedBy    string          `json:"locked_by,omitempty"`
+	LockedAt    *time.Time      `json:"locked_at,omitempty"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+func (j *Job) ConvertToResponse() JobResponse {
+	return JobResponse{
+		ID:          j.ID,
+		Type:        j.Type,
+		Payload:     json.RawMessage(j.Payload),
+		Status:      j.Status,
+		RunAt:       j.RunAt,
+		Attempts:    j.Attempts,
+		MaxAttempts: j.MaxAttempts,
+		LastError:   j.LastError,
+		LockedBy:    j.LockedBy,
+		LockedAt:    j.LockedAt,
+		FinishedAt:  j.FinishedAt,
+		CreatedAt:   j.CreatedAt,
+	}
+}
+
+type JobFilter struct {
+	Status string `query:"status" validate:"omitempty,oneof=queued running succeeded dead cancelled"`
+	Type   string `query:"type"`
+}
+
+func (f *JobFilter) Validate() error {
+	return validator.New().Struct(f)
+}
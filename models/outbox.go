@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	OutboxPending   = "pending"
+	OutboxPublished = "published"
+	// OutboxFailed messages ran out of attempts. They hold back the later
+	// messages of their aggregate until an admin retries or discards them.
+	OutboxFailed    = "failed"
+	OutboxDiscarded = "discarded"
+)
+
+// OutboxMessage is an event written in the transaction of the change it
+// describes, waiting to be relayed. Payload is the event as JSON.
+type OutboxMessage struct {
+	ID            uint   `gorm:"primaryKey"`
+	EventID       string `gorm:"type:varchar(64);uniqueIndex"`
+	Type          string `gorm:"type:varchar(50)"`
+	Aggregate     string `gorm:"type:varchar(50);index:idx_outbox_messages_aggregate"`
+	AggregateID   uint   `gorm:"index:idx_outbox_messages_aggregate"`
+	Payload       string `gorm:"type:mediumtext"`
+	Status        string `gorm:"type:varchar(20);index"`
+	Attempts      int
+	LastError     string `gorm:"type:text"`
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	PublishedAt   *time.Time
+}
+
+type OutboxMessageResponse struct {
+	ID            uint       `json:"id"`
+	EventID       string     `json:"event_id"`
+	Type          string     `json:"type"`
+	Aggregate     string     `json:"aggregate"`
+	AggregateID   uint       `json:"aggregate_id"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	PublishedAt   *time.Time `json:"published_at,omitempty"`
+}
+
+func (m *OutboxMessage) ConvertToResponse() OutboxMessageResponse {
+	return OutboxMessageResponse{
+		ID:            m.ID,
+		EventID:       m.EventID,
+		Type:          m.Type,
+		Aggregate:     m.Aggregate,
+		AggregateID:   m.AggregateID,
+		Status:        m.Status,
+		Attempts:      m.Attempts,
+		LastError:     m.LastError,
+		NextAttemptAt: m.NextAttemptAt,
+		CreatedAt:     m.CreatedAt,
+		PublishedAt:   m.PublishedAt,
+	}
+}
+
+type OutboxFilter struct {
+	Status      string `query:"status" validate:"omitempty,oneof=pending published failed discarded"`
+	Aggregate   string `query:"aggregate"`
+	AggregateID uint   `query:"aggregate_id"`
+}
+
+func (f *OutboxFilter) Validate() error {
+	return validator.New().Struct(f)
+}
+
+// OutboxStats sums up the outbox. Retrying counts the pending messages
+// that failed at least once, and OldestPendingAt is nil when none is
+// pending.
+type OutboxStats struct {
+	Pending         int64      `json:"pending"`
+	Retrying        int64      `json:"retrying"`
+	Failed          int64      `json:"failed"`
+	Published       int64      `json:"published"`
+	Discarded       int64      `json:"discarded"`
+	OldestPendingAt *time.Time `json:"oldest_pending_at"`
+}
@@ -0,0 +1,153 @@
+package models
+
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Sources of a price change.
+const (
+	PriceCreated   = "create"
+	PriceUpdated   = "update"
+	PriceScheduled = "schedule"
+)
+
+const (
+	ScheduleScheduled = "scheduled"
+	// ScheduleActive promotions have started and wait for their end.
+	ScheduleActive    = "active"
+	ScheduleCompleted = "completed"
+	ScheduleCancelled = "cancelled"
+)
+
+// PriceChange is an entry of the price history of a product. OldPrice is
+// zero for the initial price of a product, recorded with Source create.
+type PriceChange struct {
+	ID         uint `gorm:"primaryKey"`
+	ProductID  uint `gorm:"index:idx_price_changes_product"`
+	OldPrice   int
+	Price      int
+	Source     string `gorm:"type:varchar(20)"`
+	ScheduleID *uint
+	ActorID    uint
+	ActorEmail string    `gorm:"type:varchar(100)"`
+	ChangedAt  time.Time `gorm:"index:idx_price_changes_product"`
+}
+
+type PriceChangeResponse struct {
+	ID         uint      `json:"id"`
+	OldPrice   int       `json:"old_price"`
+	Price      int       `json:"price"`
+	Source     string    `json:"source"`
+	ScheduleID *uint     `json:"schedule_id,omitempty"`
+	ActorID    uint      `json:"actor_id"`
+	ActorEmail string    `json:"actor_email"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
+
+func (p *PriceChange) ConvertToResponse() PriceChangeResponse {
+	return PriceChangeResponse{
+		ID:         p.ID,
+		OldPrice:   p.OldPrice,
+		Price:      p.Price,
+		Source:     p.Source,
+		ScheduleID: p.ScheduleID,
+		ActorID:    p.ActorID,
+		ActorEmail: p.ActorEmail,
+		ChangedAt:  p.ChangedAt,
+	}
+}
+
+// PriceSchedule changes the price of a product to Price at StartsAt. With an
+// EndsAt it is a promotion, and PreviousPrice, the price it replaced, comes
+// back at EndsAt.
+type PriceSchedule struct {
+	ID            uint `gorm:"primaryKey"`
+	ProductID     uint `gorm:"index"`
+	Price         int
+	StartsAt      time.Time
+	EndsAt        *time.Time
+	Status        string `gorm:"type:varchar(20);index"`
+	PreviousPrice int
+	// The creator of the schedule is the actor of the changes it makes.
+	CreatedByID    uint
+	CreatedByEmail string `gorm:"type:varchar(100)"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// until is the end of the period of s, excluded. A change without end only
+// takes the instant it applies at.
+func (s *PriceSchedule) until() time.Time {
+	if s.EndsAt != nil {
+		return *s.EndsAt
+	}
+
+	return s.StartsAt.Add(time.Nanosecond)
+}
+
+// Overlaps reports whether s and other change the price over a common
+// period.
+func (s *PriceSchedule) Overlaps(other PriceSchedule) bool {
+	return s.StartsAt.Before(other.until()) && other.StartsAt.Before(s.until())
+}
+
+// Actor is who the changes made by the schedule are recorded for.
+func (s *PriceSchedule) Actor() Actor {
+	return Actor{
+		ID:    s.CreatedByID,
+		Email: s.CreatedByEmail,
+	}
+}
+
+type PriceScheduleRequest struct {
+	Price    int        `json:"price" validate:"required,min=1"`
+	StartsAt time.Time  `json:"starts_at" validate:"required"`
+	EndsAt   *time.Time `json:"ends_at" validate:"omitempty,gtfield=StartsAt"`
+}
+
+func (p *PriceScheduleRequest) Validate() error {
+	return validator.New().Struct(p)
+}
+
+func (p *PriceScheduleRequest) ConvertToSchedule() PriceSchedule {
+	return PriceSchedule{
+		Price:    p.Price,
+		StartsAt: p.StartsAt,
+		EndsAt:   p.EndsAt,
+	}
+}
+
+type PriceScheduleResponse struct {
+	ID            uint       `json:"id"`
+	ProductID     uint       `json:"product_id"`
+	Price         int        `json:"price"`
+	StartsAt      time.Time  `json:"starts_at"`
+	EndsAt        *time.Time `json:"ends_at,omitempty"`
+	Status        string     `json:"status"`
+	PreviousPrice int        `json:"previous_price,omitempty"`
+	CreatedByID   uint       `json:"created_by_id"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func (s *PriceSchedule) ConvertToResponse() PriceScheduleResponse {
+	return PriceScheduleResponse{
+		ID:            s.ID,
+		ProductID:     s.ProductID,
+		Price:         s.Price,
+		StartsAt:      s.StartsAt,
+		EndsAt:        s.EndsAt,
+		Status:        s.Status,
+		PreviousPrice: s.PreviousPrice,
+		CreatedByID:   s.CreatedByID,
+		CreatedAt:     s.CreatedAt,
+	}
+}
+
+// PriceAtResponse is the price of a product at a time.
+type PriceAtResponse struct {
+	ProductID uint      `json:"product_id"`
+	At        time.Time `json:"at"`
+	Price     int       `json:"price"`
+}
@@ -2,12 +2,20 @@ package models
 
 import "github.com/go-playground/validator/v10"
 
+// Product has its Price in minor units of Currency, e.g. cents. Currency is
+// always the base currency: the application refuses to start when the
+// stored prices are in another one.
 type Product struct {
 	ID          uint   `gorm:"primaryKey"`
 	Name        string `gorm:"type:varchar(100)"`
 	Description string `gorm:"type:varchar(250)"`
 	Price       int
+	Currency    string `gorm:"type:char(3);index"`
 	Stock       int
+	UserID      uint `gorm:"index"`
+	// SKU is the natural key matched by imports. It is optional, hence a
+	// pointer: NULLs do not collide in the unique index.
+	SKU *string `gorm:"type:varchar(64);uniqueIndex"`
 }
 
 type ProductResponse struct {
@@ -16,6 +24,17 @@ type ProductResponse struct {
 	Description string `json:"description"`
 	Price       int    `json:"price"`
 	Stock       int    `json:"stock"`
+	UserID      uint   `json:"user_id"`
+	SKU         string `json:"sku,omitempty"`
+	// PriceMoney is the price formatted in the currency asked for. It is
+	// only set in the responses of the product routes.
+	PriceMoney *MoneyResponse `json:"price_money,omitempty"`
+}
+
+// ProductStats summarises the whole catalog.
+type ProductStats struct {
+	Count      int64
+	StockValue int64
 }
 
 type ProductRequest struct {
@@ -23,6 +42,7 @@ type ProductRequest struct {
 	Description string `json:"description" form:"description" validate:"required"`
 	Price       int    `json:"price" form:"price" validate:"required"`
 	Stock       int    `json:"stock" form:"stock" validate:"required"`
+	SKU         string `json:"sku" form:"sku" validate:"omitempty,max=64"`
 }
 
 func (p *ProductRequest) Validate() error {
@@ -40,14 +60,44 @@ func (p *Product) ConvertToResponse() ProductResponse {
 		Description: p.Description,
 		Price:       p.Price,
 		Stock:       p.Stock,
+		UserID:      p.UserID,
+		SKU:         p.GetSKU(),
+	}
+}
+
+// GetSKU returns the SKU, or "" when the product has none.
+func (p *Product) GetSKU() string {
+	if p.SKU == nil {
+		return ""
+	}
+
+	return *p.SKU
+}
+
+// ApplyTo copies the request onto an existing product. An empty SKU leaves
+// the SKU of the product unchanged.
+func (p *ProductRequest) ApplyTo(product *Product) {
+	product.Name = p.Name
+	product.Description = p.Description
+	product.Price = p.Price
+	product.Stock = p.Stock
+
+	if p.SKU != "" {
+		product.SKU = &p.SKU
 	}
 }
 
 func (p *ProductRequest) ConvertToProduct() Product {
-	return Product{
+	product := Product{
 		Name:        p.Name,
 		Description: p.Description,
 		Price:       p.Price,
 		Stock:       p.Stock,
 	}
+
+	if p.SKU != "" {
+		product.SKU = &p.SKU
+	}
+
+	return product
 }
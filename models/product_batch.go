@@ -0,0 +1,55 @@
+package models
+
+import "github.com/go-playground/validator/v10"
+
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+const (
+	// BatchAllOrNothing applies every operation in one transaction, or none.
+	BatchAllOrNothing = "all_or_nothing"
+	// BatchPerItem applies each operation on its own and reports each result.
+	BatchPerItem = "per_item"
+)
+
+// BatchOperation is one item of a batch. ID is required to update or delete
+// a product, Product to create or update one.
+type BatchOperation struct {
+	Op      string          `json:"op" validate:"required,oneof=create update delete"`
+	ID      uint            `json:"id,omitempty" validate:"required_unless=Op create"`
+	Product *ProductRequest `json:"product,omitempty" validate:"required_unless=Op delete"`
+}
+
+func (o *BatchOperation) Validate() error {
+	return validator.New().Struct(o)
+}
+
+type BatchOptions struct {
+	Mode string `json:"mode" query:"mode" validate:"oneof=all_or_nothing per_item"`
+}
+
+func (o *BatchOptions) Validate() error {
+	return validator.New().Struct(o)
+}
+
+// BatchResult is the outcome of the operation at Index as an HTTP status:
+// 200, or 201 for a creation, once applied, 404 when its product does not
+// exist, 409 when its SKU is used by another product, 424 when it was rolled
+// back with the rest of the batch and 500 when it failed to save.
+type BatchResult struct {
+	Index   int              `json:"index"`
+	Op      string           `json:"op"`
+	Status  int              `json:"status"`
+	Error   string           `json:"error,omitempty"`
+	Product *ProductResponse `json:"product,omitempty"`
+}
+
+type BatchReport struct {
+	Mode      string        `json:"mode"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	// ImportAllOrNothing writes no row unless every row is valid.
+	ImportAllOrNothing = "all_or_nothing"
+	// ImportPerRow writes the valid rows and reports the others.
+	ImportPerRow = "per_row"
+)
+
+const (
+	ImportPending   = "pending"
+	ImportRunning   = "running"
+	ImportSucceeded = "succeeded"
+	ImportFailed    = "failed"
+)
+
+// maxImportErrors caps the row errors kept in a report.
+const maxImportErrors = 1000
+
+// ImportRow is a product read from an uploaded file. Error is set when the
+// line could not be read into Request.
+type ImportRow struct {
+	Line    int
+	Request ProductRequest
+	Error   string
+}
+
+// ImportSource passes the rows of an upload in order to fn, stopping at the
+// first error fn returns. It can be read more than once.
+type ImportSource interface {
+	Each(fn func(row ImportRow) error) error
+}
+
+type ImportOptions struct {
+	Mode   string `json:"mode" query:"mode" validate:"oneof=all_or_nothing per_row"`
+	DryRun bool   `json:"dry_run" query:"dry_run"`
+}
+
+func (o *ImportOptions) Validate() error {
+	return validator.New().Struct(o)
+}
+
+type ImportRowError struct {
+	Line    int    `json:"line"`
+	SKU     string `json:"sku,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportReport sums up an import. In a dry run Created and Updated count
+// what the import would have done.
+type ImportReport struct {
+	Mode    string           `json:"mode"`
+	DryRun  bool             `json:"dry_run"`
+	Total   int              `json:"total"`
+	Created int              `json:"created"`
+	Updated int              `json:"updated"`
+	Failed  int              `json:"failed"`
+	Errors  []ImportRowError `json:"errors"`
+}
+
+// AddError records the failure of a row. Only the first errors are kept so
+// the report of a broken file stays small.
+func (r *ImportReport) AddError(line int, sku string, message string) {
+	r.Failed++
+
+	if len(r.Errors) < maxImportErrors {
+		r.Errors = append(r.Errors, ImportRowError{Line: line, SKU: sku, Message: message})
+	}
+}
+
+// ImportJob is an import running in the background.
+type ImportJob struct {
+	ID         uint   `gorm:"primaryKey"`
+	UserID     uint   `gorm:"index"`
+	Status     string `gorm:"type:varchar(20)"`
+	Processed  int
+	Report     ImportReport `gorm:"serializer:json;type:mediumtext"`
+	Error      string       `gorm:"type:varchar(255)"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	FinishedAt *time.Time
+}
+
+type ImportJobResponse struct {
+	ID         uint         `json:"id"`
+	Status     string       `json:"status"`
+	Processed  int          `json:"processed"`
+	Total      int          `json:"total"`
+	Report     ImportReport `json:"report"`
+	Error      string       `json:"error,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+	FinishedAt *time.Time   `json:"finished_at,omitempty"`
+}
+
+func (j *ImportJob) ConvertToResponse() ImportJobResponse {
+	return ImportJobResponse{
+		ID:         j.ID,
+		Status:     j.Status,
+		Processed:  j.Processed,
+		Total:      j.Report.Total,
+		Report:     j.Report,
+		Error:      j.Error,
+		CreatedAt:  j.CreatedAt,
+		FinishedAt: j.FinishedAt,
+	}
+}
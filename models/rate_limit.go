@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// RateLimitBucket is a token bucket shared by every instance of the application.
+type RateLimitBucket struct {
+	Key       string `gorm:"primaryKey;type:varchar(191)"`
+	Tokens    float64
+	UpdatedAt time.Time
+	// FullAt is when the bucket is full again and can be deleted.
+	FullAt time.Time `gorm:"index"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Session is a server-side login of a browser client. ID is the SHA-256 of
+// the token held in the session cookie, so the stored sessions cannot be
+// used to log in. TokenVersion is the user's token version at login; the
+// session is revoked along with the tokens when it changes.
+type Session struct {
+	ID           string `gorm:"primaryKey;type:varchar(64)"`
+	UserID       uint   `gorm:"index"`
+	TokenVersion int
+	IP           string `gorm:"type:varchar(45)"`
+	UserAgent    string `gorm:"type:varchar(255)"`
+	CreatedAt    time.Time
+	LastSeenAt   time.Time
+	ExpiresAt    time.Time `gorm:"index"`
+}
+
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+func (s *Session) ConvertToResponse() SessionResponse {
+	return SessionResponse{
+		ID:         s.ID,
+		IP:         s.IP,
+		UserAgent:  s.UserAgent,
+		CreatedAt:  s.CreatedAt,
+		LastSeenAt: s.LastSeenAt,
+		ExpiresAt:  s.ExpiresAt,
+	}
+}
@@ -1,18 +1,43 @@
 package models
 
-import "github.com/go-playground/validator/v10"
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
 
 type User struct {
-	ID       uint   `gorm:"primaryKey"`
-	Name     string `gorm:"type:varchar(100)"`
-	Email    string `gorm:"type:varchar(100)"`
-	Password string `gorm:"type:varchar(100)"`
+	ID           uint   `gorm:"primaryKey"`
+	Name         string `gorm:"type:varchar(100)"`
+	Email        string `gorm:"type:varchar(100)"`
+	Password     string `gorm:"type:varchar(100)"`
+	Role         string `gorm:"type:varchar(20);default:user"`
+	PendingEmail string `gorm:"type:varchar(100)"`
+	// EmailToken is the hash of the token mailed to verify PendingEmail,
+	// which expires at EmailTokenExpiresAt.
+	EmailToken          string `gorm:"type:varchar(100);index"`
+	EmailTokenExpiresAt *time.Time
+	TokenVersion        int
+	// Deactivated users can neither log in nor use previously issued tokens.
+	Deactivated bool
+	// MustResetPassword is set for temporary passwords and forced resets; the
+	// user can only change their password until it is cleared.
+	MustResetPassword bool
 }
 
 type UserResponse struct {
-	ID    uint   `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	ID                uint   `json:"id"`
+	Name              string `json:"name"`
+	Email             string `json:"email"`
+	Role              string `json:"role"`
+	PendingEmail      string `json:"pending_email,omitempty"`
+	Deactivated       bool   `json:"deactivated"`
+	MustResetPassword bool   `json:"must_reset_password"`
 }
 
 type UserRequest struct {
@@ -21,6 +46,38 @@ type UserRequest struct {
 	Password string `json:"password" form:"password"`
 }
 
+type CreateUserRequest struct {
+	Name  string `json:"name" form:"name" validate:"required"`
+	Email string `json:"email" form:"email" validate:"required,email"`
+	Role  string `json:"role" form:"role" validate:"omitempty,oneof=user admin"`
+}
+
+type ProfileRequest struct {
+	Name string `json:"name" form:"name" validate:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" form:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" form:"new_password" validate:"required,min=6"`
+}
+
+type SetPasswordRequest struct {
+	Password string `json:"password" form:"password" validate:"required,min=6"`
+}
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email" form:"email" validate:"required,email"`
+	Password string `json:"password" form:"password" validate:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" form:"token" validate:"required"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" form:"password" validate:"required"`
+}
+
 func (u *UserRequest) Validate() error {
 	validate := validator.New()
 
@@ -29,11 +86,43 @@ func (u *UserRequest) Validate() error {
 	return err
 }
 
+func (u *CreateUserRequest) Validate() error {
+	return validator.New().Struct(u)
+}
+
+func (p *ProfileRequest) Validate() error {
+	return validator.New().Struct(p)
+}
+
+func (p *ChangePasswordRequest) Validate() error {
+	return validator.New().Struct(p)
+}
+
+func (p *SetPasswordRequest) Validate() error {
+	return validator.New().Struct(p)
+}
+
+func (e *ChangeEmailRequest) Validate() error {
+	return validator.New().Struct(e)
+}
+
+func (v *VerifyEmailRequest) Validate() error {
+	return validator.New().Struct(v)
+}
+
+func (d *DeleteAccountRequest) Validate() error {
+	return validator.New().Struct(d)
+}
+
 func (u *User) ConvertToResponse() UserResponse {
 	return UserResponse{
-		ID:    u.ID,
-		Name:  u.Name,
-		Email: u.Email,
+		ID:                u.ID,
+		Name:              u.Name,
+		Email:             u.Email,
+		Role:              u.Role,
+		PendingEmail:      u.PendingEmail,
+		Deactivated:       u.Deactivated,
+		MustResetPassword: u.MustResetPassword,
 	}
 }
 
@@ -42,5 +131,21 @@ func (u *UserRequest) ConvertToUser() User {
 		Name:     u.Name,
 		Email:    u.Email,
 		Password: u.Password,
+		Role:     RoleUser,
+	}
+}
+
+func (u *CreateUserRequest) ConvertToUser() User {
+	role := u.Role
+
+	if role == "" {
+		role = RoleUser
+	}
+
+	return User{
+		Name:              u.Name,
+		Email:             u.Email,
+		Role:              role,
+		MustResetPassword: true,
 	}
 }
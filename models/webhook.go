@@ -0,0 +1,135 @@
+package models
+
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// WebhookAllEvents subscribes a webhook to every event.
+const WebhookAllEvents = "*"
+
+// Webhook is an endpoint that receives the events of Events, signed with
+// Secret.
+type Webhook struct {
+	ID        uint     `gorm:"primaryKey"`
+	URL       string   `gorm:"type:varchar(2048)"`
+	Events    []string `gorm:"serializer:json;type:text"`
+	Secret    string   `gorm:"type:varchar(255)"`
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Subscribed reports whether the webhook receives events of eventType.
+func (w *Webhook) Subscribed(eventType string) bool {
+	for _, e := range w.Events {
+		if e == WebhookAllEvents || e == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+type WebhookRequest struct {
+	URL    string   `json:"url" validate:"required,url,max=2048"`
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=* product.created product.updated product.deleted user.registered stock.low"`
+	Secret string   `json:"secret" validate:"required,min=16,max=255"`
+	// Active defaults to true.
+	Active *bool `json:"active"`
+}
+
+func (w *WebhookRequest) Validate() error {
+	return validator.New().Struct(w)
+}
+
+// ApplyTo copies the request onto webhook.
+func (w *WebhookRequest) ApplyTo(webhook *Webhook) {
+	webhook.URL = w.URL
+	webhook.Events = w.Events
+	webhook.Secret = w.Secret
+	webhook.Active = w.Active == nil || *w.Active
+}
+
+// WebhookResponse leaves out the secret, which is only ever sent by admins.
+type WebhookResponse struct {
+	ID        uint      `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (w *Webhook) ConvertToResponse() WebhookResponse {
+	return WebhookResponse{
+		ID:        w.ID,
+		URL:       w.URL,
+		Events:    w.Events,
+		Active:    w.Active,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+// WebhookMessage is an event waiting to be delivered to one webhook. The
+// outbox relay adds one for every subscribed webhook in the transaction that
+// marks the event published, and the dispatcher deletes it once delivered or
+// out of attempts. Payload is the event as JSON.
+type WebhookMessage struct {
+	ID            uint   `gorm:"primaryKey"`
+	WebhookID     uint   `gorm:"uniqueIndex:idx_webhook_messages_event"`
+	EventID       string `gorm:"type:varchar(64);uniqueIndex:idx_webhook_messages_event"`
+	Type          string `gorm:"type:varchar(50)"`
+	Aggregate     string `gorm:"type:varchar(50)"`
+	AggregateID   uint
+	Payload       string `gorm:"type:mediumtext"`
+	Attempts      int
+	NextAttemptAt time.Time `gorm:"index"`
+	CreatedAt     time.Time
+}
+
+// WebhookDelivery logs one attempt to deliver an event to a webhook.
+// StatusCode is zero when no response was received, Error says why.
+type WebhookDelivery struct {
+	ID           uint   `gorm:"primaryKey"`
+	WebhookID    uint   `gorm:"index"`
+	EventID      string `gorm:"type:varchar(64);index"`
+	EventType    string `gorm:"type:varchar(50)"`
+	Attempt      int
+	Succeeded    bool
+	StatusCode   int
+	ResponseBody string `gorm:"type:text"`
+	Error        string `gorm:"type:text"`
+	Duration     time.Duration
+	CreatedAt    time.Time `gorm:"index"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID           uint      `json:"id"`
+	EventID      string    `json:"event_id"`
+	EventType    string    `json:"event_type"`
+	Attempt      int       `json:"attempt"`
+	Succeeded    bool      `json:"succeeded"`
+	StatusCode   int       `json:"status_code"`
+	ResponseBody string    `json:"response_body"`
+	Error        string    `json:"error,omitempty"`
+	DurationMS   int64     `json:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (d *WebhookDelivery) ConvertToResponse() WebhookDeliveryResponse {
+	return WebhookDeliveryResponse{
+		ID:           d.ID,
+		EventID:      d.EventID,
+		EventType:    d.EventType,
+		Attempt:      d.Attempt,
+		Succeeded:    d.Succeeded,
+		StatusCode:   d.StatusCode,
+		ResponseBody: d.ResponseBody,
+		Error:        d.Error,
+		DurationMS:   d.Duration.Milliseconds(),
+		CreatedAt:    d.CreatedAt,
+	}
+}
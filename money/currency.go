@@ -0,0 +1,48 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+var validate = validator.New()
+
+// digits lists the ISO 4217 currencies whose minor unit is not the
+// hundredth. Codes without a minor unit, such as XAU, count as 0.
+var digits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0,
+	"XPF": 0, "XAG": 0, "XAU": 0, "XBA": 0, "XBB": 0, "XBC": 0, "XBD": 0, "XDR": 0,
+	"XPD": 0, "XPT": 0, "XSU": 0, "XTS": 0, "XUA": 0, "XXX": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// Currency is an ISO 4217 currency. Amounts in it are counted in minor
+// units, 10^Digits of which make a unit.
+type Currency struct {
+	Code   string
+	Digits int
+}
+
+// Lookup returns the ISO 4217 currency of code, in any case.
+func Lookup(code string) (Currency, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	if validate.Var(code, "iso4217") != nil {
+		return Currency{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, code)
+	}
+
+	currency := Currency{Code: code, Digits: 2}
+
+	if n, ok := digits[code]; ok {
+		currency.Digits = n
+	}
+
+	return currency, nil
+}
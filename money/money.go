@@ -0,0 +1,103 @@
+package money
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidRate = errors.New("exchange rate must be a positive decimal number")
+
+// Money is an amount in minor units of a currency: 1234 USD is $12.34.
+type Money struct {
+	Amount   int64
+	Currency Currency
+}
+
+func New(amount int64, currency Currency) Money {
+	return Money{
+		Amount:   amount,
+		Currency: currency,
+	}
+}
+
+// String formats m with its currency code and a thousands separator, e.g.
+// "USD 1,234.56" or "JPY -1,235".
+func (m Money) String() string {
+	amount := m.Amount
+	sign := ""
+
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.FormatInt(amount, 10)
+
+	if pad := m.Currency.Digits + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+
+	units := digits[:len(digits)-m.Currency.Digits]
+	minor := digits[len(units):]
+
+	var grouped strings.Builder
+
+	for i, digit := range units {
+		if i > 0 && (len(units)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+
+		grouped.WriteRune(digit)
+	}
+
+	if minor != "" {
+		grouped.WriteString("." + minor)
+	}
+
+	return m.Currency.Code + " " + sign + grouped.String()
+}
+
+// ParseRate parses an exchange rate written as a decimal number, e.g. "0.92".
+func ParseRate(s string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(s)
+
+	if !ok || rate.Sign() <= 0 {
+		return nil, ErrInvalidRate
+	}
+
+	return rate, nil
+}
+
+// FormatRate writes rate as a decimal number of at most 10 decimals, without
+// trailing zeros.
+func FormatRate(rate *big.Rat) string {
+	s := rate.FloatString(10)
+	s = strings.TrimRight(s, "0")
+
+	return strings.TrimSuffix(s, ".")
+}
+
+// Convert converts m to currency at rate, the units of currency one unit of
+// m.Currency is worth, and rounds the result with rounding.
+func Convert(m Money, currency Currency, rate *big.Rat, rounding Rounding) Money {
+	exact := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), rate)
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(currency.Digits-m.Currency.Digits))), nil))
+
+	if currency.Digits >= m.Currency.Digits {
+		exact.Mul(exact, scale)
+	} else {
+		exact.Quo(exact, scale)
+	}
+
+	return New(rounding.Round(exact), currency)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
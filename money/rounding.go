@@ -0,0 +1,83 @@
+package money
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	HalfUp   = "half-up"
+	HalfEven = "half-even"
+	Down     = "down"
+	Up       = "up"
+)
+
+// Rounding is how converted amounts of a currency are rounded. The zero
+// value rounds half up to the minor unit.
+type Rounding struct {
+	// Mode is half-up, away from zero on ties, half-even, down, toward
+	// zero, or up, away from zero.
+	Mode string `mapstructure:"mode" validate:"omitempty,oneof=half-up half-even down up"`
+	// Increment is the step amounts are rounded to, in minor units: 5
+	// rounds CHF to 0.05 and 100 rounds JPY to the hundred yen.
+	Increment int64 `mapstructure:"increment" validate:"min=0"`
+}
+
+// Round rounds amount, in minor units, to a multiple of the increment.
+func (r Rounding) Round(amount *big.Rat) int64 {
+	increment := max(r.Increment, 1)
+
+	steps := new(big.Rat).Quo(amount, new(big.Rat).SetInt64(increment))
+	quotient, remainder := new(big.Int).QuoRem(steps.Num(), steps.Denom(), new(big.Int))
+
+	if remainder.Sign() != 0 {
+		// Compares the remainder to half a step.
+		half := new(big.Int).Abs(remainder)
+		half.Lsh(half, 1)
+		tie := half.Cmp(steps.Denom())
+
+		away := false
+
+		switch r.Mode {
+		case Down:
+		case Up:
+			away = true
+		case HalfEven:
+			away = tie > 0 || tie == 0 && quotient.Bit(0) == 1
+		default:
+			away = tie >= 0
+		}
+
+		if away {
+			quotient.Add(quotient, big.NewInt(int64(remainder.Sign())))
+		}
+	}
+
+	return quotient.Int64() * increment
+}
+
+// Rules are the rounding rules of the currencies, keyed by currency code.
+type Rules map[string]Rounding
+
+// NewRules checks the currencies of rounding, keyed by code in any case.
+func NewRules(rounding map[string]Rounding) (Rules, error) {
+	rules := Rules{}
+
+	for code, rule := range rounding {
+		currency, err := Lookup(code)
+
+		if err != nil {
+			return nil, fmt.Errorf("rounding of %s: %w", strings.ToUpper(code), err)
+		}
+
+		rules[currency.Code] = rule
+	}
+
+	return rules, nil
+}
+
+// For returns the rounding of currency.
+func (r Rules) For(currency Currency) Rounding {
+	return r[currency.Code]
+}
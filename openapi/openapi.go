@@ -0,0 +1,285 @@
+// Package openapi builds an OpenAPI 3.1 document from the routes of a fiber
+// app and a description of each route.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Operation documents a route. Request is the body and Response the "data"
+// of the response envelope; both are example values of the models, e.g.
+// models.ProductRequest{}. Page wraps Response in a page of results.
+type Operation struct {
+	Summary  string
+	Tag      string
+	Auth     bool
+	Query    []Parameter
+	Request  any
+	Response any
+	Page     bool
+	// Deprecated marks routes of deprecated API versions.
+	Deprecated bool
+}
+
+type Parameter struct {
+	Name        string `json:"name"`
+	In          string `json:"in"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+	Schema      Schema `json:"schema"`
+}
+
+// QueryParam describes an optional query parameter of type typ.
+func QueryParam(name, typ, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: Schema{Type: typ}}
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Paths      map[string]map[string]operation `json:"paths"`
+	Components components                      `json:"components"`
+}
+
+type components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]securityScheme `json:"securitySchemes"`
+}
+
+type securityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+}
+
+type operation struct {
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	OperationID string                `json:"operationId"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *body                 `json:"requestBody,omitempty"`
+	Responses   map[string]body       `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+}
+
+type body struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]mediaType `json:"content"`
+}
+
+type mediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Key is the key of a route in the operations map, e.g. "GET /products/:id".
+func Key(method, path string) string {
+	return method + " " + path
+}
+
+// Routes returns the keys of the routes of app, leaving out the HEAD
+// routes fiber adds for every GET route.
+func Routes(app *fiber.App) []string {
+	var keys []string
+	seen := map[string]bool{}
+
+	for _, route := range app.GetRoutes(true) {
+		key := Key(route.Method, route.Path)
+
+		if route.Method == fiber.MethodHead || seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Undocumented returns the routes of app missing from operations.
+func Undocumented(app *fiber.App, operations map[string]Operation) []string {
+	var missing []string
+
+	for _, key := range Routes(app) {
+		if _, ok := operations[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+
+	return missing
+}
+
+// Generate documents the routes of app. Undocumented routes are left out.
+// sessionCookie names the cookie accepted in place of the bearer token.
+func Generate(app *fiber.App, info Info, operations map[string]Operation, sessionCookie string) Document {
+	doc := Document{
+		OpenAPI: "3.1.0",
+		Info:    info,
+		Paths:   map[string]map[string]operation{},
+		Components: components{
+			Schemas: map[string]*Schema{},
+			SecuritySchemes: map[string]securityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				"cookieAuth": {Type: "apiKey", In: "cookie", Name: sessionCookie},
+			},
+		},
+	}
+
+	schemas := schemaBuilder{schemas: doc.Components.Schemas}
+	message := &Schema{Type: "object", Properties: map[string]*Schema{"message": {Type: "string"}}, Required: []string{"message"}}
+	doc.Components.Schemas["Message"] = message
+
+	for _, key := range Routes(app) {
+		op, ok := operations[key]
+
+		if !ok {
+			continue
+		}
+
+		method, path, _ := strings.Cut(key, " ")
+		path, params := convertPath(path)
+
+		result := operation{
+			Summary:     op.Summary,
+			OperationID: operationID(method, path),
+			Parameters:  append(params, op.Query...),
+			Deprecated:  op.Deprecated,
+			Responses: map[string]body{
+				"200": {
+					Description: "OK",
+					Content:     jsonContent(envelope(schemas, op)),
+				},
+				"default": {
+					Description: "Error",
+					Content:     jsonContent(&Schema{Ref: "#/components/schemas/Message"}),
+				},
+			},
+		}
+
+		if op.Tag != "" {
+			result.Tags = []string{op.Tag}
+		}
+
+		if op.Request != nil {
+			result.RequestBody = &body{
+				Required: true,
+				Content:  jsonContent(schemas.schema(reflect.TypeOf(op.Request))),
+			}
+		}
+
+		if op.Auth {
+			result.Security = []map[string][]string{{"bearerAuth": {}}, {"cookieAuth": {}}}
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]operation{}
+		}
+
+		doc.Paths[path][strings.ToLower(method)] = result
+	}
+
+	return doc
+}
+
+// envelope is the schema of the JSON written by the handlers' response helper.
+func envelope(schemas schemaBuilder, op Operation) *Schema {
+	if op.Response == nil {
+		return &Schema{Ref: "#/components/schemas/Message"}
+	}
+
+	data := schemas.schema(reflect.TypeOf(op.Response))
+
+	if op.Page {
+		data = &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"items": {Type: "array", Items: data},
+				"page":  {Type: "integer"},
+				"limit": {Type: "integer"},
+				"total": {Type: "integer"},
+			},
+			Required: []string{"items", "page", "limit", "total"},
+		}
+	}
+
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"message": {Type: "string"},
+			"data":    data,
+		},
+		Required: []string{"message"},
+	}
+}
+
+func jsonContent(schema *Schema) map[string]mediaType {
+	return map[string]mediaType{fiber.MIMEApplicationJSON: {Schema: schema}}
+}
+
+// convertPath turns the fiber parameters of path into OpenAPI ones.
+func convertPath(path string) (string, []Parameter) {
+	var params []Parameter
+
+	segments := strings.Split(path, "/")
+
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+
+		name := strings.TrimSuffix(strings.TrimPrefix(segment, ":"), "?")
+		segments[i] = "{" + name + "}"
+		params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: Schema{Type: "string"}})
+	}
+
+	return strings.Join(segments, "/"), params
+}
+
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+
+	for _, segment := range strings.Split(path, "/") {
+		segment = strings.Trim(segment, "{}")
+
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+			id += strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+
+	return id
+}
+
+// Handler serves the document as JSON. It is generated on the first request,
+// once every route has been registered.
+func Handler(app *fiber.App, info Info, operations map[string]Operation, sessionCookie string) fiber.Handler {
+	var (
+		once sync.Once
+		doc  Document
+	)
+
+	return func(c *fiber.Ctx) error {
+		once.Do(func() {
+			doc = Generate(app, info, operations, sessionCookie)
+		})
+
+		return c.Status(http.StatusOK).JSON(doc)
+	}
+}
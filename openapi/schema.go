@@ -0,0 +1,227 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is the subset of JSON Schema used by the document.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaBuilder adds the named structs it meets to schemas and refers to them.
+type schemaBuilder struct {
+	schemas map[string]*Schema
+}
+
+func (sb schemaBuilder) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: sb.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: sb.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return sb.object(t)
+		}
+
+		if _, ok := sb.schemas[t.Name()]; !ok {
+			// Reserve the name first so recursive types terminate.
+			sb.schemas[t.Name()] = &Schema{}
+			*sb.schemas[t.Name()] = *sb.object(t)
+		}
+
+		return &Schema{Ref: "#/components/schemas/" + t.Name()}
+	default:
+		return &Schema{}
+	}
+}
+
+func (sb schemaBuilder) object(t reflect.Type) *Schema {
+	object := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			embedded := sb.object(field.Type)
+
+			for key, property := range embedded.Properties {
+				object.Properties[key] = property
+			}
+
+			object.Required = append(object.Required, embedded.Required...)
+
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		property := sb.schema(field.Type)
+
+		if constrain(property, field.Tag.Get("validate")) {
+			object.Required = append(object.Required, name)
+		}
+
+		object.Properties[name] = property
+	}
+
+	return object
+}
+
+// constrain adds the validator rules of tag to schema and reports whether
+// the field is required. Rules without a JSON Schema equivalent are ignored.
+func constrain(schema *Schema, tag string) bool {
+	if tag == "" || schema.Ref != "" {
+		return strings.Contains(","+tag+",", ",required,")
+	}
+
+	required := false
+
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+
+		switch name {
+		case "dive":
+			// The following rules apply to the elements.
+			return required
+		case "required":
+			required = true
+		case "email":
+			schema.Format = "email"
+		case "url", "uri":
+			schema.Format = "uri"
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				schema.Enum = append(schema.Enum, enumValue(schema, value))
+			}
+		case "len":
+			bound(schema, param, true, true, false)
+		case "min":
+			bound(schema, param, true, false, false)
+		case "max":
+			bound(schema, param, false, true, false)
+		case "gte":
+			bound(schema, param, true, false, false)
+		case "lte":
+			bound(schema, param, false, true, false)
+		case "gt":
+			bound(schema, param, true, false, true)
+		case "lt":
+			bound(schema, param, false, true, true)
+		}
+	}
+
+	return required
+}
+
+// bound sets a lower and/or upper bound: a length for strings, a number of
+// items for arrays and a value for numbers.
+func bound(schema *Schema, param string, lower, upper, exclusive bool) {
+	value, err := strconv.ParseFloat(param, 64)
+
+	if err != nil {
+		return
+	}
+
+	switch schema.Type {
+	case "string", "array":
+		n := int(value)
+
+		if exclusive {
+			if lower {
+				n++
+			} else {
+				n--
+			}
+		}
+
+		minimum, maximum := &schema.MinLength, &schema.MaxLength
+
+		if schema.Type == "array" {
+			minimum, maximum = &schema.MinItems, &schema.MaxItems
+		}
+
+		if lower {
+			*minimum = &n
+		}
+
+		if upper {
+			*maximum = &n
+		}
+	case "integer", "number":
+		switch {
+		case lower && exclusive:
+			schema.ExclusiveMinimum = &value
+		case upper && exclusive:
+			schema.ExclusiveMaximum = &value
+		}
+
+		if !exclusive && lower {
+			schema.Minimum = &value
+		}
+
+		if !exclusive && upper {
+			schema.Maximum = &value
+		}
+	}
+}
+
+func enumValue(schema *Schema, value string) any {
+	if schema.Type == "integer" || schema.Type == "number" {
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	}
+
+	return value
+}
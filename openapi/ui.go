@@ -0,0 +1,40 @@
+package openapi
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// uiCSP lets the docs page load Swagger UI from its CDN. It replaces the
+// stricter policy sent with the API responses.
+const uiCSP = "default-src 'none'; script-src 'unsafe-inline' https://unpkg.com; style-src https://unpkg.com; img-src data: https://unpkg.com; connect-src 'self'; frame-ancestors 'none'"
+
+const uiPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>%[1]s</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>
+window.ui = SwaggerUIBundle({url: %[2]q, dom_id: "#swagger-ui", withCredentials: true});
+</script>
+</body>
+</html>
+`
+
+// UI serves a Swagger UI page rendering the document at specURL.
+func UI(title, specURL string) fiber.Handler {
+	page := fmt.Sprintf(uiPage, title, specURL)
+
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentSecurityPolicy, uiCSP)
+		c.Type("html", "utf-8")
+
+		return c.SendString(page)
+	}
+}
@@ -0,0 +1,136 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"product/config"
+	"product/events"
+	"product/models"
+	"product/services"
+	"product/utils"
+	"strconv"
+	"time"
+)
+
+// Relay publishes the messages of the outbox to a sink, at least once and
+// in order for each aggregate: a message is only published once the earlier
+// messages of its aggregate have been. A published message is queued for the
+// webhooks subscribed to it, whatever the sink. Failures are retried with exponential
+// backoff; a message out of attempts is marked failed and holds back its
+// aggregate until an admin retries or discards it. Only the instance holding
+// the relay lock publishes.
+type Relay struct {
+	outbox services.OutboxService
+	sink   Sink
+	cfg    config.OutboxConfig
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+func NewRelay(outbox services.OutboxService, sink Sink, cfg config.OutboxConfig, logger *slog.Logger) *Relay {
+	return &Relay{
+		outbox: outbox,
+		sink:   sink,
+		cfg:    cfg,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Run polls the outbox every PollInterval until ctx is done.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	lastCleanup := r.now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Poll(ctx); err != nil {
+				r.logger.ErrorContext(ctx, "outbox relay failed", "error", err)
+			}
+
+			if r.now().Sub(lastCleanup) >= utils.CleanupInterval {
+				lastCleanup = r.now()
+
+				if err := r.outbox.DeletePublished(ctx, lastCleanup.Add(-r.cfg.Retention)); err != nil {
+					r.logger.ErrorContext(ctx, "failed to delete published outbox messages", "error", err)
+				}
+			}
+		}
+	}
+}
+
+// Poll publishes the pending messages due, up to BatchSize of them.
+func (r *Relay) Poll(ctx context.Context) error {
+	_, err := r.outbox.WithRelayLock(ctx, func(ctx context.Context) error {
+		messages, err := r.outbox.GetPending(ctx, r.cfg.BatchSize)
+
+		if err != nil {
+			return err
+		}
+
+		// held are the aggregates with an earlier message still pending.
+		held := map[string]bool{}
+
+		for _, message := range messages {
+			aggregate := message.Aggregate + ":" + strconv.FormatUint(uint64(message.AggregateID), 10)
+
+			if held[aggregate] || message.NextAttemptAt.After(r.now()) {
+				held[aggregate] = true
+				continue
+			}
+
+			if err := r.publish(ctx, message); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+
+				held[aggregate] = true
+			}
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+// publish sends message to the sink and records the outcome.
+func (r *Relay) publish(ctx context.Context, message models.OutboxMessage) error {
+	event := events.Event{}
+
+	err := json.Unmarshal([]byte(message.Payload), &event)
+
+	if err == nil {
+		err = r.sink.Publish(ctx, event)
+	}
+
+	if err == nil {
+		publishedAt := r.now()
+		message.Status = models.OutboxPublished
+		message.PublishedAt = &publishedAt
+		message.LastError = ""
+
+		return r.outbox.MarkPublished(ctx, message)
+	}
+
+	message.Attempts++
+	message.LastError = err.Error()
+	message.NextAttemptAt = r.now().Add(utils.Backoff(r.cfg.InitialBackoff, r.cfg.MaxBackoff, message.Attempts))
+
+	if message.Attempts >= r.cfg.MaxAttempts {
+		message.Status = models.OutboxFailed
+		r.logger.ErrorContext(ctx, "outbox message failed, holding back its aggregate", "error", err, "message_id", message.ID, "event_id", message.EventID)
+	}
+
+	if updateErr := r.outbox.Update(ctx, message); updateErr != nil {
+		return updateErr
+	}
+
+	return err
+}
@@ -0,0 +1,161 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"product/config"
+	"product/events"
+	"product/webhook"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	SinkBus  = "bus"
+	SinkHTTP = "http"
+	SinkFile = "file"
+)
+
+// Sink receives the relayed events. An error leaves the event in the outbox
+// to be relayed again, so a sink may see an event more than once and should
+// use its ID to tell.
+type Sink interface {
+	Publish(ctx context.Context, event events.Event) error
+}
+
+// NewSink returns the sink named by cfg.Sink.
+func NewSink(cfg config.OutboxConfig, bus *events.Bus) Sink {
+	switch cfg.Sink {
+	case SinkHTTP:
+		return NewHTTPSink(cfg.URL, cfg.Secret, cfg.Timeout)
+	case SinkFile:
+		return NewFileSink(cfg.File)
+	default:
+		return NewBusSink(bus)
+	}
+}
+
+// BusSink hands the events to the subscribers of the in-process bus. The
+// subscribers cannot fail an event, so it counts as published once they
+// return; durable consumers such as the webhooks are fed by the relay
+// instead.
+type BusSink struct {
+	bus *events.Bus
+}
+
+func NewBusSink(bus *events.Bus) *BusSink {
+	return &BusSink{
+		bus: bus,
+	}
+}
+
+func (bs *BusSink) Publish(ctx context.Context, event events.Event) error {
+	bs.bus.Publish(ctx, event)
+
+	return nil
+}
+
+// HTTPSink posts each event as JSON to a URL, signed like the webhook
+// deliveries when a secret is set. Any status but 2xx is a failure.
+type HTTPSink struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func NewHTTPSink(url string, secret string, timeout time.Duration) *HTTPSink {
+	return &HTTPSink{
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (hs *HTTPSink) Publish(ctx context.Context, event events.Event) error {
+	body, err := json.Marshal(event)
+
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hs.url, bytes.NewReader(body))
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.HeaderEvent, event.Type)
+	req.Header.Set(webhook.HeaderDelivery, event.ID)
+
+	if hs.secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+		req.Header.Set(webhook.HeaderTimestamp, timestamp)
+		req.Header.Set(webhook.HeaderSignature, webhook.Sign(hs.secret, timestamp, body))
+	}
+
+	resp, err := hs.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// FileSink appends each event as a line of JSON to a file, synced to disk
+// before the event counts as published.
+type FileSink struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileSink(path string) *FileSink {
+	return &FileSink{
+		path: path,
+	}
+}
+
+func (fs *FileSink) Publish(ctx context.Context, event events.Event) error {
+	line, err := json.Marshal(event)
+
+	if err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	file, err := os.OpenFile(fs.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,52 @@
+package pricing
+
+import (
+	"context"
+	"log/slog"
+	"product/config"
+	"product/services"
+	"time"
+)
+
+// Scheduler applies the scheduled price changes once they are due. It checks
+// every interval, so a change lands at most one interval late.
+type Scheduler struct {
+	prices services.PriceService
+	cfg    config.PricingConfig
+	logger *slog.Logger
+}
+
+func NewScheduler(priceService services.PriceService, cfg config.PricingConfig, logger *slog.Logger) *Scheduler {
+	return &Scheduler{
+		prices: priceService,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// Run applies the due changes at once, catching up on the ones missed while
+// the application was down, then every interval until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	now := time.Now()
+
+	for {
+		applied, err := s.prices.ApplyDue(ctx, now)
+
+		if err != nil {
+			s.logger.ErrorContext(ctx, "failed to apply scheduled prices", "error", err)
+		}
+
+		if applied > 0 {
+			s.logger.InfoContext(ctx, "scheduled prices applied", "count", applied)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case now = <-ticker.C:
+		}
+	}
+}
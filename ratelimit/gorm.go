@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"context"
+	"product/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormStore keeps the buckets in the database so every instance of the
+// application shares them. Each Take locks the row of its bucket, which it
+// inserts first when the bucket is new.
+type GormStore struct {
+	db *gorm.DB
+}
+
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{
+		db: db,
+	}
+}
+
+func (gs *GormStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	var result Result
+
+	err := gs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		// A new bucket is inserted full first, so concurrent first requests
+		// all find a row to lock instead of racing to insert it.
+		b := models.RateLimitBucket{Key: key, Tokens: policy.capacity(), UpdatedAt: now, FullAt: now}
+
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&b).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&b, "`key` = ?", key).Error; err != nil {
+			return err
+		}
+
+		b.Tokens, result = take(policy, b.Tokens, b.UpdatedAt, now)
+		b.UpdatedAt = now
+		b.FullAt = now.Add(result.Reset)
+
+		return tx.Save(&b).Error
+	})
+
+	return result, err
+}
+
+func (gs *GormStore) DeleteExpired(ctx context.Context, now time.Time) error {
+	return gs.db.WithContext(ctx).Where("full_at <= ?", now).Delete(&models.RateLimitBucket{}).Error
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepEvery is the number of Take calls between removals of idle buckets.
+const sweepEvery = 1000
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	full      time.Time
+}
+
+// MemoryStore keeps the buckets in process. It is only accurate when a single
+// instance serves the traffic.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+	now     func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: map[string]*bucket{},
+		now:     time.Now,
+	}
+}
+
+func (ms *MemoryStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := ms.now()
+
+	ms.calls++
+
+	if ms.calls%sweepEvery == 0 {
+		ms.sweep(now)
+	}
+
+	b, ok := ms.buckets[key]
+
+	if !ok {
+		b = &bucket{tokens: policy.capacity(), updatedAt: now}
+		ms.buckets[key] = b
+	}
+
+	tokens, result := take(policy, b.tokens, b.updatedAt, now)
+
+	b.tokens = tokens
+	b.updatedAt = now
+	b.full = now.Add(result.Reset)
+
+	return result, nil
+}
+
+func (ms *MemoryStore) DeleteExpired(ctx context.Context, now time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.sweep(now)
+
+	return nil
+}
+
+// sweep drops the buckets that are full again, they behave like missing ones.
+func (ms *MemoryStore) sweep(now time.Time) {
+	for key, b := range ms.buckets {
+		if !now.Before(b.full) {
+			delete(ms.buckets, key)
+		}
+	}
+}
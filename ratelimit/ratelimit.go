@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"product/utils"
+	"time"
+)
+
+const (
+	KeyIP     = "ip"
+	KeyUser   = "user"
+	KeyAPIKey = "api_key"
+)
+
+// Policy is a token bucket refilled with Limit tokens every Period and
+// holding at most Burst tokens. Key selects what requests are counted by: the
+// client IP, the authenticated user or the API key.
+type Policy struct {
+	Limit  int           `mapstructure:"limit" validate:"min=0"`
+	Period time.Duration `mapstructure:"period" validate:"required_with=Limit"`
+	Burst  int           `mapstructure:"burst" validate:"min=0"`
+	Key    string        `mapstructure:"key" validate:"omitempty,oneof=ip user api_key"`
+}
+
+// Enabled reports whether the policy limits anything.
+func (p Policy) Enabled() bool {
+	return p.Limit > 0 && p.Period > 0
+}
+
+func (p Policy) capacity() float64 {
+	if p.Burst > 0 {
+		return float64(p.Burst)
+	}
+
+	return float64(p.Limit)
+}
+
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+type Result struct {
+	Allowed bool
+	// Limit is the size of the bucket.
+	Limit int
+	// Remaining is the number of requests that can still be made right now.
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed. It is zero
+	// when Allowed is true.
+	RetryAfter time.Duration
+}
+
+// Store keeps the buckets. Take removes a token from the bucket of key,
+// creating a full bucket when there is none. DeleteExpired drops the buckets
+// that are full again at now, they behave like missing ones.
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+// Run deletes full buckets from store every hour until ctx is done.
+func Run(ctx context.Context, store Store) {
+	utils.RunCleanup(ctx, "full rate limit buckets", store.DeleteExpired)
+}
+
+// take refills a bucket that had tokens left at updatedAt and tries to remove
+// one token at now. It returns the tokens left and the outcome.
+func take(policy Policy, tokens float64, updatedAt time.Time, now time.Time) (float64, Result) {
+	capacity := policy.capacity()
+	rate := policy.rate()
+
+	tokens = math.Min(capacity, tokens+now.Sub(updatedAt).Seconds()*rate)
+
+	result := Result{
+		Limit: int(capacity),
+	}
+
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - tokens) / rate)
+	}
+
+	result.Remaining = int(math.Floor(tokens))
+	result.Reset = seconds((capacity - tokens) / rate)
+
+	return tokens, result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,182 @@
+package router
+
+import (
+	"product/config"
+	"product/models"
+	"product/openapi"
+	"strings"
+)
+
+var info = openapi.Info{
+	Title:       "User Product Management API",
+	Version:     "1.0.0",
+	Description: "Responses are wrapped in an envelope holding a message and, on success, the data.",
+}
+
+// loginRequest documents the body read by the login handlers.
+type loginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type tokenResponse struct {
+	Token string `json:"token"`
+}
+
+var pageParams = []openapi.Parameter{
+	openapi.QueryParam("page", "integer", "page number, starting at 1"),
+	openapi.QueryParam("limit", "integer", "page size, at most 100"),
+}
+
+// currencyParams select the currency of the prices of the listings.
+var currencyParams = []openapi.Parameter{
+	openapi.QueryParam("currency", "string", "ISO 4217 currency of price_money, the base currency by default"),
+	{Name: "Accept-Currency", In: "header", Description: "currency used when the currency parameter is missing", Schema: openapi.Schema{Type: "string"}},
+}
+
+// operationalRoutes documents the unversioned routes.
+var operationalRoutes = map[string]openapi.Operation{
+	"GET /metrics":      {Summary: "Prometheus metrics", Tag: "operations"},
+	"GET /healthz":      {Summary: "Liveness probe", Tag: "operations"},
+	"GET /readyz":       {Summary: "Readiness probe", Tag: "operations"},
+	"GET /openapi.json": {Summary: "This OpenAPI document", Tag: "operations"},
+	"GET /docs":         {Summary: "Interactive API documentation", Tag: "operations"},
+}
+
+// apiRoutes documents the routes registered by apiRoutes as they are in v1.
+var apiRoutes = map[string]openapi.Operation{
+	"POST /login":         {Summary: "Log in and get a bearer token", Tag: "auth", Request: loginRequest{}, Response: tokenResponse{}},
+	"POST /login/session": {Summary: "Log in and get a session cookie", Tag: "auth", Request: loginRequest{}, Response: models.UserResponse{}},
+	"POST /register":      {Summary: "Register a user", Tag: "auth", Request: models.UserRequest{}, Response: models.UserResponse{}},
+	"POST /verify-email":  {Summary: "Confirm a change of email", Tag: "auth", Request: models.VerifyEmailRequest{}, Response: models.UserResponse{}},
+
+	"GET /me":                           {Summary: "Get the profile", Tag: "me", Auth: true, Response: models.UserResponse{}},
+	"PUT /me":                           {Summary: "Update the profile", Tag: "me", Auth: true, Request: models.ProfileRequest{}, Response: models.UserResponse{}},
+	"DELETE /me":                        {Summary: "Delete the account", Tag: "me", Auth: true, Request: models.DeleteAccountRequest{}},
+	"PUT /me/password":                  {Summary: "Change the password", Tag: "me", Auth: true, Request: models.ChangePasswordRequest{}, Response: tokenResponse{}},
+	"PUT /me/email":                     {Summary: "Request a change of email", Tag: "me", Auth: true, Request: models.ChangeEmailRequest{}, Response: models.UserResponse{}},
+	"POST /me/logout":                   {Summary: "End the current session", Tag: "me", Auth: true},
+	"GET /me/sessions":                  {Summary: "List the sessions", Tag: "me", Auth: true, Response: []models.SessionResponse{}},
+	"DELETE /me/sessions":               {Summary: "Revoke every session", Tag: "me", Auth: true},
+	"DELETE /me/sessions/:id":           {Summary: "Revoke a session", Tag: "me", Auth: true},
+	"GET /admin/users":                  {Summary: "Search users", Tag: "admin", Auth: true, Query: append([]openapi.Parameter{openapi.QueryParam("q", "string", "matches name or email")}, pageParams...), Response: models.UserResponse{}, Page: true},
+	"POST /admin/users":                 {Summary: "Create a user with a temporary password", Tag: "admin", Auth: true, Request: models.CreateUserRequest{}, Response: models.UserResponse{}},
+	"PUT /admin/users/:id":              {Summary: "Update a user", Tag: "admin", Auth: true, Request: models.UserRequest{}, Response: models.UserResponse{}},
+	"DELETE /admin/users/:id":           {Summary: "Delete a user", Tag: "admin", Auth: true, Query: []openapi.Parameter{openapi.QueryParam("reassign_to", "integer", "user receiving the products of the deleted user")}},
+	"PUT /admin/users/:id/password":     {Summary: "Set the password of a user", Tag: "admin", Auth: true, Request: models.SetPasswordRequest{}},
+	"POST /admin/users/:id/force-reset": {Summary: "Force a user to change their password", Tag: "admin", Auth: true, Response: models.UserResponse{}},
+	"POST /admin/users/:id/deactivate":  {Summary: "Deactivate a user", Tag: "admin", Auth: true, Response: models.UserResponse{}},
+	"POST /admin/users/:id/reactivate":  {Summary: "Reactivate a user", Tag: "admin", Auth: true, Response: models.UserResponse{}},
+	"GET /admin/users/:id/sessions":     {Summary: "List the sessions of a user", Tag: "admin", Auth: true, Response: []models.SessionResponse{}},
+	"DELETE /admin/users/:id/sessions":  {Summary: "Revoke every session of a user", Tag: "admin", Auth: true},
+	"GET /admin/audit-logs": {Summary: "Search the audit log", Tag: "admin", Auth: true, Query: append([]openapi.Parameter{
+		openapi.QueryParam("entity_type", "string", "product or user"),
+		openapi.QueryParam("entity_id", "integer", ""),
+		openapi.QueryParam("actor_id", "integer", ""),
+		openapi.QueryParam("from", "string", "RFC 3339 time"),
+		openapi.QueryParam("to", "string", "RFC 3339 time"),
+	}, pageParams...), Response: models.AuditLogResponse{}, Page: true},
+
+	"GET /admin/webhooks":                {Summary: "List webhooks", Tag: "admin", Auth: true, Response: []models.WebhookResponse{}},
+	"POST /admin/webhooks":               {Summary: "Register a webhook", Tag: "admin", Auth: true, Request: models.WebhookRequest{}, Response: models.WebhookResponse{}},
+	"GET /admin/webhooks/:id":            {Summary: "Get a webhook", Tag: "admin", Auth: true, Response: models.WebhookResponse{}},
+	"PUT /admin/webhooks/:id":            {Summary: "Update a webhook", Tag: "admin", Auth: true, Request: models.WebhookRequest{}, Response: models.WebhookResponse{}},
+	"DELETE /admin/webhooks/:id":         {Summary: "Delete a webhook and its delivery log", Tag: "admin", Auth: true},
+	"GET /admin/webhooks/:id/deliveries": {Summary: "List the delivery attempts of a webhook", Tag: "admin", Auth: true, Query: pageParams, Response: models.WebhookDeliveryResponse{}, Page: true},
+	"GET /admin/outbox": {Summary: "Search the outbox", Tag: "admin", Auth: true, Query: append([]openapi.Parameter{
+		openapi.QueryParam("status", "string", "pending, published, failed or discarded"),
+		openapi.QueryParam("aggregate", "string", "product or user"),
+		openapi.QueryParam("aggregate_id", "integer", ""),
+	}, pageParams...), Response: models.OutboxMessageResponse{}, Page: true},
+	"GET /admin/outbox/stats":        {Summary: "Count the outbox messages by status", Tag: "admin", Auth: true, Response: models.OutboxStats{}},
+	"POST /admin/outbox/:id/retry":   {Summary: "Retry a failed outbox message", Tag: "admin", Auth: true, Response: models.OutboxMessageResponse{}},
+	"POST /admin/outbox/:id/discard": {Summary: "Discard an unpublished outbox message", Tag: "admin", Auth: true, Response: models.OutboxMessageResponse{}},
+	"GET /admin/jobs": {Summary: "Search the background jobs, latest first", Tag: "admin", Auth: true, Query: append([]openapi.Parameter{
+		openapi.QueryParam("status", "string", "queued, running, succeeded, dead or cancelled"),
+		openapi.QueryParam("type", "string", "job type, such as user.mail"),
+	}, pageParams...), Response: models.JobResponse{}, Page: true},
+	"GET /admin/jobs/:id":         {Summary: "Get a background job", Tag: "admin", Auth: true, Response: models.JobResponse{}},
+	"POST /admin/jobs/:id/retry":  {Summary: "Queue a dead or cancelled job again", Tag: "admin", Auth: true, Response: models.JobResponse{}},
+	"POST /admin/jobs/:id/cancel": {Summary: "Cancel a queued job", Tag: "admin", Auth: true, Response: models.JobResponse{}},
+
+	"GET /admin/exchange-rates":              {Summary: "List the exchange rates from the base currency", Tag: "admin", Auth: true, Response: []models.ExchangeRateResponse{}},
+	"PUT /admin/exchange-rates/:currency":    {Summary: "Set the units of a currency one unit of the base currency is worth", Tag: "admin", Auth: true, Request: models.ExchangeRateRequest{}, Response: models.ExchangeRateResponse{}},
+	"DELETE /admin/exchange-rates/:currency": {Summary: "Delete the exchange rate of a currency", Tag: "admin", Auth: true},
+
+	"GET /products":        {Summary: "List products", Tag: "products", Query: currencyParams, Response: []models.ProductResponse{}},
+	"POST /products":       {Summary: "Create a product", Tag: "products", Auth: true, Request: models.ProductRequest{}, Response: models.ProductResponse{}},
+	"PUT /products/:id":    {Summary: "Update a product", Tag: "products", Auth: true, Request: models.ProductRequest{}, Response: models.ProductResponse{}},
+	"DELETE /products/:id": {Summary: "Delete a product", Tag: "products", Auth: true},
+	"POST /products/batch": {Summary: "Create, update and delete products in one request", Tag: "products", Auth: true, Query: []openapi.Parameter{
+		openapi.QueryParam("mode", "string", "all_or_nothing (default) or per_item, answered with 207 Multi-Status"),
+	}, Request: []models.BatchOperation{}, Response: models.BatchReport{}},
+	"POST /products/import": {Summary: "Import products from a CSV or JSON Lines file", Tag: "products", Auth: true, Query: []openapi.Parameter{
+		openapi.QueryParam("mode", "string", "all_or_nothing (default) or per_row"),
+		openapi.QueryParam("dry_run", "boolean", "only validate and report"),
+		openapi.QueryParam("async", "boolean", "run as a background job"),
+		openapi.QueryParam("format", "string", "csv or jsonl, detected from the upload by default"),
+	}, Response: models.ImportReport{}},
+	"GET /products/export": {Summary: "Export products as CSV, JSON Lines or XLSX", Tag: "products", Auth: true, Query: []openapi.Parameter{
+		openapi.QueryParam("format", "string", "csv (default), jsonl or xlsx"),
+		openapi.QueryParam("columns", "string", "comma separated columns, all by default"),
+		openapi.QueryParam("q", "string", "matches name or description"),
+	}},
+	"GET /products/imports/:id": {Summary: "Get the progress of a background import", Tag: "products", Auth: true, Response: models.ImportJobResponse{}},
+	"GET /products/:id/prices":  {Summary: "List the price changes of a product, latest first", Tag: "products", Auth: true, Query: pageParams, Response: models.PriceChangeResponse{}, Page: true},
+	"GET /products/:id/price": {Summary: "Get the price of a product at a time", Tag: "products", Auth: true, Query: []openapi.Parameter{
+		openapi.QueryParam("at", "string", "RFC 3339 time, now by default; future times account for the scheduled changes"),
+	}, Response: models.PriceAtResponse{}},
+	"GET /products/:id/price-schedules":                {Summary: "List the scheduled price changes of a product", Tag: "products", Auth: true, Response: []models.PriceScheduleResponse{}},
+	"POST /products/:id/price-schedules":               {Summary: "Schedule a price change, ended at ends_at for a promotion", Tag: "products", Auth: true, Request: models.PriceScheduleRequest{}, Response: models.PriceScheduleResponse{}},
+	"DELETE /products/:id/price-schedules/:scheduleId": {Summary: "Cancel a price change not started yet", Tag: "products", Auth: true, Response: models.PriceScheduleResponse{}},
+	"GET /products/:id/price-list":                     {Summary: "List the prices of a product in other currencies", Tag: "products", Auth: true, Response: []models.ProductPriceResponse{}},
+	"PUT /products/:id/price-list/:currency":           {Summary: "Set the price of a product in a currency instead of converting it", Tag: "products", Auth: true, Request: models.ProductPriceRequest{}, Response: models.ProductPriceResponse{}},
+	"DELETE /products/:id/price-list/:currency":        {Summary: "Convert the price of a product to a currency again", Tag: "products", Auth: true},
+}
+
+// versionChanges documents the routes that changed since v1, by version.
+// Later versions inherit the changes of earlier ones.
+var versionChanges = map[string]map[string]openapi.Operation{
+	"v2": {
+		"GET /products": {Summary: "Search products", Tag: "products", Query: append(append([]openapi.Parameter{openapi.QueryParam("q", "string", "matches name or description")}, pageParams...), currencyParams...), Response: models.ProductResponse{}, Page: true},
+	},
+}
+
+// Operations documents every route registered by InitRoute. Routes of the
+// deprecated versions are marked as such. A route missing from it is left
+// out of /openapi.json and fails the tests.
+func Operations(deprecations map[string]config.DeprecationConfig) map[string]openapi.Operation {
+	operations := map[string]openapi.Operation{}
+
+	for key, op := range operationalRoutes {
+		operations[key] = op
+	}
+
+	current := map[string]openapi.Operation{}
+
+	for key, op := range apiRoutes {
+		current[key] = op
+	}
+
+	for _, version := range Versions {
+		for key, op := range versionChanges[version] {
+			current[key] = op
+		}
+
+		_, deprecated := deprecations[version]
+
+		for key, op := range current {
+			method, path, _ := strings.Cut(key, " ")
+			op.Deprecated = deprecated
+			op.Tag = version + " " + op.Tag
+
+			operations[openapi.Key(method, "/"+version+path)] = op
+
+			if version == Versions[0] {
+				operations[key] = op
+			}
+		}
+	}
+
+	return operations
+}
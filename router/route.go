@@ -3,31 +3,181 @@ package router
 import (
 	"product/config"
 	"product/handlers"
+	"product/idempotency"
+	"product/middleware"
+	"product/models"
+	"product/openapi"
+	"product/ratelimit"
+	"product/services"
+	"product/session"
+	"time"
 
 	jwtware "github.com/gofiber/contrib/jwt"
 	"github.com/gofiber/fiber/v2"
 )
 
+// Versions lists the API versions, oldest first. Each is mounted under its
+// own prefix; the unprefixed routes are aliases for the first one.
+var Versions = []string{"v1", "v2"}
+
 type HandlerList struct {
-	UserHandler    handlers.UserHandler
-	ProductHandler handlers.ProductHandler
+	UserHandler      handlers.UserHandler
+	ProductHandler   handlers.ProductHandler
+	ImportHandler    handlers.ProductImportHandler
+	BatchHandler     handlers.ProductBatchHandler
+	AuditHandler     handlers.AuditHandler
+	HealthHandler    handlers.HealthHandler
+	SessionHandler   handlers.SessionHandler
+	WebhookHandler   handlers.WebhookHandler
+	OutboxHandler    handlers.OutboxHandler
+	JobHandler       handlers.JobHandler
+	PriceHandler     handlers.PriceHandler
+	CurrencyHandler  handlers.CurrencyHandler
+	UserService      services.UserService
+	MetricsHandler   fiber.Handler
+	RateLimitStore   ratelimit.Store
+	IdempotencyStore idempotency.Store
+	Sessions         *session.Manager
+	Config           *config.Reloader
 }
 
 func (hl *HandlerList) InitRoute(app *fiber.App) {
-	app.Post("/login", hl.UserHandler.Login)
-	app.Post("/register", hl.UserHandler.Register)
+	cfg := hl.Config.Current()
+
+	app.Use(middleware.CORS(func() config.CORSConfig {
+		return hl.Config.Current().Security.CORS
+	}))
+	app.Use(middleware.SecurityHeaders(func() config.HeadersConfig {
+		return hl.Config.Current().Security.Headers
+	}))
+	app.Use(middleware.CSRF(func() config.CSRFConfig {
+		return hl.Config.Current().Security.CSRF
+	}, cfg.Auth.SessionCookie))
+	app.Use(middleware.APIKey(func() []string {
+		return hl.Config.Current().RateLimit.APIKeys
+	}))
+
+	app.Get("/metrics", hl.MetricsHandler)
+	app.Get("/healthz", hl.HealthHandler.Live)
+	app.Get("/readyz", hl.HealthHandler.Ready)
+	app.Get("/openapi.json", openapi.Handler(app, info, Operations(cfg.API.Deprecations), cfg.Auth.SessionCookie))
+	app.Get("/docs", openapi.UI(info.Title, "/openapi.json"))
+
+	app.Use(middleware.NegotiateVersion(Versions))
+
+	for _, version := range Versions {
+		hl.apiRoutes(app.Group("/"+version, middleware.APIVersion(version, cfg.API.Deprecations)), version)
+	}
+
+	// Registered last, the aliases only see requests no version matched.
+	hl.apiRoutes(app.Group("", middleware.APIVersion(Versions[0], cfg.API.Deprecations)), Versions[0])
+}
+
+// apiRoutes registers the routes of version on r. Handlers that differ
+// between versions are picked here.
+func (hl *HandlerList) apiRoutes(r fiber.Router, version string) {
+	cfg := hl.Config.Current()
+
+	timeout := func(group string) fiber.Handler {
+		return middleware.Timeout(func() time.Duration {
+			return hl.Config.Current().RouteTimeout(group)
+		})
+	}
+
+	limit := func(name string) fiber.Handler {
+		return middleware.RateLimit(hl.RateLimitStore, name, func() ratelimit.Policy {
+			return hl.Config.Current().RateLimit.Policies[name]
+		})
+	}
+
+	idempotent := middleware.Idempotency(hl.IdempotencyStore, func() config.IdempotencyConfig {
+		return hl.Config.Current().Idempotency
+	})
+
+	authTimeout := timeout("auth")
+	authLimit := limit("auth")
+	r.Post("/login", authLimit, authTimeout, hl.UserHandler.Login)
+	r.Post("/register", authLimit, authTimeout, idempotent, hl.UserHandler.Register)
+	r.Post("/verify-email", authLimit, authTimeout, hl.UserHandler.VerifyEmail)
+	r.Post("/login/session", authLimit, authTimeout, hl.SessionHandler.Login)
 
 	userJWTMiddleware := jwtware.New(jwtware.Config{
-		SigningKey: jwtware.SigningKey{Key: []byte(config.Cfg.JWT_SECRET_KEY)},
+		SigningKey: jwtware.SigningKey{Key: []byte(config.Cfg.Auth.JWTSecret)},
 	})
+	// authMiddleware accepts the session cookie as well as the bearer token.
+	authMiddleware := middleware.Authenticate(hl.Sessions, cfg.Auth, userJWTMiddleware)
+	currentUserMiddleware := middleware.CurrentUser(hl.UserService)
+	passwordResetMiddleware := middleware.PasswordResetGuard()
+	adminMiddleware := middleware.RequireRole(models.RoleAdmin)
+	// apiLimit runs after currentUserMiddleware so it can key on the user.
+	apiLimit := limit("api")
+
+	me := r.Group("/me", timeout("me"), authMiddleware, currentUserMiddleware, apiLimit)
+	me.Put("/password", hl.UserHandler.ChangePassword)
+	me.Post("/logout", hl.SessionHandler.Logout)
+	me.Get("", passwordResetMiddleware, hl.UserHandler.GetProfile)
+	me.Put("", passwordResetMiddleware, hl.UserHandler.UpdateProfile)
+	me.Delete("", passwordResetMiddleware, hl.UserHandler.DeleteAccount)
+	me.Put("/email", passwordResetMiddleware, hl.UserHandler.ChangeEmail)
+	me.Get("/sessions", passwordResetMiddleware, hl.SessionHandler.GetMine)
+	me.Delete("/sessions", hl.SessionHandler.RevokeMine)
+	me.Delete("/sessions/:id", hl.SessionHandler.Revoke)
 
-	user := app.Group("/users")
-	user.Get("", hl.UserHandler.GetAll)
-	user.Put("/:id", userJWTMiddleware, hl.UserHandler.Update)
+	admin := r.Group("/admin", timeout("admin"), authMiddleware, currentUserMiddleware, apiLimit, passwordResetMiddleware, adminMiddleware)
+	admin.Get("/users", hl.UserHandler.GetAll)
+	admin.Post("/users", hl.UserHandler.Create)
+	admin.Put("/users/:id", hl.UserHandler.Update)
+	admin.Put("/users/:id/password", hl.UserHandler.SetPassword)
+	admin.Post("/users/:id/force-reset", hl.UserHandler.ForcePasswordReset)
+	admin.Post("/users/:id/deactivate", hl.UserHandler.Deactivate)
+	admin.Post("/users/:id/reactivate", hl.UserHandler.Reactivate)
+	admin.Delete("/users/:id", hl.UserHandler.Delete)
+	admin.Get("/users/:id/sessions", hl.SessionHandler.GetByUser)
+	admin.Delete("/users/:id/sessions", hl.SessionHandler.RevokeByUser)
+	admin.Get("/audit-logs", hl.AuditHandler.GetAll)
+	admin.Get("/webhooks", hl.WebhookHandler.GetAll)
+	admin.Post("/webhooks", hl.WebhookHandler.Create)
+	admin.Get("/webhooks/:id", hl.WebhookHandler.Get)
+	admin.Put("/webhooks/:id", hl.WebhookHandler.Update)
+	admin.Delete("/webhooks/:id", hl.WebhookHandler.Delete)
+	admin.Get("/webhooks/:id/deliveries", hl.WebhookHandler.GetDeliveries)
+	admin.Get("/outbox", hl.OutboxHandler.GetAll)
+	admin.Get("/outbox/stats", hl.OutboxHandler.GetStats)
+	admin.Post("/outbox/:id/retry", hl.OutboxHandler.Retry)
+	admin.Post("/outbox/:id/discard", hl.OutboxHandler.Discard)
+	admin.Get("/jobs", hl.JobHandler.GetAll)
+	admin.Get("/jobs/:id", hl.JobHandler.Get)
+	admin.Post("/jobs/:id/retry", hl.JobHandler.Retry)
+	admin.Post("/jobs/:id/cancel", hl.JobHandler.Cancel)
+	admin.Get("/exchange-rates", hl.CurrencyHandler.GetRates)
+	admin.Put("/exchange-rates/:currency", hl.CurrencyHandler.SetRate)
+	admin.Delete("/exchange-rates/:currency", hl.CurrencyHandler.DeleteRate)
+
+	product := r.Group("/products", timeout("products"))
+	productList := hl.ProductHandler.GetAll
+
+	if version != "v1" {
+		productList = hl.ProductHandler.Search
+	}
+
+	cacheControl := middleware.CacheControl(func() time.Duration {
+		return hl.Config.Current().Cache.MaxAge
+	})
 
-	product := app.Group("/products")
-	product.Get("", hl.ProductHandler.GetAll)
-	product.Post("", userJWTMiddleware, hl.ProductHandler.Create)
-	product.Put("/:id", userJWTMiddleware, hl.ProductHandler.Update)
-	product.Delete("/:id", userJWTMiddleware, hl.ProductHandler.Delete)
+	product.Get("", limit("products"), cacheControl, productList)
+	product.Post("", authMiddleware, currentUserMiddleware, apiLimit, passwordResetMiddleware, idempotent, hl.ProductHandler.Create)
+	product.Get("/export", authMiddleware, currentUserMiddleware, apiLimit, passwordResetMiddleware, hl.ProductHandler.Export)
+	product.Put("/:id", authMiddleware, currentUserMiddleware, apiLimit, passwordResetMiddleware, hl.ProductHandler.Update)
+	product.Delete("/:id", authMiddleware, currentUserMiddleware, apiLimit, passwordResetMiddleware, hl.ProductHandler.Delete)
+	product.Post("/batch", authMiddleware, currentUserMiddleware, apiLimit, passwordResetMiddleware, idempotent, hl.BatchHandler.Apply)
+	product.Post("/import", authMiddleware, currentUserMiddleware, apiLimit, passwordResetMiddleware, idempotent, hl.ImportHandler.Import)
+	product.Get("/imports/:id", authMiddleware, currentUserMiddleware, apiLimit, passwordResetMiddleware, hl.ImportHandler.GetJob)
+	product.Get("/:id/prices", authMiddleware, currentUserMiddleware, apiLimit, passwordResetMiddleware, hl.PriceHandler.GetHistory)
+	product.Get("/:id/price", authMiddleware, currentUserMiddleware, apiLimit, passwordResetMiddleware, hl.PriceHandler.GetPriceAt)
+	product.Get("/:id/price-schedules", authMiddleware, currentUserMiddleware, apiLimit, passwordResetMiddleware, hl.PriceHandler.GetSchedules)
+	product.Post("/:id/price-schedules", authMiddleware, currentUserMiddleware, apiLimit, passwordResetMiddleware, hl.PriceHandler.CreateSchedule)
+	product.Delete("/:id/price-schedules/:scheduleId", authMiddleware, currentUserMiddleware, apiLimit, passwordResetMiddleware, hl.PriceHandler.CancelSchedule)
+	product.Get("/:id/price-list", authMiddleware, currentUserMiddleware, apiLimit, passwordResetMiddleware, hl.CurrencyHandler.GetPriceList)
+	product.Put("/:id/price-list/:currency", authMiddleware, currentUserMiddleware, apiLimit, passwordResetMiddleware, hl.CurrencyHandler.SetPrice)
+	product.Delete("/:id/price-list/:currency", authMiddleware, currentUserMiddleware, apiLimit, passwordResetMiddleware, hl.CurrencyHandler.DeletePrice)
 }
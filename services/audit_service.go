@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"product/models"
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+// redactedFields never have their values written to the audit log.
+var redactedFields = map[string]bool{
+	"Password":   true,
+	"EmailToken": true,
+	"Secret":     true,
+}
+
+type AuditService interface {
+	Search(ctx context.Context, filter models.AuditFilter, page int, limit int) ([]models.AuditLog, int64, error)
+}
+
+func NewAuditService(gormDB *gorm.DB, logger *slog.Logger) AuditService {
+	return &AuditServiceImpl{
+		db:     gormDB,
+		logger: logger,
+	}
+}
+
+type AuditServiceImpl struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func (as *AuditServiceImpl) Search(ctx context.Context, filter models.AuditFilter, page int, limit int) ([]models.AuditLog, int64, error) {
+	ctx, span := tracer.Start(ctx, "AuditService.Search")
+	defer span.End()
+
+	var logs []models.AuditLog
+	var total int64
+
+	rec := as.db.WithContext(ctx).Model(&models.AuditLog{})
+
+	if filter.EntityType != "" {
+		rec = rec.Where("entity_type = ?", filter.EntityType)
+	}
+
+	if filter.EntityID != 0 {
+		rec = rec.Where("entity_id = ?", filter.EntityID)
+	}
+
+	if filter.ActorID != 0 {
+		rec = rec.Where("actor_id = ?", filter.ActorID)
+	}
+
+	if !filter.From.IsZero() {
+		rec = rec.Where("created_at >= ?", filter.From)
+	}
+
+	if !filter.To.IsZero() {
+		rec = rec.Where("created_at <= ?", filter.To)
+	}
+
+	if err := rec.Count(&total).Error; err != nil {
+		logError(ctx, as.logger, "failed to count audit logs", err)
+		return nil, 0, err
+	}
+
+	err := rec.Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&logs).Error
+
+	if err != nil {
+		logError(ctx, as.logger, "failed to search audit logs", err)
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
+
+// recordAudit appends an audit row using tx, so it has to be called inside the
+// transaction performing the change. before is nil for creates and after is
+// nil for deletes.
+func recordAudit(tx *gorm.DB, actor models.Actor, action string, entityType string, entityID uint, before any, after any) error {
+	changes, err := json.Marshal(diff(before, after))
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&models.AuditLog{
+		ActorID:    actor.ID,
+		ActorEmail: actor.Email,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    string(changes),
+		IP:         actor.IP,
+		RequestID:  actor.RequestID,
+	}).Error
+}
+
+// diff compares the exported fields of two values of the same struct type and
+// returns the ones that differ.
+func diff(before any, after any) map[string]models.Change {
+	changes := map[string]models.Change{}
+
+	beforeFields := fields(before)
+	afterFields := fields(after)
+
+	for name, afterValue := range afterFields {
+		beforeValue, ok := beforeFields[name]
+
+		if ok && reflect.DeepEqual(beforeValue, afterValue) {
+			continue
+		}
+
+		changes[name] = models.Change{Before: beforeValue, After: afterValue}
+	}
+
+	for name, beforeValue := range beforeFields {
+		if _, ok := afterFields[name]; !ok {
+			changes[name] = models.Change{Before: beforeValue}
+		}
+	}
+
+	for name, change := range changes {
+		if redactedFields[name] {
+			changes[name] = models.Change{Before: redact(change.Before), After: redact(change.After)}
+		}
+	}
+
+	return changes
+}
+
+func fields(value any) map[string]any {
+	result := map[string]any{}
+
+	if value == nil {
+		return result
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(value))
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+
+		if field.IsExported() {
+			result[field.Name] = v.Field(i).Interface()
+		}
+	}
+
+	return result
+}
+
+func redact(value any) any {
+	if value == nil {
+		return nil
+	}
+
+	return "[redacted]"
+}
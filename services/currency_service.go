@@ -0,0 +1,271 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"product/models"
+	"product/money"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNoRate is returned when converting to a currency without exchange rate.
+var ErrNoRate = errors.New("no exchange rate for the currency")
+
+type CurrencyService interface {
+	GetRates(ctx context.Context) ([]models.ExchangeRate, error)
+	SetRate(ctx context.Context, actor models.Actor, rate models.ExchangeRate) (models.ExchangeRate, error)
+	DeleteRate(ctx context.Context, actor models.Actor, currency string) error
+	GetPriceList(ctx context.Context, productID uint) ([]models.ProductPrice, error)
+	SetPrice(ctx context.Context, actor models.Actor, price models.ProductPrice) (models.ProductPrice, error)
+	DeletePrice(ctx context.Context, actor models.Actor, productID uint, currency string) error
+	Convert(ctx context.Context, currency money.Currency, products []models.Product) ([]money.Money, error)
+}
+
+// NewCurrencyService converts the prices, in base, to other currencies with
+// the rounding of rules.
+func NewCurrencyService(gormDB *gorm.DB, logger *slog.Logger, base money.Currency, rules money.Rules) CurrencyService {
+	return &CurrencyServiceImpl{
+		db:     gormDB,
+		logger: logger,
+		base:   base,
+		rules:  rules,
+	}
+}
+
+type CurrencyServiceImpl struct {
+	db     *gorm.DB
+	logger *slog.Logger
+	base   money.Currency
+	rules  money.Rules
+}
+
+func (cs *CurrencyServiceImpl) GetRates(ctx context.Context) ([]models.ExchangeRate, error) {
+	ctx, span := tracer.Start(ctx, "CurrencyService.GetRates")
+	defer span.End()
+
+	var rates []models.ExchangeRate
+
+	if err := cs.db.WithContext(ctx).Order("currency").Find(&rates).Error; err != nil {
+		logError(ctx, cs.logger, "failed to get exchange rates", err)
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+// SetRate creates or replaces the exchange rate of rate.Currency.
+func (cs *CurrencyServiceImpl) SetRate(ctx context.Context, actor models.Actor, rate models.ExchangeRate) (models.ExchangeRate, error) {
+	ctx, span := tracer.Start(ctx, "CurrencyService.SetRate")
+	defer span.End()
+
+	err := cs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var before models.ExchangeRate
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("currency = ?", rate.Currency).Limit(1).Find(&before).Error; err != nil {
+			return err
+		}
+
+		rate.ID = before.ID
+
+		if err := tx.Save(&rate).Error; err != nil {
+			return err
+		}
+
+		if before.ID == 0 {
+			return recordAudit(tx, actor, models.AuditCreate, models.EntityExchangeRate, rate.ID, nil, rate)
+		}
+
+		return recordAudit(tx, actor, models.AuditUpdate, models.EntityExchangeRate, rate.ID, before, rate)
+	})
+
+	if err != nil {
+		logError(ctx, cs.logger, "failed to set exchange rate", err, actorAttrs(actor, "currency", rate.Currency)...)
+		return models.ExchangeRate{}, err
+	}
+
+	return rate, nil
+}
+
+func (cs *CurrencyServiceImpl) DeleteRate(ctx context.Context, actor models.Actor, currency string) error {
+	ctx, span := tracer.Start(ctx, "CurrencyService.DeleteRate")
+	defer span.End()
+
+	err := cs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var rate models.ExchangeRate
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&rate, "currency = ?", currency).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&rate).Error; err != nil {
+			return err
+		}
+
+		return recordAudit(tx, actor, models.AuditDelete, models.EntityExchangeRate, rate.ID, rate, nil)
+	})
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logError(ctx, cs.logger, "failed to delete exchange rate", err, actorAttrs(actor, "currency", currency)...)
+	}
+
+	return err
+}
+
+func (cs *CurrencyServiceImpl) GetPriceList(ctx context.Context, productID uint) ([]models.ProductPrice, error) {
+	ctx, span := tracer.Start(ctx, "CurrencyService.GetPriceList")
+	defer span.End()
+
+	var prices []models.ProductPrice
+
+	if err := cs.db.WithContext(ctx).Where("product_id = ?", productID).Order("currency").Find(&prices).Error; err != nil {
+		logError(ctx, cs.logger, "failed to get price list", err, "product_id", productID)
+		return nil, err
+	}
+
+	return prices, nil
+}
+
+// SetPrice creates or replaces the price of price.ProductID in
+// price.Currency.
+func (cs *CurrencyServiceImpl) SetPrice(ctx context.Context, actor models.Actor, price models.ProductPrice) (models.ProductPrice, error) {
+	ctx, span := tracer.Start(ctx, "CurrencyService.SetPrice")
+	defer span.End()
+
+	err := cs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var before models.ProductPrice
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("product_id = ? AND currency = ?", price.ProductID, price.Currency).Limit(1).Find(&before).Error
+
+		if err != nil {
+			return err
+		}
+
+		price.ID = before.ID
+
+		if err := tx.Save(&price).Error; err != nil {
+			return err
+		}
+
+		if before.ID == 0 {
+			return recordAudit(tx, actor, models.AuditCreate, models.EntityProductPrice, price.ID, nil, price)
+		}
+
+		return recordAudit(tx, actor, models.AuditUpdate, models.EntityProductPrice, price.ID, before, price)
+	})
+
+	if err != nil {
+		logError(ctx, cs.logger, "failed to set product price", err, actorAttrs(actor, "product_id", price.ProductID, "currency", price.Currency)...)
+		return models.ProductPrice{}, err
+	}
+
+	return price, nil
+}
+
+func (cs *CurrencyServiceImpl) DeletePrice(ctx context.Context, actor models.Actor, productID uint, currency string) error {
+	ctx, span := tracer.Start(ctx, "CurrencyService.DeletePrice")
+	defer span.End()
+
+	err := cs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var price models.ProductPrice
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&price, "product_id = ? AND currency = ?", productID, currency).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&price).Error; err != nil {
+			return err
+		}
+
+		return recordAudit(tx, actor, models.AuditDelete, models.EntityProductPrice, price.ID, price, nil)
+	})
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logError(ctx, cs.logger, "failed to delete product price", err, actorAttrs(actor, "product_id", productID, "currency", currency)...)
+	}
+
+	return err
+}
+
+// Convert returns the prices of products in currency, in the same order. A
+// product with a price in currency in its price list keeps it; the others
+// have their price converted at the exchange rate of currency, which fails
+// with ErrNoRate when there is none.
+func (cs *CurrencyServiceImpl) Convert(ctx context.Context, currency money.Currency, products []models.Product) ([]money.Money, error) {
+	ctx, span := tracer.Start(ctx, "CurrencyService.Convert")
+	defer span.End()
+
+	prices := make([]money.Money, len(products))
+
+	if currency == cs.base || len(products) == 0 {
+		for i, product := range products {
+			prices[i] = money.New(int64(product.Price), cs.base)
+		}
+
+		return prices, nil
+	}
+
+	ids := make([]uint, len(products))
+
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+
+	var listed []models.ProductPrice
+
+	if err := cs.db.WithContext(ctx).Where("currency = ? AND product_id IN ?", currency.Code, ids).Find(&listed).Error; err != nil {
+		logError(ctx, cs.logger, "failed to get product prices", err, "currency", currency.Code)
+		return nil, err
+	}
+
+	amounts := map[uint]int64{}
+
+	for _, price := range listed {
+		amounts[price.ProductID] = price.Amount
+	}
+
+	// The exchange rate is only needed for the products not in the list.
+	var exchangeRate *big.Rat
+
+	if len(amounts) < len(products) {
+		var rate models.ExchangeRate
+
+		if err := cs.db.WithContext(ctx).Where("currency = ?", currency.Code).Limit(1).Find(&rate).Error; err != nil {
+			logError(ctx, cs.logger, "failed to get exchange rate", err, "currency", currency.Code)
+			return nil, err
+		}
+
+		if rate.ID == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrNoRate, currency.Code)
+		}
+
+		var err error
+
+		if exchangeRate, err = money.ParseRate(rate.Rate); err != nil {
+			logError(ctx, cs.logger, "invalid exchange rate", err, "currency", currency.Code, "rate", rate.Rate)
+			return nil, err
+		}
+	}
+
+	for i, product := range products {
+		if amount, ok := amounts[product.ID]; ok {
+			prices[i] = money.New(amount, currency)
+		} else {
+			prices[i] = money.Convert(money.New(int64(product.Price), cs.base), currency, exchangeRate, cs.rules.For(currency))
+		}
+	}
+
+	return prices, nil
+}
+
+// deletePriceList deletes the prices of a deleted product in the other
+// currencies. Like recordAudit it has to be called inside the transaction of
+// the deletion.
+func deletePriceList(tx *gorm.DB, productID uint) error {
+	return tx.Where("product_id = ?", productID).Delete(&models.ProductPrice{}).Error
+}
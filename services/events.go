@@ -0,0 +1,63 @@
+package services
+
+import (
+	"encoding/json"
+	"product/events"
+	"product/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// recordProductEvents appends the events of a change to a product to the
+// outbox using tx, so like recordAudit it has to be called inside the
+// transaction performing the change. before is nil for creates and after is
+// nil for deletes. stock.low is added when the stock drops below lowStock.
+func recordProductEvents(tx *gorm.DB, lowStock int, before *models.Product, after *models.Product) error {
+	switch {
+	case before == nil:
+		if err := recordEvent(tx, events.ProductCreated, after.ID, events.ProductData{Product: after.ConvertToResponse()}); err != nil {
+			return err
+		}
+	case after == nil:
+		return recordEvent(tx, events.ProductDeleted, before.ID, events.ProductData{Product: before.ConvertToResponse()})
+	default:
+		previous := before.ConvertToResponse()
+
+		if err := recordEvent(tx, events.ProductUpdated, after.ID, events.ProductData{Product: after.ConvertToResponse(), Previous: &previous}); err != nil {
+			return err
+		}
+	}
+
+	// stock.low is raised when the stock crosses the threshold, not on every
+	// change of a product already low on stock.
+	if after.Stock < lowStock && (before == nil || before.Stock >= lowStock) {
+		return recordEvent(tx, events.StockLow, after.ID, events.StockData{Product: after.ConvertToResponse(), Threshold: lowStock})
+	}
+
+	return nil
+}
+
+func recordEvent(tx *gorm.DB, eventType string, aggregateID uint, data any) error {
+	event, err := events.New(eventType, aggregateID, data)
+
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(event)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&models.OutboxMessage{
+		EventID:       event.ID,
+		Type:          event.Type,
+		Aggregate:     event.Aggregate,
+		AggregateID:   event.AggregateID,
+		Payload:       string(payload),
+		Status:        models.OutboxPending,
+		NextAttemptAt: time.Now(),
+	}).Error
+}
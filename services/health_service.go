@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+type HealthService interface {
+	Ping(ctx context.Context) error
+	CheckMigrations(ctx context.Context) error
+}
+
+func NewHealthService(gormDB *gorm.DB, logger *slog.Logger, models []any) HealthService {
+	return &HealthServiceImpl{
+		db:     gormDB,
+		logger: logger,
+		models: models,
+	}
+}
+
+type HealthServiceImpl struct {
+	db     *gorm.DB
+	logger *slog.Logger
+	models []any
+	// migrated is set once the schema has been found current, which does
+	// not change while the process runs.
+	migrated atomic.Bool
+}
+
+func (hs *HealthServiceImpl) Ping(ctx context.Context) error {
+	sqlDB, err := hs.db.DB()
+
+	if err != nil {
+		return err
+	}
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		logError(ctx, hs.logger, "failed to ping database", err)
+		return err
+	}
+
+	return nil
+}
+
+// CheckMigrations verifies that the table of every migrated model exists
+// with all the columns and indexes of the model.
+func (hs *HealthServiceImpl) CheckMigrations(ctx context.Context) error {
+	if hs.migrated.Load() {
+		return nil
+	}
+
+	db := hs.db.WithContext(ctx)
+	migrator := db.Migrator()
+
+	for _, model := range hs.models {
+		if err := checkMigration(db, migrator, model); err != nil {
+			logError(ctx, hs.logger, "database is not migrated", err)
+			return err
+		}
+	}
+
+	hs.migrated.Store(true)
+
+	return nil
+}
+
+func checkMigration(db *gorm.DB, migrator gorm.Migrator, model any) error {
+	if !migrator.HasTable(model) {
+		return fmt.Errorf("table of %T is missing", model)
+	}
+
+	stmt := &gorm.Statement{DB: db}
+
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+
+	for _, field := range stmt.Schema.Fields {
+		if field.DBName != "" && !migrator.HasColumn(model, field.DBName) {
+			return fmt.Errorf("column %s of %T is missing", field.DBName, model)
+		}
+	}
+
+	for name := range stmt.Schema.ParseIndexes() {
+		if !migrator.HasIndex(model, name) {
+			return fmt.Errorf("index %s of %T is missing", name, model)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"product/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrJobStatus rejects a retry or cancellation of a job in the wrong status.
+var ErrJobStatus = errors.New("job cannot be changed in its status")
+
+type JobService interface {
+	Enqueue(ctx context.Context, job models.Job) (models.Job, error)
+	Search(ctx context.Context, filter models.JobFilter, page int, limit int) ([]models.Job, int64, error)
+	GetByID(ctx context.Context, id string) (models.Job, error)
+	Retry(ctx context.Context, id string) (models.Job, error)
+	Cancel(ctx context.Context, id string) (models.Job, error)
+	Claim(ctx context.Context, worker string, types []string, now time.Time) (models.Job, bool, error)
+	Update(ctx context.Context, job models.Job) error
+	RequeueStale(ctx context.Context, lockedBefore time.Time) (int64, error)
+	DeleteFinished(ctx context.Context, before time.Time) error
+}
+
+func NewJobService(gormDB *gorm.DB, logger *slog.Logger) JobService {
+	return &JobServiceImpl{
+		db:     gormDB,
+		logger: logger,
+	}
+}
+
+type JobServiceImpl struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func (js *JobServiceImpl) Enqueue(ctx context.Context, job models.Job) (models.Job, error) {
+	ctx, span := tracer.Start(ctx, "JobService.Enqueue")
+	defer span.End()
+
+	job.Status = models.JobQueued
+
+	if err := js.db.WithContext(ctx).Create(&job).Error; err != nil {
+		logError(ctx, js.logger, "failed to enqueue job", err, "job_type", job.Type)
+		return models.Job{}, err
+	}
+
+	return job, nil
+}
+
+func (js *JobServiceImpl) Search(ctx context.Context, filter models.JobFilter, page int, limit int) ([]models.Job, int64, error) {
+	ctx, span := tracer.Start(ctx, "JobService.Search")
+	defer span.End()
+
+	var jobs []models.Job
+	var total int64
+
+	rec := js.db.WithContext(ctx).Model(&models.Job{})
+
+	if filter.Status != "" {
+		rec = rec.Where("status = ?", filter.Status)
+	}
+
+	if filter.Type != "" {
+		rec = rec.Where("type = ?", filter.Type)
+	}
+
+	if err := rec.Count(&total).Error; err != nil {
+		logError(ctx, js.logger, "failed to count jobs", err)
+		return nil, 0, err
+	}
+
+	if err := rec.Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&jobs).Error; err != nil {
+		logError(ctx, js.logger, "failed to search jobs", err)
+		return nil, 0, err
+	}
+
+	return jobs, total, nil
+}
+
+func (js *JobServiceImpl) GetByID(ctx context.Context, id string) (models.Job, error) {
+	ctx, span := tracer.Start(ctx, "JobService.GetByID")
+	defer span.End()
+
+	var job models.Job
+
+	if err := js.db.WithContext(ctx).First(&job, "id = ?", id).Error; err != nil {
+		logError(ctx, js.logger, "failed to get job", err, "job_id", id)
+		return models.Job{}, err
+	}
+
+	return job, nil
+}
+
+// Retry queues a dead or cancelled job again with fresh attempts.
+func (js *JobServiceImpl) Retry(ctx context.Context, id string) (models.Job, error) {
+	ctx, span := tracer.Start(ctx, "JobService.Retry")
+	defer span.End()
+
+	return js.transition(ctx, id, []string{models.JobDead, models.JobCancelled}, func(job *models.Job) {
+		job.Status = models.JobQueued
+		job.Attempts = 0
+		job.RunAt = time.Now()
+		job.FinishedAt = nil
+	})
+}
+
+// Cancel drops a job that has not started yet, delayed or not.
+func (js *JobServiceImpl) Cancel(ctx context.Context, id string) (models.Job, error) {
+	ctx, span := tracer.Start(ctx, "JobService.Cancel")
+	defer span.End()
+
+	return js.transition(ctx, id, []string{models.JobQueued}, func(job *models.Job) {
+		finishedAt := time.Now()
+		job.Status = models.JobCancelled
+		job.FinishedAt = &finishedAt
+	})
+}
+
+// transition changes a job found in one of the statuses from.
+func (js *JobServiceImpl) transition(ctx context.Context, id string, from []string, change func(job *models.Job)) (models.Job, error) {
+	var job models.Job
+
+	err := js.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&job, "id = ?", id).Error; err != nil {
+			return err
+		}
+
+		for _, status := range from {
+			if job.Status == status {
+				change(&job)
+				return tx.Save(&job).Error
+			}
+		}
+
+		return ErrJobStatus
+	})
+
+	if err != nil {
+		logError(ctx, js.logger, "failed to change job", err, "job_id", id)
+		return models.Job{}, err
+	}
+
+	return job, nil
+}
+
+// Claim marks the next due job of one of types as running by worker and
+// returns it, or reports false when none is due. Jobs locked by another
+// claim are skipped, so instances never run the same job twice at once.
+func (js *JobServiceImpl) Claim(ctx context.Context, worker string, types []string, now time.Time) (models.Job, bool, error) {
+	ctx, span := tracer.Start(ctx, "JobService.Claim")
+	defer span.End()
+
+	var job models.Job
+
+	err := js.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND run_at <= ? AND type IN ?", models.JobQueued, now, types).
+			Order("run_at, id").Limit(1).Find(&job).Error
+
+		if err != nil || job.ID == 0 {
+			return err
+		}
+
+		job.Status = models.JobRunning
+		job.Attempts++
+		job.LockedBy = worker
+		job.LockedAt = &now
+
+		return tx.Save(&job).Error
+	})
+
+	if err != nil {
+		logError(ctx, js.logger, "failed to claim job", err, "worker", worker)
+		return models.Job{}, false, err
+	}
+
+	return job, job.ID != 0, nil
+}
+
+func (js *JobServiceImpl) Update(ctx context.Context, job models.Job) error {
+	ctx, span := tracer.Start(ctx, "JobService.Update")
+	defer span.End()
+
+	if err := js.db.WithContext(ctx).Save(&job).Error; err != nil {
+		logError(ctx, js.logger, "failed to update job", err, "job_id", job.ID)
+		return err
+	}
+
+	return nil
+}
+
+// RequeueStale queues again the jobs still running since before
+// lockedBefore, left behind by an instance that stopped without finishing
+// them. The interrupted run counts as an attempt.
+func (js *JobServiceImpl) RequeueStale(ctx context.Context, lockedBefore time.Time) (int64, error) {
+	ctx, span := tracer.Start(ctx, "JobService.RequeueStale")
+	defer span.End()
+
+	rec := js.db.WithContext(ctx).Model(&models.Job{}).
+		Where("status = ? AND locked_at < ?", models.JobRunning, lockedBefore).
+		Updates(map[string]any{"status": models.JobQueued, "locked_by": "", "locked_at": nil, "run_at": time.Now()})
+
+	if rec.Error != nil {
+		logError(ctx, js.logger, "failed to requeue stale jobs", rec.Error)
+		return 0, rec.Error
+	}
+
+	return rec.RowsAffected, nil
+}
+
+// DeleteFinished removes the succeeded and cancelled jobs finished before a
+// time. Dead jobs are kept for admins to look into.
+func (js *JobServiceImpl) DeleteFinished(ctx context.Context, before time.Time) error {
+	ctx, span := tracer.Start(ctx, "JobService.DeleteFinished")
+	defer span.End()
+
+	err := js.db.WithContext(ctx).
+		Where("status IN ? AND finished_at < ?", []string{models.JobSucceeded, models.JobCancelled}, before).
+		Delete(&models.Job{}).Error
+
+	if err != nil {
+		logError(ctx, js.logger, "failed to delete finished jobs", err)
+	}
+
+	return err
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+)
+
+type Mailer interface {
+	Send(ctx context.Context, to string, subject string, body string) error
+}
+
+// UserMailer sends users the mails carrying their credentials: the
+// temporary password of an account created by an admin and the token that
+// verifies a new email. The credentials are made when the mail is sent.
+type UserMailer interface {
+	SendTemporaryPassword(ctx context.Context, userID uint) error
+	SendEmailVerification(ctx context.Context, userID uint) error
+}
+
+func NewMailer(logger *slog.Logger) Mailer {
+	return &LogMailer{
+		logger: logger,
+	}
+}
+
+// LogMailer writes outgoing mails to the application log. It is used until
+// a real mail provider is configured. Bodies carry temporary passwords and
+// tokens, so only their size is logged.
+type LogMailer struct {
+	logger *slog.Logger
+}
+
+func (lm *LogMailer) Send(ctx context.Context, to string, subject string, body string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	lm.logger.InfoContext(ctx, "mail", "to", to, "subject", subject, "body_bytes", len(body))
+
+	return nil
+}
@@ -3,6 +3,7 @@
 package mocks
 
 import (
+	context "context"
 	models "product/models"
 
 	mock "github.com/stretchr/testify/mock"
@@ -13,20 +14,41 @@ type ProductService struct {
 	mock.Mock
 }
 
-// Create provides a mock function with given fields: productRequest
-func (_m *ProductService) Create(productRequest models.Product) (models.Product, error) {
-	ret := _m.Called(productRequest)
+// Batch provides a mock function with given fields: ctx, actor, operations, mode
+func (_m *ProductService) Batch(ctx context.Context, actor models.Actor, operations []models.BatchOperation, mode string) (models.BatchReport, error) {
+	ret := _m.Called(ctx, actor, operations, mode)
+
+	var r0 models.BatchReport
+	if rf, ok := ret.Get(0).(func(context.Context, models.Actor, []models.BatchOperation, string) models.BatchReport); ok {
+		r0 = rf(ctx, actor, operations, mode)
+	} else {
+		r0 = ret.Get(0).(models.BatchReport)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, models.Actor, []models.BatchOperation, string) error); ok {
+		r1 = rf(ctx, actor, operations, mode)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Create provides a mock function with given fields: ctx, actor, productRequest
+func (_m *ProductService) Create(ctx context.Context, actor models.Actor, productRequest models.Product) (models.Product, error) {
+	ret := _m.Called(ctx, actor, productRequest)
 
 	var r0 models.Product
-	if rf, ok := ret.Get(0).(func(models.Product) models.Product); ok {
-		r0 = rf(productRequest)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Actor, models.Product) models.Product); ok {
+		r0 = rf(ctx, actor, productRequest)
 	} else {
 		r0 = ret.Get(0).(models.Product)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(models.Product) error); ok {
-		r1 = rf(productRequest)
+	if rf, ok := ret.Get(1).(func(context.Context, models.Actor, models.Product) error); ok {
+		r1 = rf(ctx, actor, productRequest)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -34,13 +56,13 @@ func (_m *ProductService) Create(productRequest models.Product) (models.Product,
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: product
-func (_m *ProductService) Delete(product models.Product) error {
-	ret := _m.Called(product)
+// Delete provides a mock function with given fields: ctx, actor, product
+func (_m *ProductService) Delete(ctx context.Context, actor models.Actor, product models.Product) error {
+	ret := _m.Called(ctx, actor, product)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(models.Product) error); ok {
-		r0 = rf(product)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Actor, models.Product) error); ok {
+		r0 = rf(ctx, actor, product)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -48,13 +70,27 @@ func (_m *ProductService) Delete(product models.Product) error {
 	return r0
 }
 
-// GetAll provides a mock function with given fields:
-func (_m *ProductService) GetAll() ([]models.Product, error) {
-	ret := _m.Called()
+// Each provides a mock function with given fields: ctx, query, fn
+func (_m *ProductService) Each(ctx context.Context, query string, fn func(models.Product) error) error {
+	ret := _m.Called(ctx, query, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, func(models.Product) error) error); ok {
+		r0 = rf(ctx, query, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAll provides a mock function with given fields: ctx
+func (_m *ProductService) GetAll(ctx context.Context) ([]models.Product, error) {
+	ret := _m.Called(ctx)
 
 	var r0 []models.Product
-	if rf, ok := ret.Get(0).(func() []models.Product); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) []models.Product); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]models.Product)
@@ -62,8 +98,8 @@ func (_m *ProductService) GetAll() ([]models.Product, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -71,20 +107,20 @@ func (_m *ProductService) GetAll() ([]models.Product, error) {
 	return r0, r1
 }
 
-// GetByCondition provides a mock function with given fields: key, value
-func (_m *ProductService) GetByCondition(key string, value string) (models.Product, error) {
-	ret := _m.Called(key, value)
+// GetByCondition provides a mock function with given fields: ctx, key, value
+func (_m *ProductService) GetByCondition(ctx context.Context, key string, value string) (models.Product, error) {
+	ret := _m.Called(ctx, key, value)
 
 	var r0 models.Product
-	if rf, ok := ret.Get(0).(func(string, string) models.Product); ok {
-		r0 = rf(key, value)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) models.Product); ok {
+		r0 = rf(ctx, key, value)
 	} else {
 		r0 = ret.Get(0).(models.Product)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(key, value)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, key, value)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -92,20 +128,71 @@ func (_m *ProductService) GetByCondition(key string, value string) (models.Produ
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: id, productRequest
-func (_m *ProductService) Update(id string, productRequest models.Product) (models.Product, error) {
-	ret := _m.Called(id, productRequest)
+// GetStats provides a mock function with given fields: ctx
+func (_m *ProductService) GetStats(ctx context.Context) (models.ProductStats, error) {
+	ret := _m.Called(ctx)
+
+	var r0 models.ProductStats
+	if rf, ok := ret.Get(0).(func(context.Context) models.ProductStats); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(models.ProductStats)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Search provides a mock function with given fields: ctx, query, page, limit
+func (_m *ProductService) Search(ctx context.Context, query string, page int, limit int) ([]models.Product, int64, error) {
+	ret := _m.Called(ctx, query, page, limit)
+
+	var r0 []models.Product
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) []models.Product); ok {
+		r0 = rf(ctx, query, page, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Product)
+		}
+	}
+
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) int64); ok {
+		r1 = rf(ctx, query, page, limit)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, int, int) error); ok {
+		r2 = rf(ctx, query, page, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Update provides a mock function with given fields: ctx, actor, id, productRequest
+func (_m *ProductService) Update(ctx context.Context, actor models.Actor, id string, productRequest models.ProductRequest) (models.Product, error) {
+	ret := _m.Called(ctx, actor, id, productRequest)
 
 	var r0 models.Product
-	if rf, ok := ret.Get(0).(func(string, models.Product) models.Product); ok {
-		r0 = rf(id, productRequest)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Actor, string, models.ProductRequest) models.Product); ok {
+		r0 = rf(ctx, actor, id, productRequest)
 	} else {
 		r0 = ret.Get(0).(models.Product)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, models.Product) error); ok {
-		r1 = rf(id, productRequest)
+	if rf, ok := ret.Get(1).(func(context.Context, models.Actor, string, models.ProductRequest) error); ok {
+		r1 = rf(ctx, actor, id, productRequest)
 	} else {
 		r1 = ret.Error(1)
 	}
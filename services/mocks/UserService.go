@@ -3,6 +3,7 @@
 package mocks
 
 import (
+	context "context"
 	models "product/models"
 
 	mock "github.com/stretchr/testify/mock"
@@ -13,20 +14,20 @@ type UserService struct {
 	mock.Mock
 }
 
-// Create provides a mock function with given fields: userRequest
-func (_m *UserService) Create(userRequest models.User) (models.User, error) {
-	ret := _m.Called(userRequest)
+// Create provides a mock function with given fields: ctx, actor, userRequest
+func (_m *UserService) Create(ctx context.Context, actor models.Actor, userRequest models.User) (models.User, error) {
+	ret := _m.Called(ctx, actor, userRequest)
 
 	var r0 models.User
-	if rf, ok := ret.Get(0).(func(models.User) models.User); ok {
-		r0 = rf(userRequest)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Actor, models.User) models.User); ok {
+		r0 = rf(ctx, actor, userRequest)
 	} else {
 		r0 = ret.Get(0).(models.User)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(models.User) error); ok {
-		r1 = rf(userRequest)
+	if rf, ok := ret.Get(1).(func(context.Context, models.Actor, models.User) error); ok {
+		r1 = rf(ctx, actor, userRequest)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -34,13 +35,27 @@ func (_m *UserService) Create(userRequest models.User) (models.User, error) {
 	return r0, r1
 }
 
-// GetAll provides a mock function with given fields:
-func (_m *UserService) GetAll() ([]models.User, error) {
-	ret := _m.Called()
+// Delete provides a mock function with given fields: ctx, actor, user, reassignTo
+func (_m *UserService) Delete(ctx context.Context, actor models.Actor, user models.User, reassignTo uint) error {
+	ret := _m.Called(ctx, actor, user, reassignTo)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Actor, models.User, uint) error); ok {
+		r0 = rf(ctx, actor, user, reassignTo)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAll provides a mock function with given fields: ctx
+func (_m *UserService) GetAll(ctx context.Context) ([]models.User, error) {
+	ret := _m.Called(ctx)
 
 	var r0 []models.User
-	if rf, ok := ret.Get(0).(func() []models.User); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) []models.User); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]models.User)
@@ -48,8 +63,29 @@ func (_m *UserService) GetAll() ([]models.User, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByCondition provides a mock function with given fields: ctx, key, value
+func (_m *UserService) GetByCondition(ctx context.Context, key string, value string) (models.User, error) {
+	ret := _m.Called(ctx, key, value)
+
+	var r0 models.User
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) models.User); ok {
+		r0 = rf(ctx, key, value)
+	} else {
+		r0 = ret.Get(0).(models.User)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, key, value)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -57,20 +93,50 @@ func (_m *UserService) GetAll() ([]models.User, error) {
 	return r0, r1
 }
 
-// GetByCondition provides a mock function with given fields: key, value
-func (_m *UserService) GetByCondition(key string, value string) (models.User, error) {
-	ret := _m.Called(key, value)
+// Search provides a mock function with given fields: ctx, query, page, limit
+func (_m *UserService) Search(ctx context.Context, query string, page int, limit int) ([]models.User, int64, error) {
+	ret := _m.Called(ctx, query, page, limit)
+
+	var r0 []models.User
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) []models.User); ok {
+		r0 = rf(ctx, query, page, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.User)
+		}
+	}
+
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) int64); ok {
+		r1 = rf(ctx, query, page, limit)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, int, int) error); ok {
+		r2 = rf(ctx, query, page, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Update provides a mock function with given fields: ctx, actor, id, change
+func (_m *UserService) Update(ctx context.Context, actor models.Actor, id string, change func(user *models.User) error) (models.User, error) {
+	ret := _m.Called(ctx, actor, id, change)
 
 	var r0 models.User
-	if rf, ok := ret.Get(0).(func(string, string) models.User); ok {
-		r0 = rf(key, value)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Actor, string, func(user *models.User) error) models.User); ok {
+		r0 = rf(ctx, actor, id, change)
 	} else {
 		r0 = ret.Get(0).(models.User)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(key, value)
+	if rf, ok := ret.Get(1).(func(context.Context, models.Actor, string, func(user *models.User) error) error); ok {
+		r1 = rf(ctx, actor, id, change)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -78,20 +144,20 @@ func (_m *UserService) GetByCondition(key string, value string) (models.User, er
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: id, userRequest
-func (_m *UserService) Update(id string, userRequest models.User) (models.User, error) {
-	ret := _m.Called(id, userRequest)
+// VerifyEmail provides a mock function with given fields: ctx, actor, tokenHash
+func (_m *UserService) VerifyEmail(ctx context.Context, actor models.Actor, tokenHash string) (models.User, error) {
+	ret := _m.Called(ctx, actor, tokenHash)
 
 	var r0 models.User
-	if rf, ok := ret.Get(0).(func(string, models.User) models.User); ok {
-		r0 = rf(id, userRequest)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Actor, string) models.User); ok {
+		r0 = rf(ctx, actor, tokenHash)
 	} else {
 		r0 = ret.Get(0).(models.User)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, models.User) error); ok {
-		r1 = rf(id, userRequest)
+	if rf, ok := ret.Get(1).(func(context.Context, models.Actor, string) error); ok {
+		r1 = rf(ctx, actor, tokenHash)
 	} else {
 		r1 = ret.Error(1)
 	}
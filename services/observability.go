@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"product/models"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+var tracer = otel.Tracer("product/services")
+
+// logError logs a failed database call and marks the current span as failed.
+// Missing records are expected by the handlers and are not reported.
+func logError(ctx context.Context, logger *slog.Logger, msg string, err error, attrs ...any) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return
+	}
+
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, msg)
+
+	if spanContext := span.SpanContext(); spanContext.IsValid() {
+		attrs = append(attrs, "trace_id", spanContext.TraceID().String())
+	}
+
+	logger.ErrorContext(ctx, msg, append(attrs, "error", err)...)
+}
+
+func actorAttrs(actor models.Actor, attrs ...any) []any {
+	return append([]any{"request_id", actor.RequestID, "actor_id", actor.ID}, attrs...)
+}
@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"product/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// outboxLock names the MySQL lock held by the relay of one instance at a
+// time, so that messages are relayed in order.
+const outboxLock = "product_outbox_relay"
+
+// ErrOutboxStatus rejects a retry or discard of a message in the wrong
+// status.
+var ErrOutboxStatus = errors.New("outbox message cannot be changed in its status")
+
+type OutboxService interface {
+	Search(ctx context.Context, filter models.OutboxFilter, page int, limit int) ([]models.OutboxMessage, int64, error)
+	GetStats(ctx context.Context) (models.OutboxStats, error)
+	Retry(ctx context.Context, id string) (models.OutboxMessage, error)
+	Discard(ctx context.Context, id string) (models.OutboxMessage, error)
+	WithRelayLock(ctx context.Context, fn func(ctx context.Context) error) (bool, error)
+	GetPending(ctx context.Context, limit int) ([]models.OutboxMessage, error)
+	Update(ctx context.Context, message models.OutboxMessage) error
+	MarkPublished(ctx context.Context, message models.OutboxMessage) error
+	DeletePublished(ctx context.Context, before time.Time) error
+}
+
+func NewOutboxService(gormDB *gorm.DB, logger *slog.Logger) OutboxService {
+	return &OutboxServiceImpl{
+		db:     gormDB,
+		logger: logger,
+	}
+}
+
+type OutboxServiceImpl struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func (ob *OutboxServiceImpl) Search(ctx context.Context, filter models.OutboxFilter, page int, limit int) ([]models.OutboxMessage, int64, error) {
+	ctx, span := tracer.Start(ctx, "OutboxService.Search")
+	defer span.End()
+
+	var messages []models.OutboxMessage
+	var total int64
+
+	rec := ob.db.WithContext(ctx).Model(&models.OutboxMessage{})
+
+	if filter.Status != "" {
+		rec = rec.Where("status = ?", filter.Status)
+	}
+
+	if filter.Aggregate != "" {
+		rec = rec.Where("aggregate = ?", filter.Aggregate)
+	}
+
+	if filter.AggregateID != 0 {
+		rec = rec.Where("aggregate_id = ?", filter.AggregateID)
+	}
+
+	if err := rec.Count(&total).Error; err != nil {
+		logError(ctx, ob.logger, "failed to count outbox messages", err)
+		return nil, 0, err
+	}
+
+	if err := rec.Order("id").Offset((page - 1) * limit).Limit(limit).Find(&messages).Error; err != nil {
+		logError(ctx, ob.logger, "failed to search outbox messages", err)
+		return nil, 0, err
+	}
+
+	return messages, total, nil
+}
+
+func (ob *OutboxServiceImpl) GetStats(ctx context.Context) (models.OutboxStats, error) {
+	ctx, span := tracer.Start(ctx, "OutboxService.GetStats")
+	defer span.End()
+
+	var stats models.OutboxStats
+	var counts []struct {
+		Status string
+		Count  int64
+	}
+
+	db := ob.db.WithContext(ctx).Model(&models.OutboxMessage{})
+
+	if err := db.Select("status, COUNT(*) AS count").Group("status").Scan(&counts).Error; err != nil {
+		logError(ctx, ob.logger, "failed to count outbox messages", err)
+		return stats, err
+	}
+
+	for _, count := range counts {
+		switch count.Status {
+		case models.OutboxPending:
+			stats.Pending = count.Count
+		case models.OutboxFailed:
+			stats.Failed = count.Count
+		case models.OutboxPublished:
+			stats.Published = count.Count
+		case models.OutboxDiscarded:
+			stats.Discarded = count.Count
+		}
+	}
+
+	pending := ob.db.WithContext(ctx).Model(&models.OutboxMessage{}).Where("status = ?", models.OutboxPending)
+
+	if err := pending.Where("attempts > 0").Count(&stats.Retrying).Error; err != nil {
+		logError(ctx, ob.logger, "failed to count retried outbox messages", err)
+		return stats, err
+	}
+
+	var oldest models.OutboxMessage
+
+	err := ob.db.WithContext(ctx).Where("status = ?", models.OutboxPending).Order("id").Limit(1).Find(&oldest).Error
+
+	if err != nil {
+		logError(ctx, ob.logger, "failed to get the oldest outbox message", err)
+		return stats, err
+	}
+
+	if oldest.ID != 0 {
+		stats.OldestPendingAt = &oldest.CreatedAt
+	}
+
+	return stats, nil
+}
+
+// Retry puts a failed message back in the queue with fresh attempts.
+func (ob *OutboxServiceImpl) Retry(ctx context.Context, id string) (models.OutboxMessage, error) {
+	ctx, span := tracer.Start(ctx, "OutboxService.Retry")
+	defer span.End()
+
+	return ob.transition(ctx, id, models.OutboxFailed, func(message *models.OutboxMessage) {
+		message.Status = models.OutboxPending
+		message.Attempts = 0
+		message.NextAttemptAt = time.Now()
+	})
+}
+
+// Discard gives up on a pending or failed message, which lets the later
+// messages of its aggregate through.
+func (ob *OutboxServiceImpl) Discard(ctx context.Context, id string) (models.OutboxMessage, error) {
+	ctx, span := tracer.Start(ctx, "OutboxService.Discard")
+	defer span.End()
+
+	return ob.transition(ctx, id, "", func(message *models.OutboxMessage) {
+		message.Status = models.OutboxDiscarded
+	})
+}
+
+// transition changes a message found in status from, or in any unpublished
+// status when from is empty.
+func (ob *OutboxServiceImpl) transition(ctx context.Context, id string, from string, change func(message *models.OutboxMessage)) (models.OutboxMessage, error) {
+	var message models.OutboxMessage
+
+	err := ob.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&message, "id = ?", id).Error; err != nil {
+			return err
+		}
+
+		unpublished := message.Status == models.OutboxPending || message.Status == models.OutboxFailed
+
+		if from != "" && message.Status != from || from == "" && !unpublished {
+			return ErrOutboxStatus
+		}
+
+		change(&message)
+
+		return tx.Save(&message).Error
+	})
+
+	if err != nil {
+		logError(ctx, ob.logger, "failed to change outbox message", err, "message_id", id)
+		return models.OutboxMessage{}, err
+	}
+
+	return message, nil
+}
+
+// WithRelayLock runs fn while holding the relay lock, and reports false
+// without running it when another instance holds the lock.
+func (ob *OutboxServiceImpl) WithRelayLock(ctx context.Context, fn func(ctx context.Context) error) (bool, error) {
+	ctx, span := tracer.Start(ctx, "OutboxService.WithRelayLock")
+	defer span.End()
+
+	return withLock(ctx, ob.db, outboxLock, fn)
+}
+
+// withLock runs fn while holding the MySQL lock name, and reports false
+// without running it when another connection holds the lock. The lock
+// belongs to a database connection, which is set aside for the duration of
+// fn.
+func withLock(ctx context.Context, db *gorm.DB, name string, fn func(ctx context.Context) error) (bool, error) {
+	locked := false
+
+	err := db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		var acquired int
+
+		if err := conn.Raw("SELECT GET_LOCK(?, 0)", name).Scan(&acquired).Error; err != nil {
+			return err
+		}
+
+		if acquired != 1 {
+			return nil
+		}
+
+		locked = true
+
+		defer conn.Exec("SELECT RELEASE_LOCK(?)", name)
+
+		return fn(ctx)
+	})
+
+	return locked, err
+}
+
+// GetPending returns the oldest pending messages, leaving out the aggregates
+// held back by a failed message.
+func (ob *OutboxServiceImpl) GetPending(ctx context.Context, limit int) ([]models.OutboxMessage, error) {
+	ctx, span := tracer.Start(ctx, "OutboxService.GetPending")
+	defer span.End()
+
+	var messages []models.OutboxMessage
+
+	failed := ob.db.Model(&models.OutboxMessage{}).Select("1").
+		Where("failed.aggregate = outbox_messages.aggregate AND failed.aggregate_id = outbox_messages.aggregate_id AND failed.status = ?", models.OutboxFailed)
+
+	err := ob.db.WithContext(ctx).
+		Where("status = ?", models.OutboxPending).
+		Where("NOT EXISTS (?)", failed.Table("outbox_messages AS failed")).
+		Order("id").Limit(limit).Find(&messages).Error
+
+	if err != nil {
+		logError(ctx, ob.logger, "failed to get pending outbox messages", err)
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (ob *OutboxServiceImpl) Update(ctx context.Context, message models.OutboxMessage) error {
+	ctx, span := tracer.Start(ctx, "OutboxService.Update")
+	defer span.End()
+
+	if err := ob.db.WithContext(ctx).Save(&message).Error; err != nil {
+		logError(ctx, ob.logger, "failed to update outbox message", err, "message_id", message.ID)
+		return err
+	}
+
+	return nil
+}
+
+// MarkPublished saves a message relayed to the sink and, in the same
+// transaction, queues it for the webhooks subscribed to its type, so that
+// webhooks receive every published event whatever the sink. A message
+// published again after a failed save is only queued once per webhook.
+func (ob *OutboxServiceImpl) MarkPublished(ctx context.Context, message models.OutboxMessage) error {
+	ctx, span := tracer.Start(ctx, "OutboxService.MarkPublished")
+	defer span.End()
+
+	err := ob.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&message).Error; err != nil {
+			return err
+		}
+
+		webhooks, err := subscribedWebhooks(tx, message.Type)
+
+		if err != nil || len(webhooks) == 0 {
+			return err
+		}
+
+		queued := make([]models.WebhookMessage, len(webhooks))
+
+		for i, webhook := range webhooks {
+			queued[i] = models.WebhookMessage{
+				WebhookID:     webhook.ID,
+				EventID:       message.EventID,
+				Type:          message.Type,
+				Aggregate:     message.Aggregate,
+				AggregateID:   message.AggregateID,
+				Payload:       message.Payload,
+				NextAttemptAt: time.Now(),
+			}
+		}
+
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&queued).Error
+	})
+
+	if err != nil {
+		logError(ctx, ob.logger, "failed to mark outbox message published", err, "message_id", message.ID)
+	}
+
+	return err
+}
+
+// DeletePublished removes the messages published before a time.
+func (ob *OutboxServiceImpl) DeletePublished(ctx context.Context, before time.Time) error {
+	ctx, span := tracer.Start(ctx, "OutboxService.DeletePublished")
+	defer span.End()
+
+	err := ob.db.WithContext(ctx).Where("status = ? AND published_at < ?", models.OutboxPublished, before).Delete(&models.OutboxMessage{}).Error
+
+	if err != nil {
+		logError(ctx, ob.logger, "failed to delete published outbox messages", err)
+	}
+
+	return err
+}
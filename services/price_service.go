@@ -0,0 +1,379 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"product/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrScheduleConflict rejects a schedule overlapping another pending or
+	// active schedule of the product.
+	ErrScheduleConflict = errors.New("price schedule overlaps another schedule of the product")
+	// ErrScheduleStatus rejects the cancellation of a schedule that has
+	// already started.
+	ErrScheduleStatus = errors.New("only scheduled price changes can be cancelled")
+	// ErrNoPrice is returned for a time before the product was created.
+	ErrNoPrice = errors.New("product did not exist at that time")
+)
+
+type PriceService interface {
+	GetHistory(ctx context.Context, productID uint, page int, limit int) ([]models.PriceChange, int64, error)
+	GetPriceAt(ctx context.Context, product models.Product, at time.Time) (int, error)
+	GetSchedules(ctx context.Context, productID uint) ([]models.PriceSchedule, error)
+	CreateSchedule(ctx context.Context, actor models.Actor, schedule models.PriceSchedule) (models.PriceSchedule, error)
+	CancelSchedule(ctx context.Context, actor models.Actor, productID uint, id string) (models.PriceSchedule, error)
+	ApplyDue(ctx context.Context, now time.Time) (int, error)
+}
+
+// NewPriceService raises stock.low like ProductService when it changes a
+// product, which only happens to prices.
+func NewPriceService(gormDB *gorm.DB, logger *slog.Logger, lowStock int) PriceService {
+	return &PriceServiceImpl{
+		db:       gormDB,
+		logger:   logger,
+		lowStock: lowStock,
+	}
+}
+
+type PriceServiceImpl struct {
+	db       *gorm.DB
+	logger   *slog.Logger
+	lowStock int
+}
+
+// GetHistory pages through the price changes of a product, latest first.
+func (ps *PriceServiceImpl) GetHistory(ctx context.Context, productID uint, page int, limit int) ([]models.PriceChange, int64, error) {
+	ctx, span := tracer.Start(ctx, "PriceService.GetHistory")
+	defer span.End()
+
+	var changes []models.PriceChange
+	var total int64
+
+	rec := ps.db.WithContext(ctx).Model(&models.PriceChange{}).Where("product_id = ?", productID)
+
+	if err := rec.Count(&total).Error; err != nil {
+		logError(ctx, ps.logger, "failed to count price changes", err, "product_id", productID)
+		return nil, 0, err
+	}
+
+	if err := rec.Order("changed_at DESC, id DESC").Offset((page - 1) * limit).Limit(limit).Find(&changes).Error; err != nil {
+		logError(ctx, ps.logger, "failed to get price history", err, "product_id", productID)
+		return nil, 0, err
+	}
+
+	return changes, total, nil
+}
+
+// GetPriceAt returns the price of product at a time. Past prices come from
+// the history, future ones from the schedules as planned.
+func (ps *PriceServiceImpl) GetPriceAt(ctx context.Context, product models.Product, at time.Time) (int, error) {
+	ctx, span := tracer.Start(ctx, "PriceService.GetPriceAt")
+	defer span.End()
+
+	var price int
+	var err error
+
+	if at.After(time.Now()) {
+		price, err = ps.plannedPrice(ctx, product, at)
+	} else {
+		price, err = ps.pastPrice(ctx, product, at)
+	}
+
+	if err != nil && !errors.Is(err, ErrNoPrice) {
+		logError(ctx, ps.logger, "failed to get price", err, "product_id", product.ID)
+	}
+
+	return price, err
+}
+
+func (ps *PriceServiceImpl) pastPrice(ctx context.Context, product models.Product, at time.Time) (int, error) {
+	db := ps.db.WithContext(ctx).Where("product_id = ?", product.ID)
+
+	var change models.PriceChange
+
+	rec := db.Where("changed_at <= ?", at).Order("changed_at DESC, id DESC").Limit(1).Find(&change)
+
+	if rec.Error != nil || rec.RowsAffected == 1 {
+		return change.Price, rec.Error
+	}
+
+	// Before the first change the price is the one that change replaced.
+	// Products older than the history have kept their price all along.
+	rec = ps.db.WithContext(ctx).Where("product_id = ?", product.ID).Order("changed_at, id").Limit(1).Find(&change)
+
+	switch {
+	case rec.Error != nil:
+		return 0, rec.Error
+	case rec.RowsAffected == 0:
+		return product.Price, nil
+	case change.Source == models.PriceCreated:
+		return 0, ErrNoPrice
+	default:
+		return change.OldPrice, nil
+	}
+}
+
+// plannedPrice applies the schedules of product up to at to its current
+// price.
+func (ps *PriceServiceImpl) plannedPrice(ctx context.Context, product models.Product, at time.Time) (int, error) {
+	var schedules []models.PriceSchedule
+
+	err := ps.db.WithContext(ctx).
+		Where("product_id = ? AND status IN ? AND starts_at <= ?", product.ID, []string{models.ScheduleScheduled, models.ScheduleActive}, at).
+		Order("starts_at").Find(&schedules).Error
+
+	if err != nil {
+		return 0, err
+	}
+
+	price := product.Price
+
+	for _, schedule := range schedules {
+		switch {
+		case schedule.EndsAt == nil || at.Before(*schedule.EndsAt):
+			price = schedule.Price
+		case schedule.Status == models.ScheduleActive:
+			price = schedule.PreviousPrice
+		}
+	}
+
+	return price, nil
+}
+
+func (ps *PriceServiceImpl) GetSchedules(ctx context.Context, productID uint) ([]models.PriceSchedule, error) {
+	ctx, span := tracer.Start(ctx, "PriceService.GetSchedules")
+	defer span.End()
+
+	var schedules []models.PriceSchedule
+
+	if err := ps.db.WithContext(ctx).Where("product_id = ?", productID).Order("starts_at, id").Find(&schedules).Error; err != nil {
+		logError(ctx, ps.logger, "failed to get price schedules", err, "product_id", productID)
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+func (ps *PriceServiceImpl) CreateSchedule(ctx context.Context, actor models.Actor, schedule models.PriceSchedule) (models.PriceSchedule, error) {
+	ctx, span := tracer.Start(ctx, "PriceService.CreateSchedule")
+	defer span.End()
+
+	schedule.Status = models.ScheduleScheduled
+	schedule.CreatedByID = actor.ID
+	schedule.CreatedByEmail = actor.Email
+
+	err := ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Locking the product serializes the schedules created for it.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Product{}, schedule.ProductID).Error; err != nil {
+			return err
+		}
+
+		var pending []models.PriceSchedule
+
+		err := tx.Where("product_id = ? AND status IN ?", schedule.ProductID, []string{models.ScheduleScheduled, models.ScheduleActive}).Find(&pending).Error
+
+		if err != nil {
+			return err
+		}
+
+		for _, other := range pending {
+			if schedule.Overlaps(other) {
+				return ErrScheduleConflict
+			}
+		}
+
+		if err := tx.Create(&schedule).Error; err != nil {
+			return err
+		}
+
+		return recordAudit(tx, actor, models.AuditCreate, models.EntityPriceSchedule, schedule.ID, nil, schedule)
+	})
+
+	if err != nil {
+		logError(ctx, ps.logger, "failed to create price schedule", err, actorAttrs(actor, "product_id", schedule.ProductID)...)
+		return models.PriceSchedule{}, err
+	}
+
+	return schedule, nil
+}
+
+func (ps *PriceServiceImpl) CancelSchedule(ctx context.Context, actor models.Actor, productID uint, id string) (models.PriceSchedule, error) {
+	ctx, span := tracer.Start(ctx, "PriceService.CancelSchedule")
+	defer span.End()
+
+	var schedule models.PriceSchedule
+
+	err := ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&schedule, "id = ? AND product_id = ?", id, productID).Error
+
+		if err != nil {
+			return err
+		}
+
+		if schedule.Status != models.ScheduleScheduled {
+			return ErrScheduleStatus
+		}
+
+		before := schedule
+		schedule.Status = models.ScheduleCancelled
+
+		if err := tx.Save(&schedule).Error; err != nil {
+			return err
+		}
+
+		return recordAudit(tx, actor, models.AuditUpdate, models.EntityPriceSchedule, schedule.ID, before, schedule)
+	})
+
+	if err != nil {
+		logError(ctx, ps.logger, "failed to cancel price schedule", err, actorAttrs(actor, "schedule_id", id)...)
+		return models.PriceSchedule{}, err
+	}
+
+	return schedule, nil
+}
+
+// ApplyDue starts and ends the schedules due at now, oldest first, and
+// returns how many it applied. Each is applied in its own transaction,
+// skipping the ones another instance is applying.
+func (ps *PriceServiceImpl) ApplyDue(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := tracer.Start(ctx, "PriceService.ApplyDue")
+	defer span.End()
+
+	applied := 0
+
+	for {
+		found, err := ps.applyNext(ctx, now)
+
+		if err != nil {
+			logError(ctx, ps.logger, "failed to apply price schedule", err)
+			return applied, err
+		}
+
+		if !found {
+			return applied, nil
+		}
+
+		applied++
+	}
+}
+
+// applyNext applies the next due schedule, and reports false when there is
+// none.
+func (ps *PriceServiceImpl) applyNext(ctx context.Context, now time.Time) (bool, error) {
+	found := false
+
+	err := ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var schedule models.PriceSchedule
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND starts_at <= ?) OR (status = ? AND ends_at <= ?)", models.ScheduleScheduled, now, models.ScheduleActive, now).
+			Clauses(clause.OrderBy{Expression: clause.Expr{
+				SQL:                "CASE WHEN status = ? THEN ends_at ELSE starts_at END, id",
+				Vars:               []any{models.ScheduleActive},
+				WithoutParentheses: true,
+			}}).
+			Limit(1).Find(&schedule).Error
+
+		if err != nil || schedule.ID == 0 {
+			return err
+		}
+
+		found = true
+
+		var before models.Product
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Limit(1).Find(&before, schedule.ProductID).Error; err != nil {
+			return err
+		}
+
+		// The product has been deleted since.
+		if before.ID == 0 {
+			schedule.Status = models.ScheduleCancelled
+			return tx.Save(&schedule).Error
+		}
+
+		product := before
+
+		switch {
+		case schedule.Status == models.ScheduleScheduled && schedule.EndsAt != nil:
+			schedule.Status = models.ScheduleActive
+			schedule.PreviousPrice = before.Price
+			product.Price = schedule.Price
+		case schedule.Status == models.ScheduleScheduled:
+			schedule.Status = models.ScheduleCompleted
+			schedule.PreviousPrice = before.Price
+			product.Price = schedule.Price
+		default:
+			// The end of a promotion leaves alone a price changed since its
+			// start.
+			schedule.Status = models.ScheduleCompleted
+
+			if before.Price == schedule.Price {
+				product.Price = schedule.PreviousPrice
+			}
+		}
+
+		if err := tx.Save(&schedule).Error; err != nil {
+			return err
+		}
+
+		if product.Price == before.Price {
+			return nil
+		}
+
+		if err := tx.Save(&product).Error; err != nil {
+			return err
+		}
+
+		actor := schedule.Actor()
+
+		if err := recordAudit(tx, actor, models.AuditUpdate, models.EntityProduct, product.ID, before, product); err != nil {
+			return err
+		}
+
+		if err := recordPriceChange(tx, actor, &before, product, &schedule.ID); err != nil {
+			return err
+		}
+
+		return recordProductEvents(tx, ps.lowStock, &before, &product)
+	})
+
+	return found, err
+}
+
+// recordPriceChange adds the price of after to the price history when it
+// differs from the price of before, which is nil for creates. Like
+// recordAudit it has to be called inside the transaction of the change.
+// scheduleID is set when a schedule made the change.
+func recordPriceChange(tx *gorm.DB, actor models.Actor, before *models.Product, after models.Product, scheduleID *uint) error {
+	change := models.PriceChange{
+		ProductID:  after.ID,
+		Price:      after.Price,
+		Source:     models.PriceCreated,
+		ScheduleID: scheduleID,
+		ActorID:    actor.ID,
+		ActorEmail: actor.Email,
+		ChangedAt:  time.Now(),
+	}
+
+	if before != nil {
+		if before.Price == after.Price {
+			return nil
+		}
+
+		change.OldPrice = before.Price
+		change.Source = models.PriceUpdated
+
+		if scheduleID != nil {
+			change.Source = models.PriceScheduled
+		}
+	}
+
+	return tx.Create(&change).Error
+}
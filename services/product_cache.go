@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"product/cache"
+	"product/models"
+	"product/money"
+	"strings"
+	"time"
+)
+
+// productPage is a page of Search as cached.
+type productPage struct {
+	Products []models.Product
+	Total    int64
+}
+
+// CachedProductService serves the product listings from productCache and
+// invalidates it after every write, whether it succeeded or not.
+type CachedProductService struct {
+	ProductService
+	cache *cache.Cache
+}
+
+func NewCachedProductService(productService ProductService, productCache *cache.Cache) ProductService {
+	return &CachedProductService{
+		ProductService: productService,
+		cache:          productCache,
+	}
+}
+
+func (cs *CachedProductService) GetAll(ctx context.Context) ([]models.Product, error) {
+	return cache.Fetch(ctx, cs.cache, "all", cs.ProductService.GetAll)
+}
+
+func (cs *CachedProductService) Search(ctx context.Context, query string, page int, limit int) ([]models.Product, int64, error) {
+	key := fmt.Sprintf("search:%d:%d:%s", page, limit, query)
+
+	result, err := cache.Fetch(ctx, cs.cache, key, func(ctx context.Context) (productPage, error) {
+		products, total, err := cs.ProductService.Search(ctx, query, page, limit)
+
+		return productPage{Products: products, Total: total}, err
+	})
+
+	return result.Products, result.Total, err
+}
+
+func (cs *CachedProductService) Create(ctx context.Context, actor models.Actor, productRequest models.Product) (models.Product, error) {
+	defer cs.cache.Invalidate(ctx)
+
+	return cs.ProductService.Create(ctx, actor, productRequest)
+}
+
+func (cs *CachedProductService) Update(ctx context.Context, actor models.Actor, id string, productRequest models.ProductRequest) (models.Product, error) {
+	defer cs.cache.Invalidate(ctx)
+
+	return cs.ProductService.Update(ctx, actor, id, productRequest)
+}
+
+func (cs *CachedProductService) Delete(ctx context.Context, actor models.Actor, product models.Product) error {
+	defer cs.cache.Invalidate(ctx)
+
+	return cs.ProductService.Delete(ctx, actor, product)
+}
+
+func (cs *CachedProductService) Batch(ctx context.Context, actor models.Actor, operations []models.BatchOperation, mode string) (models.BatchReport, error) {
+	defer cs.cache.Invalidate(ctx)
+
+	return cs.ProductService.Batch(ctx, actor, operations, mode)
+}
+
+// CachedProductImportService invalidates productCache after every import
+// that is not a dry run.
+type CachedProductImportService struct {
+	ProductImportService
+	cache *cache.Cache
+}
+
+func NewCachedProductImportService(importService ProductImportService, productCache *cache.Cache) ProductImportService {
+	return &CachedProductImportService{
+		ProductImportService: importService,
+		cache:                productCache,
+	}
+}
+
+func (cs *CachedProductImportService) Import(ctx context.Context, actor models.Actor, rows models.ImportSource, options models.ImportOptions, progress func(processed int)) (models.ImportReport, error) {
+	if !options.DryRun {
+		defer cs.cache.Invalidate(ctx)
+	}
+
+	return cs.ProductImportService.Import(ctx, actor, rows, options, progress)
+}
+
+// CachedPriceService invalidates productCache after applying scheduled
+// prices.
+type CachedPriceService struct {
+	PriceService
+	cache *cache.Cache
+}
+
+func NewCachedPriceService(priceService PriceService, productCache *cache.Cache) PriceService {
+	return &CachedPriceService{
+		PriceService: priceService,
+		cache:        productCache,
+	}
+}
+
+func (cs *CachedPriceService) ApplyDue(ctx context.Context, now time.Time) (int, error) {
+	applied, err := cs.PriceService.ApplyDue(ctx, now)
+
+	if applied > 0 {
+		cs.cache.Invalidate(ctx)
+	}
+
+	return applied, err
+}
+
+// CachedUserService invalidates productCache after deleting a user, which
+// hands their products over to another owner.
+type CachedUserService struct {
+	UserService
+	cache *cache.Cache
+}
+
+func NewCachedUserService(userService UserService, productCache *cache.Cache) UserService {
+	return &CachedUserService{
+		UserService: userService,
+		cache:       productCache,
+	}
+}
+
+func (cs *CachedUserService) Delete(ctx context.Context, actor models.Actor, user models.User, reassignTo uint) error {
+	defer cs.cache.Invalidate(ctx)
+
+	return cs.UserService.Delete(ctx, actor, user, reassignTo)
+}
+
+// CachedCurrencyService serves the conversions of the listed prices from
+// productCache and invalidates it after every change of the exchange rates
+// or the price lists.
+type CachedCurrencyService struct {
+	CurrencyService
+	cache *cache.Cache
+}
+
+func NewCachedCurrencyService(currencyService CurrencyService, productCache *cache.Cache) CurrencyService {
+	return &CachedCurrencyService{
+		CurrencyService: currencyService,
+		cache:           productCache,
+	}
+}
+
+// Convert keys the conversions by the prices converted as well as the
+// products, which the listings are cached by already.
+func (cs *CachedCurrencyService) Convert(ctx context.Context, currency money.Currency, products []models.Product) ([]money.Money, error) {
+	var key strings.Builder
+
+	key.WriteString("convert:" + currency.Code)
+
+	for _, product := range products {
+		fmt.Fprintf(&key, ":%d=%d", product.ID, product.Price)
+	}
+
+	return cache.Fetch(ctx, cs.cache, key.String(), func(ctx context.Context) ([]money.Money, error) {
+		return cs.CurrencyService.Convert(ctx, currency, products)
+	})
+}
+
+func (cs *CachedCurrencyService) SetRate(ctx context.Context, actor models.Actor, rate models.ExchangeRate) (models.ExchangeRate, error) {
+	defer cs.cache.Invalidate(ctx)
+
+	return cs.CurrencyService.SetRate(ctx, actor, rate)
+}
+
+func (cs *CachedCurrencyService) DeleteRate(ctx context.Context, actor models.Actor, currency string) error {
+	defer cs.cache.Invalidate(ctx)
+
+	return cs.CurrencyService.DeleteRate(ctx, actor, currency)
+}
+
+func (cs *CachedCurrencyService) SetPrice(ctx context.Context, actor models.Actor, price models.ProductPrice) (models.ProductPrice, error) {
+	defer cs.cache.Invalidate(ctx)
+
+	return cs.CurrencyService.SetPrice(ctx, actor, price)
+}
+
+func (cs *CachedCurrencyService) DeletePrice(ctx context.Context, actor models.Actor, productID uint, currency string) error {
+	defer cs.cache.Invalidate(ctx)
+
+	return cs.CurrencyService.DeletePrice(ctx, actor, productID, currency)
+}
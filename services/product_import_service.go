@@ -0,0 +1,396 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"product/models"
+	"product/money"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// skuBatchSize is the number of SKUs looked up per query.
+const skuBatchSize = 500
+
+type ProductImportService interface {
+	Import(ctx context.Context, actor models.Actor, rows models.ImportSource, options models.ImportOptions, progress func(processed int)) (models.ImportReport, error)
+	CreateJob(ctx context.Context, job models.ImportJob) (models.ImportJob, error)
+	UpdateJob(ctx context.Context, job models.ImportJob) error
+	GetJob(ctx context.Context, id string) (models.ImportJob, error)
+}
+
+// NewProductImportService raises the events of the imported products and
+// stores their prices in currency as ProductService does.
+func NewProductImportService(gormDB *gorm.DB, logger *slog.Logger, lowStock int, currency money.Currency) ProductImportService {
+	return &ProductImportServiceImpl{
+		db:       gormDB,
+		logger:   logger,
+		lowStock: lowStock,
+		currency: currency,
+	}
+}
+
+type ProductImportServiceImpl struct {
+	db       *gorm.DB
+	logger   *slog.Logger
+	lowStock int
+	currency money.Currency
+}
+
+// Import creates the rows without a known SKU and updates the others, owned
+// by the actor. Invalid rows are reported; in all_or_nothing mode they stop
+// the whole import, as does any failure to save. A dry run only reports.
+// rows is read once to check every row, then again to save the valid ones
+// skuBatchSize at a time, so they are never all held in memory. progress is
+// called with the number of rows handled so far.
+func (is *ProductImportServiceImpl) Import(ctx context.Context, actor models.Actor, rows models.ImportSource, options models.ImportOptions, progress func(processed int)) (models.ImportReport, error) {
+	ctx, span := tracer.Start(ctx, "ProductImportService.Import")
+	defer span.End()
+
+	report := models.ImportReport{
+		Mode:   options.Mode,
+		DryRun: options.DryRun,
+		Errors: []models.ImportRowError{},
+	}
+
+	checker := newImportChecker()
+
+	err := rows.Each(func(row models.ImportRow) error {
+		report.Total++
+
+		if message := checker.check(row); message != "" {
+			report.AddError(row.Line, row.Request.SKU, message)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		logError(ctx, is.logger, "failed to read imported products", err, actorAttrs(actor)...)
+		return report, err
+	}
+
+	processed := report.Failed
+	progress(processed)
+
+	if options.Mode == models.ImportAllOrNothing && report.Failed > 0 {
+		return report, nil
+	}
+
+	if options.DryRun {
+		err := eachValidChunk(rows, func(chunk []models.ImportRow) error {
+			existing, err := findBySKU(is.db.WithContext(ctx), chunk)
+
+			if err != nil {
+				return err
+			}
+
+			for _, row := range chunk {
+				if _, ok := existing[row.Request.SKU]; ok && row.Request.SKU != "" {
+					report.Updated++
+				} else {
+					report.Created++
+				}
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			logError(ctx, is.logger, "failed to look up imported products", err, actorAttrs(actor)...)
+			return report, err
+		}
+
+		progress(report.Total)
+
+		return report, nil
+	}
+
+	if options.Mode == models.ImportAllOrNothing {
+		err := is.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return eachValidChunk(rows, func(chunk []models.ImportRow) error {
+				existing, err := findBySKU(tx.Clauses(clause.Locking{Strength: "UPDATE"}), chunk)
+
+				if err != nil {
+					return err
+				}
+
+				for _, row := range chunk {
+					if err := upsertImportRow(tx, actor, row, existing, is.lowStock, is.currency, &report); err != nil {
+						return fmt.Errorf("line %d: %w", row.Line, err)
+					}
+
+					processed++
+					progress(processed)
+				}
+
+				return nil
+			})
+		})
+
+		if err != nil {
+			report.Created, report.Updated = 0, 0
+			logError(ctx, is.logger, "failed to import products", err, actorAttrs(actor)...)
+
+			return report, err
+		}
+
+		return report, nil
+	}
+
+	err = eachValidChunk(rows, func(chunk []models.ImportRow) error {
+		for _, row := range chunk {
+			// The product is read again and locked in the transaction that
+			// saves it, so a concurrent change is neither lost nor
+			// misrecorded.
+			err := is.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				existing, err := findBySKU(tx.Clauses(clause.Locking{Strength: "UPDATE"}), []models.ImportRow{row})
+
+				if err != nil {
+					return err
+				}
+
+				return upsertImportRow(tx, actor, row, existing, is.lowStock, is.currency, &report)
+			})
+
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return err
+			}
+
+			if err != nil {
+				logError(ctx, is.logger, "failed to import product", err, actorAttrs(actor, "line", row.Line)...)
+				report.AddError(row.Line, row.Request.SKU, "failed to save product")
+			}
+
+			processed++
+			progress(processed)
+		}
+
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		logError(ctx, is.logger, "failed to read imported products", err, actorAttrs(actor)...)
+	}
+
+	return report, err
+}
+
+// importChecker applies the rules of ProductRequest.Validate and rejects
+// SKUs repeated in the file. Only the SKUs seen so far are kept.
+type importChecker struct {
+	seen map[string]int
+}
+
+func newImportChecker() *importChecker {
+	return &importChecker{
+		seen: map[string]int{},
+	}
+}
+
+// check returns why row is invalid, or "" when it is valid.
+func (ic *importChecker) check(row models.ImportRow) string {
+	if row.Error != "" {
+		return row.Error
+	}
+
+	if err := row.Request.Validate(); err != nil {
+		return validationMessage(err)
+	}
+
+	if sku := row.Request.SKU; sku != "" {
+		if line, ok := ic.seen[sku]; ok {
+			return fmt.Sprintf("sku already used on line %d", line)
+		}
+
+		ic.seen[sku] = row.Line
+	}
+
+	return ""
+}
+
+// eachValidChunk reads rows again and passes their valid rows to fn,
+// skuBatchSize at a time.
+func eachValidChunk(rows models.ImportSource, fn func(chunk []models.ImportRow) error) error {
+	checker := newImportChecker()
+	chunk := make([]models.ImportRow, 0, skuBatchSize)
+
+	err := rows.Each(func(row models.ImportRow) error {
+		if checker.check(row) != "" {
+			return nil
+		}
+
+		chunk = append(chunk, row)
+
+		if len(chunk) < skuBatchSize {
+			return nil
+		}
+
+		err := fn(chunk)
+		chunk = chunk[:0]
+
+		return err
+	})
+
+	if err != nil || len(chunk) == 0 {
+		return err
+	}
+
+	return fn(chunk)
+}
+
+// validationMessage describes the rules a request broke.
+func validationMessage(err error) string {
+	var validationErrors validator.ValidationErrors
+
+	if !errors.As(err, &validationErrors) {
+		return err.Error()
+	}
+
+	var messages []string
+
+	for _, fieldError := range validationErrors {
+		field := strings.ToLower(fieldError.Field())
+
+		switch fieldError.Tag() {
+		case "required":
+			messages = append(messages, field+" is required")
+		case "max":
+			messages = append(messages, field+" must be at most "+fieldError.Param()+" characters")
+		default:
+			messages = append(messages, field+" is invalid")
+		}
+	}
+
+	return strings.Join(messages, ", ")
+}
+
+// findBySKU looks up the products with the SKUs of rows, which are at most
+// skuBatchSize. Saving imports pass db with a locking clause, so the products
+// stay as read until their transaction ends.
+func findBySKU(db *gorm.DB, rows []models.ImportRow) (map[string]models.Product, error) {
+	existing := map[string]models.Product{}
+
+	var skus []string
+
+	for _, row := range rows {
+		if row.Request.SKU != "" {
+			skus = append(skus, row.Request.SKU)
+		}
+	}
+
+	if len(skus) == 0 {
+		return existing, nil
+	}
+
+	var products []models.Product
+
+	if err := db.Where("sku IN ?", skus).Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	for _, product := range products {
+		existing[product.GetSKU()] = product
+	}
+
+	return existing, nil
+}
+
+// upsertImportRow creates the product of row, or applies row to the product
+// with its SKU in existing, which has to be locked by tx.
+func upsertImportRow(tx *gorm.DB, actor models.Actor, row models.ImportRow, existing map[string]models.Product, lowStock int, currency money.Currency, report *models.ImportReport) error {
+	before, ok := existing[row.Request.SKU]
+
+	if !ok || row.Request.SKU == "" {
+		product := row.Request.ConvertToProduct()
+		product.Currency = currency.Code
+		product.UserID = actor.ID
+
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+
+		if err := recordAudit(tx, actor, models.AuditCreate, models.EntityProduct, product.ID, nil, product); err != nil {
+			return err
+		}
+
+		if err := recordPriceChange(tx, actor, nil, product, nil); err != nil {
+			return err
+		}
+
+		if err := recordProductEvents(tx, lowStock, nil, &product); err != nil {
+			return err
+		}
+
+		report.Created++
+
+		return nil
+	}
+
+	product := before
+	row.Request.ApplyTo(&product)
+	product.Currency = currency.Code
+
+	if err := tx.Save(&product).Error; err != nil {
+		return err
+	}
+
+	if err := recordAudit(tx, actor, models.AuditUpdate, models.EntityProduct, product.ID, before, product); err != nil {
+		return err
+	}
+
+	if err := recordPriceChange(tx, actor, &before, product, nil); err != nil {
+		return err
+	}
+
+	if err := recordProductEvents(tx, lowStock, &before, &product); err != nil {
+		return err
+	}
+
+	report.Updated++
+
+	return nil
+}
+
+func (is *ProductImportServiceImpl) CreateJob(ctx context.Context, job models.ImportJob) (models.ImportJob, error) {
+	ctx, span := tracer.Start(ctx, "ProductImportService.CreateJob")
+	defer span.End()
+
+	if err := is.db.WithContext(ctx).Create(&job).Error; err != nil {
+		logError(ctx, is.logger, "failed to create import job", err)
+		return models.ImportJob{}, err
+	}
+
+	return job, nil
+}
+
+func (is *ProductImportServiceImpl) UpdateJob(ctx context.Context, job models.ImportJob) error {
+	ctx, span := tracer.Start(ctx, "ProductImportService.UpdateJob")
+	defer span.End()
+
+	if err := is.db.WithContext(ctx).Save(&job).Error; err != nil {
+		logError(ctx, is.logger, "failed to update import job", err, "job_id", job.ID)
+		return err
+	}
+
+	return nil
+}
+
+func (is *ProductImportServiceImpl) GetJob(ctx context.Context, id string) (models.ImportJob, error) {
+	ctx, span := tracer.Start(ctx, "ProductImportService.GetJob")
+	defer span.End()
+
+	var job models.ImportJob
+
+	if err := is.db.WithContext(ctx).First(&job, "id = ?", id).Error; err != nil {
+		logError(ctx, is.logger, "failed to get import job", err, "job_id", id)
+		return models.ImportJob{}, err
+	}
+
+	return job, nil
+}
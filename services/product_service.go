@@ -1,85 +1,514 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
 	"product/models"
+	"product/money"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// eachBatchSize is the number of products Each loads per query.
+const eachBatchSize = 500
+
 type ProductService interface {
-	GetAll() ([]models.Product, error)
-	GetByCondition(key string, value string) (models.Product, error)
-	Create(productRequest models.Product) (models.Product, error)
-	Update(id string, productRequest models.Product) (models.Product, error)
-	Delete(product models.Product) error
+	GetAll(ctx context.Context) ([]models.Product, error)
+	Search(ctx context.Context, query string, page int, limit int) ([]models.Product, int64, error)
+	Each(ctx context.Context, query string, fn func(models.Product) error) error
+	GetByCondition(ctx context.Context, key string, value string) (models.Product, error)
+	Create(ctx context.Context, actor models.Actor, productRequest models.Product) (models.Product, error)
+	Update(ctx context.Context, actor models.Actor, id string, productRequest models.ProductRequest) (models.Product, error)
+	Delete(ctx context.Context, actor models.Actor, product models.Product) error
+	Batch(ctx context.Context, actor models.Actor, operations []models.BatchOperation, mode string) (models.BatchReport, error)
+	GetStats(ctx context.Context) (models.ProductStats, error)
 }
 
-func NewProductService(gormDB *gorm.DB) ProductService {
+// NewProductService raises stock.low when the stock of a product drops
+// below lowStock, and stores the prices of new products in currency.
+func NewProductService(gormDB *gorm.DB, logger *slog.Logger, lowStock int, currency money.Currency) ProductService {
 	return &ProductServiceImpl{
-		db: gormDB,
+		db:       gormDB,
+		logger:   logger,
+		lowStock: lowStock,
+		currency: currency,
 	}
 }
 
 type ProductServiceImpl struct {
-	db *gorm.DB
+	db       *gorm.DB
+	logger   *slog.Logger
+	lowStock int
+	currency money.Currency
 }
 
-func (ps *ProductServiceImpl) GetAll() ([]models.Product, error) {
+func (ps *ProductServiceImpl) GetAll(ctx context.Context) ([]models.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetAll")
+	defer span.End()
+
 	var products []models.Product
 
-	err := ps.db.Find(&products).Error
+	err := ps.db.WithContext(ctx).Find(&products).Error
 
 	if err != nil {
+		logError(ctx, ps.logger, "failed to get products", err)
 		return nil, err
 	}
 
 	return products, nil
 }
 
-func (ps *ProductServiceImpl) GetByCondition(key string, value string) (models.Product, error) {
+// Search pages through products whose name or description contains query.
+// Page starts at 1.
+func (ps *ProductServiceImpl) Search(ctx context.Context, query string, page int, limit int) ([]models.Product, int64, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.Search")
+	defer span.End()
+
+	var products []models.Product
+	var total int64
+
+	rec := searchProducts(ps.db.WithContext(ctx).Model(&models.Product{}), query)
+
+	if err := rec.Count(&total).Error; err != nil {
+		logError(ctx, ps.logger, "failed to count products", err, "query", query)
+		return nil, 0, err
+	}
+
+	err := rec.Order("id").Offset((page - 1) * limit).Limit(limit).Find(&products).Error
+
+	if err != nil {
+		logError(ctx, ps.logger, "failed to search products", err, "query", query)
+		return nil, 0, err
+	}
+
+	return products, total, nil
+}
+
+// Each calls fn with every product Search would find for query, in ID order.
+// Products are loaded in batches so the catalog is never held in memory at
+// once. It stops at the first error returned by fn.
+func (ps *ProductServiceImpl) Each(ctx context.Context, query string, fn func(models.Product) error) error {
+	ctx, span := tracer.Start(ctx, "ProductService.Each")
+	defer span.End()
+
+	var batch []models.Product
+
+	err := searchProducts(ps.db.WithContext(ctx), query).FindInBatches(&batch, eachBatchSize, func(tx *gorm.DB, _ int) error {
+		for _, product := range batch {
+			if err := fn(product); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}).Error
+
+	if err != nil {
+		logError(ctx, ps.logger, "failed to read products", err, "query", query)
+		return err
+	}
+
+	return nil
+}
+
+func searchProducts(db *gorm.DB, query string) *gorm.DB {
+	if query == "" {
+		return db
+	}
+
+	like := "%" + query + "%"
+
+	return db.Where("name LIKE ? OR description LIKE ?", like, like)
+}
+
+func (ps *ProductServiceImpl) GetByCondition(ctx context.Context, key string, value string) (models.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetByCondition")
+	defer span.End()
+
 	var product models.Product
 
-	err := ps.db.First(&product, key, value).Error
+	err := ps.db.WithContext(ctx).First(&product, key, value).Error
 
 	if err != nil {
+		logError(ctx, ps.logger, "failed to get product", err, "key", key, "value", value)
 		return models.Product{}, err
 	}
 
 	return product, err
 }
 
-func (ps *ProductServiceImpl) Create(productRequest models.Product) (models.Product, error) {
+func (ps *ProductServiceImpl) Create(ctx context.Context, actor models.Actor, product models.Product) (models.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.Create")
+	defer span.End()
+
+	product.Currency = ps.currency.Code
+
+	err := ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+
+		if err := recordAudit(tx, actor, models.AuditCreate, models.EntityProduct, product.ID, nil, product); err != nil {
+			return err
+		}
+
+		if err := recordPriceChange(tx, actor, nil, product, nil); err != nil {
+			return err
+		}
+
+		return recordProductEvents(tx, ps.lowStock, nil, &product)
+	})
+
+	if err != nil {
+		logError(ctx, ps.logger, "failed to create product", err, actorAttrs(actor)...)
+		return models.Product{}, err
+	}
+
+	return product, nil
+}
+
+// Update applies productRequest to the product with id. The product is
+// locked while the request is applied, so a concurrent change such as a
+// scheduled price is neither lost nor misrecorded in the history.
+func (ps *ProductServiceImpl) Update(ctx context.Context, actor models.Actor, id string, productRequest models.ProductRequest) (models.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.Update")
+	defer span.End()
+
 	var product models.Product
 
-	rec := ps.db.Create(&productRequest)
+	err := ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var before models.Product
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&before, "id", id).Error; err != nil {
+			return err
+		}
+
+		product = before
+		productRequest.ApplyTo(&product)
 
-	if rec.Error != nil {
-		return models.Product{}, rec.Error
+		if err := tx.Save(&product).Error; err != nil {
+			return err
+		}
+
+		if err := recordAudit(tx, actor, models.AuditUpdate, models.EntityProduct, product.ID, before, product); err != nil {
+			return err
+		}
+
+		if err := recordPriceChange(tx, actor, &before, product, nil); err != nil {
+			return err
+		}
+
+		return recordProductEvents(tx, ps.lowStock, &before, &product)
+	})
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.Product{}, err
 	}
 
-	rec.Last(&product)
+	if err != nil {
+		logError(ctx, ps.logger, "failed to update product", err, actorAttrs(actor, "product_id", id)...)
+		return models.Product{}, err
+	}
 
 	return product, nil
 }
 
-func (ps *ProductServiceImpl) Update(id string, product models.Product) (models.Product, error) {
-	rec := ps.db.Save(&product)
+func (ps *ProductServiceImpl) Delete(ctx context.Context, actor models.Actor, product models.Product) error {
+	ctx, span := tracer.Start(ctx, "ProductService.Delete")
+	defer span.End()
+
+	err := ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&product).Error; err != nil {
+			return err
+		}
 
-	if rec.Error != nil {
-		return models.Product{}, rec.Error
+		if err := deletePriceList(tx, product.ID); err != nil {
+			return err
+		}
+
+		if err := recordAudit(tx, actor, models.AuditDelete, models.EntityProduct, product.ID, product, nil); err != nil {
+			return err
+		}
+
+		return recordProductEvents(tx, ps.lowStock, &product, nil)
+	})
+
+	if err != nil {
+		logError(ctx, ps.logger, "failed to delete product", err, actorAttrs(actor, "product_id", product.ID)...)
 	}
 
-	rec.Last(&product)
+	return err
+}
 
-	return product, nil
+// GetStats counts the products and sums up the value of their stock.
+func (ps *ProductServiceImpl) GetStats(ctx context.Context) (models.ProductStats, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetStats")
+	defer span.End()
+
+	var stats models.ProductStats
+
+	err := ps.db.WithContext(ctx).Model(&models.Product{}).
+		Select("COUNT(*) AS count, COALESCE(SUM(price * stock), 0) AS stock_value").
+		Scan(&stats).Error
+
+	if err != nil {
+		logError(ctx, ps.logger, "failed to get product stats", err)
+		return models.ProductStats{}, err
+	}
+
+	return stats, nil
 }
 
-func (ps *ProductServiceImpl) Delete(product models.Product) error {
-	rec := ps.db.Delete(&product)
+// errProductNotFound fails a batch update or delete of a missing product.
+var errProductNotFound = errors.New("product is not found")
 
-	if rec.Error != nil {
-		return rec.Error
+// Batch validates and applies operations in order, as Create, Update and
+// Delete would. In all_or_nothing mode they share a transaction, and one
+// invalid or failed operation rolls back the others. In per_item mode each
+// is applied on its own. The error is only set when the batch as a whole
+// could not be carried out.
+func (ps *ProductServiceImpl) Batch(ctx context.Context, actor models.Actor, operations []models.BatchOperation, mode string) (models.BatchReport, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.Batch")
+	defer span.End()
+
+	report := models.BatchReport{
+		Mode:    mode,
+		Results: make([]models.BatchResult, len(operations)),
+	}
+
+	invalid := false
+
+	for i, operation := range operations {
+		report.Results[i] = models.BatchResult{Index: i, Op: operation.Op}
+
+		if err := operation.Validate(); err != nil {
+			report.Results[i].Status = http.StatusBadRequest
+			report.Results[i].Error = validationMessage(err)
+			invalid = true
+		}
+	}
+
+	if mode == models.BatchAllOrNothing {
+		if invalid {
+			return rollBackBatch(report), nil
+		}
+
+		var lookupErr error
+
+		err := ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var existing map[uint]models.Product
+
+			if existing, lookupErr = findBatchProducts(tx, operations); lookupErr != nil {
+				return lookupErr
+			}
+
+			for i, operation := range operations {
+				if err := applyBatchOperation(tx, actor, operation, existing, ps.lowStock, ps.currency, &report.Results[i]); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+
+		if lookupErr != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			logError(ctx, ps.logger, "failed to apply batch", err, actorAttrs(actor)...)
+			return report, err
+		}
+
+		if err != nil {
+			if !isBatchClientError(err) {
+				logError(ctx, ps.logger, "batch rolled back", err, actorAttrs(actor)...)
+			}
+
+			return rollBackBatch(report), nil
+		}
+
+		report.Succeeded = len(operations)
+
+		return report, nil
+	}
+
+	for i, operation := range operations {
+		if report.Results[i].Status != 0 {
+			report.Failed++
+			continue
+		}
+
+		err := ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			existing, err := findBatchProducts(tx, []models.BatchOperation{operation})
+
+			if err != nil {
+				return err
+			}
+
+			return applyBatchOperation(tx, actor, operation, existing, ps.lowStock, ps.currency, &report.Results[i])
+		})
+
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return report, err
+		}
+
+		if err != nil {
+			if !isBatchClientError(err) {
+				logError(ctx, ps.logger, "failed to apply batch operation", err, actorAttrs(actor, "index", i)...)
+			}
+
+			report.Failed++
+			continue
+		}
+
+		report.Succeeded++
+	}
+
+	return report, nil
+}
+
+// isBatchClientError reports whether err failed an operation for a reason
+// reported to the caller, a missing product or a taken SKU, rather than a
+// fault worth logging.
+func isBatchClientError(err error) bool {
+	return errors.Is(err, errProductNotFound) || errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, gorm.ErrDuplicatedKey)
+}
+
+// findBatchProducts loads and locks the products the operations update or
+// delete, in one query, keyed by ID.
+func findBatchProducts(db *gorm.DB, operations []models.BatchOperation) (map[uint]models.Product, error) {
+	existing := map[uint]models.Product{}
+
+	var ids []uint
+
+	for _, operation := range operations {
+		if operation.Op != models.BatchCreate {
+			ids = append(ids, operation.ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return existing, nil
+	}
+
+	var products []models.Product
+
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", ids).Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	for _, product := range products {
+		existing[product.ID] = product
+	}
+
+	return existing, nil
+}
+
+// applyBatchOperation applies operation and records its outcome in result.
+// existing is kept up to date for the operations that follow.
+func applyBatchOperation(tx *gorm.DB, actor models.Actor, operation models.BatchOperation, existing map[uint]models.Product, lowStock int, currency money.Currency, result *models.BatchResult) error {
+	before, found := existing[operation.ID]
+
+	if operation.Op != models.BatchCreate && !found {
+		result.Status = http.StatusNotFound
+		result.Error = errProductNotFound.Error()
+
+		return errProductNotFound
+	}
+
+	var err error
+
+	product := before
+
+	switch operation.Op {
+	case models.BatchCreate:
+		product = operation.Product.ConvertToProduct()
+		product.UserID = actor.ID
+		product.Currency = currency.Code
+
+		if err = tx.Create(&product).Error; err == nil {
+			err = recordAudit(tx, actor, models.AuditCreate, models.EntityProduct, product.ID, nil, product)
+		}
+
+		if err == nil {
+			err = recordPriceChange(tx, actor, nil, product, nil)
+		}
+
+		if err == nil {
+			err = recordProductEvents(tx, lowStock, nil, &product)
+		}
+	case models.BatchUpdate:
+		operation.Product.ApplyTo(&product)
+
+		if err = tx.Save(&product).Error; err == nil {
+			err = recordAudit(tx, actor, models.AuditUpdate, models.EntityProduct, product.ID, before, product)
+		}
+
+		if err == nil {
+			err = recordPriceChange(tx, actor, &before, product, nil)
+		}
+
+		if err == nil {
+			err = recordProductEvents(tx, lowStock, &before, &product)
+		}
+	case models.BatchDelete:
+		if err = tx.Delete(&product).Error; err == nil {
+			err = deletePriceList(tx, product.ID)
+		}
+
+		if err == nil {
+			err = recordAudit(tx, actor, models.AuditDelete, models.EntityProduct, product.ID, product, nil)
+		}
+
+		if err == nil {
+			err = recordProductEvents(tx, lowStock, &product, nil)
+		}
+	}
+
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		result.Status = http.StatusConflict
+		result.Error = "sku has been used"
+
+		return err
+	}
+
+	if err != nil {
+		result.Status = http.StatusInternalServerError
+		result.Error = "failed to save product"
+
+		return err
+	}
+
+	result.Status = http.StatusOK
+
+	if operation.Op == models.BatchCreate {
+		result.Status = http.StatusCreated
+	}
+
+	if operation.Op == models.BatchDelete {
+		delete(existing, product.ID)
+	} else {
+		response := product.ConvertToResponse()
+		result.Product = &response
+		existing[product.ID] = product
 	}
 
 	return nil
 }
+
+// rollBackBatch reports every operation that did not fail itself as
+// 424 Failed Dependency: none of them was kept.
+func rollBackBatch(report models.BatchReport) models.BatchReport {
+	for i := range report.Results {
+		if report.Results[i].Status < http.StatusBadRequest {
+			report.Results[i].Status = http.StatusFailedDependency
+			report.Results[i].Error = "not applied, the batch was rolled back"
+			report.Results[i].Product = nil
+		}
+	}
+
+	report.Succeeded = 0
+	report.Failed = len(report.Results)
+
+	return report
+}
@@ -1,74 +1,289 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"log/slog"
+	"product/events"
 	"product/models"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrTokenInvalid is returned for unknown and expired email tokens.
+	ErrTokenInvalid = errors.New("token invalid")
+	// ErrEmailRegistered is returned when the email of another user is
+	// verified.
+	ErrEmailRegistered = errors.New("email has been registered")
 )
 
 type UserService interface {
-	GetAll() ([]models.User, error)
-	GetByCondition(key string, value string) (models.User, error)
-	Create(userRequest models.User) (models.User, error)
-	Update(id string, userRequest models.User) (models.User, error)
+	GetAll(ctx context.Context) ([]models.User, error)
+	Search(ctx context.Context, query string, page int, limit int) ([]models.User, int64, error)
+	GetByCondition(ctx context.Context, key string, value string) (models.User, error)
+	Create(ctx context.Context, actor models.Actor, userRequest models.User) (models.User, error)
+	Update(ctx context.Context, actor models.Actor, id string, change func(user *models.User) error) (models.User, error)
+	VerifyEmail(ctx context.Context, actor models.Actor, tokenHash string) (models.User, error)
+	Delete(ctx context.Context, actor models.Actor, user models.User, reassignTo uint) error
 }
 
-func NewUserService(gormDB *gorm.DB) UserService {
+func NewUserService(gormDB *gorm.DB, logger *slog.Logger) UserService {
 	return &UserServiceImpl{
-		db: gormDB,
+		db:     gormDB,
+		logger: logger,
 	}
 }
 
 type UserServiceImpl struct {
-	db *gorm.DB
+	db     *gorm.DB
+	logger *slog.Logger
 }
 
-func (us *UserServiceImpl) GetAll() ([]models.User, error) {
+func (us *UserServiceImpl) GetAll(ctx context.Context) ([]models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.GetAll")
+	defer span.End()
+
 	var users []models.User
 
-	err := us.db.Find(&users).Error
+	err := us.db.WithContext(ctx).Find(&users).Error
 
 	if err != nil {
+		logError(ctx, us.logger, "failed to get users", err)
 		return nil, err
 	}
 
 	return users, nil
 }
 
-func (us *UserServiceImpl) GetByCondition(key string, value string) (models.User, error) {
+// Search pages through users whose name or email contains query. Page starts at 1.
+func (us *UserServiceImpl) Search(ctx context.Context, query string, page int, limit int) ([]models.User, int64, error) {
+	ctx, span := tracer.Start(ctx, "UserService.Search")
+	defer span.End()
+
+	var users []models.User
+	var total int64
+
+	rec := us.db.WithContext(ctx).Model(&models.User{})
+
+	if query != "" {
+		like := "%" + query + "%"
+		rec = rec.Where("name LIKE ? OR email LIKE ?", like, like)
+	}
+
+	if err := rec.Count(&total).Error; err != nil {
+		logError(ctx, us.logger, "failed to count users", err, "query", query)
+		return nil, 0, err
+	}
+
+	err := rec.Order("id").Offset((page - 1) * limit).Limit(limit).Find(&users).Error
+
+	if err != nil {
+		logError(ctx, us.logger, "failed to search users", err, "query", query)
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+func (us *UserServiceImpl) GetByCondition(ctx context.Context, key string, value string) (models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.GetByCondition")
+	defer span.End()
+
 	var user models.User
 
-	err := us.db.First(&user, key, value).Error
+	err := us.db.WithContext(ctx).First(&user, key, value).Error
 
 	if err != nil {
+		logError(ctx, us.logger, "failed to get user", err, "key", key)
 		return models.User{}, err
 	}
 
 	return user, err
 }
 
-func (us *UserServiceImpl) Create(userRequest models.User) (models.User, error) {
+func (us *UserServiceImpl) Create(ctx context.Context, actor models.Actor, user models.User) (models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.Create")
+	defer span.End()
+
+	err := us.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+
+		if err := recordAudit(tx, actor, models.AuditCreate, models.EntityUser, user.ID, nil, user); err != nil {
+			return err
+		}
+
+		return recordEvent(tx, events.UserRegistered, user.ID, events.UserData{User: user.ConvertToResponse()})
+	})
+
+	if err != nil {
+		logError(ctx, us.logger, "failed to create user", err, actorAttrs(actor)...)
+		return models.User{}, err
+	}
+
+	return user, nil
+}
+
+// Update applies change to the user with id, locked for the rest of the
+// transaction so that a concurrent change is neither lost nor misrecorded in
+// the audit log. An error from change is returned as is, without saving. A
+// changed email is checked to be unregistered in the same transaction.
+func (us *UserServiceImpl) Update(ctx context.Context, actor models.Actor, id string, change func(user *models.User) error) (models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.Update")
+	defer span.End()
+
 	var user models.User
+	var changeErr error
+
+	err := us.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var before models.User
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&before, "id", id).Error; err != nil {
+			return err
+		}
+
+		user = before
+
+		if changeErr = change(&user); changeErr != nil {
+			return changeErr
+		}
 
-	rec := us.db.Create(&userRequest)
+		if user.Email != before.Email {
+			if err := checkEmailUnregistered(tx, user.Email, user.ID); err != nil {
+				return err
+			}
+		}
 
-	if rec.Error != nil {
-		return models.User{}, rec.Error
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+
+		return recordAudit(tx, actor, models.AuditUpdate, models.EntityUser, user.ID, before, user)
+	})
+
+	if changeErr != nil || errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, ErrEmailRegistered) {
+		return models.User{}, err
 	}
 
-	rec.Last(&user)
+	if err != nil {
+		logError(ctx, us.logger, "failed to update user", err, actorAttrs(actor, "user_id", id)...)
+		return models.User{}, err
+	}
 
 	return user, nil
 }
 
-func (us *UserServiceImpl) Update(id string, user models.User) (models.User, error) {
-	rec := us.db.Save(&user)
+// VerifyEmail switches the email of the user holding the email token with
+// hash tokenHash to their pending email. The email is checked to still be
+// unregistered in the same transaction.
+func (us *UserServiceImpl) VerifyEmail(ctx context.Context, actor models.Actor, tokenHash string) (models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.VerifyEmail")
+	defer span.End()
+
+	var user models.User
+
+	err := us.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("email_token = ?", tokenHash).Limit(1).Find(&user).Error; err != nil {
+			return err
+		}
+
+		if user.ID == 0 || user.PendingEmail == "" || user.EmailTokenExpiresAt == nil || !time.Now().Before(*user.EmailTokenExpiresAt) {
+			return ErrTokenInvalid
+		}
+
+		if err := checkEmailUnregistered(tx, user.PendingEmail, user.ID); err != nil {
+			return err
+		}
+
+		before := user
+
+		user.Email = user.PendingEmail
+		user.PendingEmail = ""
+		user.EmailToken = ""
+		user.EmailTokenExpiresAt = nil
+
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
 
-	if rec.Error != nil {
-		return models.User{}, rec.Error
+		return recordAudit(tx, actor, models.AuditUpdate, models.EntityUser, user.ID, before, user)
+	})
+
+	if errors.Is(err, ErrTokenInvalid) || errors.Is(err, ErrEmailRegistered) {
+		return models.User{}, err
 	}
 
-	rec.Last(&user)
+	if err != nil {
+		logError(ctx, us.logger, "failed to verify email", err, actorAttrs(actor, "user_id", user.ID)...)
+		return models.User{}, err
+	}
 
 	return user, nil
 }
+
+// checkEmailUnregistered returns ErrEmailRegistered when a user other than
+// the one with id has email, locking the rows it reads so that the email
+// cannot be taken before the transaction ends.
+func checkEmailUnregistered(tx *gorm.DB, email string, id uint) error {
+	var registered int64
+
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&models.User{}).
+		Where("email = ? AND id <> ?", email, id).Count(&registered).Error
+
+	if err != nil {
+		return err
+	}
+
+	if registered > 0 {
+		return ErrEmailRegistered
+	}
+
+	return nil
+}
+
+// Delete removes the user and hands their products over to the user with id
+// reassignTo. A reassignTo of 0 leaves the products without an owner. Every
+// product changing hands is audited as an update.
+func (us *UserServiceImpl) Delete(ctx context.Context, actor models.Actor, user models.User, reassignTo uint) error {
+	ctx, span := tracer.Start(ctx, "UserService.Delete")
+	defer span.End()
+
+	err := us.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var products []models.Product
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", user.ID).Find(&products).Error; err != nil {
+			return err
+		}
+
+		if len(products) > 0 {
+			if err := tx.Model(&models.Product{}).Where("user_id = ?", user.ID).Update("user_id", reassignTo).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, before := range products {
+			after := before
+			after.UserID = reassignTo
+
+			if err := recordAudit(tx, actor, models.AuditUpdate, models.EntityProduct, before.ID, before, after); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Delete(&user).Error; err != nil {
+			return err
+		}
+
+		return recordAudit(tx, actor, models.AuditDelete, models.EntityUser, user.ID, user, nil)
+	})
+
+	if err != nil {
+		logError(ctx, us.logger, "failed to delete user", err, actorAttrs(actor, "user_id", user.ID)...)
+	}
+
+	return err
+}
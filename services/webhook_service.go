@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"product/models"
+
+	"gorm.io/gorm"
+)
+
+// webhookLock names the MySQL lock held by the dispatcher of one instance
+// at a time, so that messages are delivered in order.
+const webhookLock = "product_webhook_dispatch"
+
+type WebhookService interface {
+	GetAll(ctx context.Context) ([]models.Webhook, error)
+	GetByID(ctx context.Context, id string) (models.Webhook, error)
+	Create(ctx context.Context, actor models.Actor, webhook models.Webhook) (models.Webhook, error)
+	Update(ctx context.Context, actor models.Actor, webhook models.Webhook) (models.Webhook, error)
+	Delete(ctx context.Context, actor models.Actor, webhook models.Webhook) error
+	WithDispatchLock(ctx context.Context, fn func(ctx context.Context) error) (bool, error)
+	GetPendingMessages(ctx context.Context, limit int) ([]models.WebhookMessage, error)
+	CompleteMessage(ctx context.Context, message models.WebhookMessage, delivery models.WebhookDelivery) error
+	RescheduleMessage(ctx context.Context, message models.WebhookMessage, delivery models.WebhookDelivery) error
+	GetDeliveries(ctx context.Context, webhookID uint, page int, limit int) ([]models.WebhookDelivery, int64, error)
+}
+
+func NewWebhookService(gormDB *gorm.DB, logger *slog.Logger) WebhookService {
+	return &WebhookServiceImpl{
+		db:     gormDB,
+		logger: logger,
+	}
+}
+
+type WebhookServiceImpl struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func (ws *WebhookServiceImpl) GetAll(ctx context.Context) ([]models.Webhook, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.GetAll")
+	defer span.End()
+
+	var webhooks []models.Webhook
+
+	if err := ws.db.WithContext(ctx).Order("id").Find(&webhooks).Error; err != nil {
+		logError(ctx, ws.logger, "failed to get webhooks", err)
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (ws *WebhookServiceImpl) GetByID(ctx context.Context, id string) (models.Webhook, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.GetByID")
+	defer span.End()
+
+	var webhook models.Webhook
+
+	if err := ws.db.WithContext(ctx).First(&webhook, "id = ?", id).Error; err != nil {
+		logError(ctx, ws.logger, "failed to get webhook", err, "webhook_id", id)
+		return models.Webhook{}, err
+	}
+
+	return webhook, nil
+}
+
+func (ws *WebhookServiceImpl) Create(ctx context.Context, actor models.Actor, webhook models.Webhook) (models.Webhook, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.Create")
+	defer span.End()
+
+	err := ws.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&webhook).Error; err != nil {
+			return err
+		}
+
+		return recordAudit(tx, actor, models.AuditCreate, models.EntityWebhook, webhook.ID, nil, webhook)
+	})
+
+	if err != nil {
+		logError(ctx, ws.logger, "failed to create webhook", err, actorAttrs(actor)...)
+		return models.Webhook{}, err
+	}
+
+	return webhook, nil
+}
+
+func (ws *WebhookServiceImpl) Update(ctx context.Context, actor models.Actor, webhook models.Webhook) (models.Webhook, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.Update")
+	defer span.End()
+
+	err := ws.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var before models.Webhook
+
+		if err := tx.First(&before, "id = ?", webhook.ID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Save(&webhook).Error; err != nil {
+			return err
+		}
+
+		return recordAudit(tx, actor, models.AuditUpdate, models.EntityWebhook, webhook.ID, before, webhook)
+	})
+
+	if err != nil {
+		logError(ctx, ws.logger, "failed to update webhook", err, actorAttrs(actor, "webhook_id", webhook.ID)...)
+		return models.Webhook{}, err
+	}
+
+	return webhook, nil
+}
+
+// Delete removes the webhook along with its delivery log and the messages
+// waiting for it.
+func (ws *WebhookServiceImpl) Delete(ctx context.Context, actor models.Actor, webhook models.Webhook) error {
+	ctx, span := tracer.Start(ctx, "WebhookService.Delete")
+	defer span.End()
+
+	err := ws.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", webhook.ID).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("webhook_id = ?", webhook.ID).Delete(&models.WebhookMessage{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&webhook).Error; err != nil {
+			return err
+		}
+
+		return recordAudit(tx, actor, models.AuditDelete, models.EntityWebhook, webhook.ID, webhook, nil)
+	})
+
+	if err != nil {
+		logError(ctx, ws.logger, "failed to delete webhook", err, actorAttrs(actor, "webhook_id", webhook.ID)...)
+	}
+
+	return err
+}
+
+// subscribedWebhooks returns the active webhooks receiving events of
+// eventType.
+func subscribedWebhooks(db *gorm.DB, eventType string) ([]models.Webhook, error) {
+	var active []models.Webhook
+
+	if err := db.Where("active = ?", true).Find(&active).Error; err != nil {
+		return nil, err
+	}
+
+	var webhooks []models.Webhook
+
+	for _, webhook := range active {
+		if webhook.Subscribed(eventType) {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+
+	return webhooks, nil
+}
+
+// WithDispatchLock runs fn while holding the dispatch lock, and reports
+// false without running it when another instance holds the lock.
+func (ws *WebhookServiceImpl) WithDispatchLock(ctx context.Context, fn func(ctx context.Context) error) (bool, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.WithDispatchLock")
+	defer span.End()
+
+	return withLock(ctx, ws.db, webhookLock, fn)
+}
+
+// GetPendingMessages returns the oldest messages waiting for delivery to an
+// active webhook, due or not. The messages of an inactive webhook wait for it
+// to be activated again.
+func (ws *WebhookServiceImpl) GetPendingMessages(ctx context.Context, limit int) ([]models.WebhookMessage, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.GetPendingMessages")
+	defer span.End()
+
+	var messages []models.WebhookMessage
+
+	active := ws.db.Model(&models.Webhook{}).Select("id").Where("active = ?", true)
+
+	err := ws.db.WithContext(ctx).Where("webhook_id IN (?)", active).Order("id").Limit(limit).Find(&messages).Error
+
+	if err != nil {
+		logError(ctx, ws.logger, "failed to get pending webhook messages", err)
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// CompleteMessage logs the last attempt to deliver a message, delivered or
+// out of attempts, and deletes the message.
+func (ws *WebhookServiceImpl) CompleteMessage(ctx context.Context, message models.WebhookMessage, delivery models.WebhookDelivery) error {
+	ctx, span := tracer.Start(ctx, "WebhookService.CompleteMessage")
+	defer span.End()
+
+	err := ws.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&delivery).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&message).Error
+	})
+
+	if err != nil {
+		logError(ctx, ws.logger, "failed to complete webhook message", err, "webhook_id", message.WebhookID, "event_id", message.EventID)
+	}
+
+	return err
+}
+
+// RescheduleMessage logs a failed attempt to deliver a message and saves the
+// message with its next attempt.
+func (ws *WebhookServiceImpl) RescheduleMessage(ctx context.Context, message models.WebhookMessage, delivery models.WebhookDelivery) error {
+	ctx, span := tracer.Start(ctx, "WebhookService.RescheduleMessage")
+	defer span.End()
+
+	err := ws.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&delivery).Error; err != nil {
+			return err
+		}
+
+		return tx.Save(&message).Error
+	})
+
+	if err != nil {
+		logError(ctx, ws.logger, "failed to reschedule webhook message", err, "webhook_id", message.WebhookID, "event_id", message.EventID)
+	}
+
+	return err
+}
+
+// GetDeliveries pages through the delivery log of a webhook, latest first.
+func (ws *WebhookServiceImpl) GetDeliveries(ctx context.Context, webhookID uint, page int, limit int) ([]models.WebhookDelivery, int64, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.GetDeliveries")
+	defer span.End()
+
+	var deliveries []models.WebhookDelivery
+	var total int64
+
+	rec := ws.db.WithContext(ctx).Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+
+	if err := rec.Count(&total).Error; err != nil {
+		logError(ctx, ws.logger, "failed to count webhook deliveries", err, "webhook_id", webhookID)
+		return nil, 0, err
+	}
+
+	if err := rec.Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&deliveries).Error; err != nil {
+		logError(ctx, ws.logger, "failed to get webhook deliveries", err, "webhook_id", webhookID)
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
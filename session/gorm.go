@@ -0,0 +1,64 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"product/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GormStore keeps the sessions in the database so they survive restarts and
+// are shared between instances.
+type GormStore struct {
+	db *gorm.DB
+}
+
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{
+		db: db,
+	}
+}
+
+func (gs *GormStore) Save(ctx context.Context, s models.Session) error {
+	return gs.db.WithContext(ctx).Save(&s).Error
+}
+
+func (gs *GormStore) Get(ctx context.Context, id string) (models.Session, error) {
+	s := models.Session{}
+
+	err := gs.db.WithContext(ctx).First(&s, "id = ?", id).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.Session{}, ErrNotFound
+	}
+
+	return s, err
+}
+
+func (gs *GormStore) ListByUser(ctx context.Context, userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+
+	err := gs.db.WithContext(ctx).Where("user_id = ?", userID).Order("last_seen_at desc").Find(&sessions).Error
+
+	return sessions, err
+}
+
+func (gs *GormStore) Delete(ctx context.Context, userID uint, id string) error {
+	result := gs.db.WithContext(ctx).Where("user_id = ? AND id = ?", userID, id).Delete(&models.Session{})
+
+	if result.Error == nil && result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return result.Error
+}
+
+func (gs *GormStore) DeleteByUser(ctx context.Context, userID uint) error {
+	return gs.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.Session{}).Error
+}
+
+func (gs *GormStore) DeleteExpired(ctx context.Context, now time.Time) error {
+	return gs.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&models.Session{}).Error
+}
@@ -0,0 +1,102 @@
+package session
+
+import (
+	"context"
+	"product/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps the sessions in process. They are lost on restart and
+// not shared between instances.
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]models.Session
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		sessions: map[string]models.Session{},
+	}
+}
+
+func (ms *MemoryStore) Save(ctx context.Context, s models.Session) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.sessions[s.ID] = s
+
+	return nil
+}
+
+func (ms *MemoryStore) Get(ctx context.Context, id string) (models.Session, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	s, ok := ms.sessions[id]
+
+	if !ok {
+		return models.Session{}, ErrNotFound
+	}
+
+	return s, nil
+}
+
+func (ms *MemoryStore) ListByUser(ctx context.Context, userID uint) ([]models.Session, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var sessions []models.Session
+
+	for _, s := range ms.sessions {
+		if s.UserID == userID {
+			sessions = append(sessions, s)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+
+	return sessions, nil
+}
+
+func (ms *MemoryStore) Delete(ctx context.Context, userID uint, id string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if s, ok := ms.sessions[id]; !ok || s.UserID != userID {
+		return ErrNotFound
+	}
+
+	delete(ms.sessions, id)
+
+	return nil
+}
+
+func (ms *MemoryStore) DeleteByUser(ctx context.Context, userID uint) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for id, s := range ms.sessions {
+		if s.UserID == userID {
+			delete(ms.sessions, id)
+		}
+	}
+
+	return nil
+}
+
+func (ms *MemoryStore) DeleteExpired(ctx context.Context, now time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for id, s := range ms.sessions {
+		if !now.Before(s.ExpiresAt) {
+			delete(ms.sessions, id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"product/models"
+	"product/utils"
+	"time"
+)
+
+// ErrNotFound is returned for unknown, expired and revoked sessions.
+var ErrNotFound = errors.New("session not found")
+
+// touchInterval is how often a session in use is extended. Extending it on
+// every request would write to the store on every request.
+const touchInterval = time.Minute
+
+// Store keeps the sessions. Get and Delete return ErrNotFound for unknown
+// sessions; DeleteByUser succeeds when the user has none.
+type Store interface {
+	Save(ctx context.Context, s models.Session) error
+	Get(ctx context.Context, id string) (models.Session, error)
+	ListByUser(ctx context.Context, userID uint) ([]models.Session, error)
+	Delete(ctx context.Context, userID uint, id string) error
+	DeleteByUser(ctx context.Context, userID uint) error
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+// Manager creates and checks sessions. A session expires after ttl without
+// use: every use pushes the expiry back.
+type Manager struct {
+	store Store
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+func NewManager(store Store, ttl time.Duration) *Manager {
+	return &Manager{
+		store: store,
+		ttl:   ttl,
+		now:   time.Now,
+	}
+}
+
+// ID returns the ID of the session held by token.
+func ID(token string) string {
+	return utils.HashToken(token)
+}
+
+// Create logs user in and returns the token to put in the session cookie.
+func (m *Manager) Create(ctx context.Context, user models.User, ip, userAgent string) (string, models.Session, error) {
+	token, err := utils.RandomToken(32)
+
+	if err != nil {
+		return "", models.Session{}, err
+	}
+
+	now := m.now()
+
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+
+	s := models.Session{
+		ID:           ID(token),
+		UserID:       user.ID,
+		TokenVersion: user.TokenVersion,
+		IP:           ip,
+		UserAgent:    userAgent,
+		CreatedAt:    now,
+		LastSeenAt:   now,
+		ExpiresAt:    now.Add(m.ttl),
+	}
+
+	if err := m.store.Save(ctx, s); err != nil {
+		return "", models.Session{}, err
+	}
+
+	return token, s, nil
+}
+
+// Authenticate returns the session held by token, extending it when it was
+// last extended more than touchInterval ago. refreshed reports whether the
+// expiry moved, in which case the cookie has to be sent again.
+func (m *Manager) Authenticate(ctx context.Context, token string) (s models.Session, refreshed bool, err error) {
+	s, err = m.store.Get(ctx, ID(token))
+
+	if err != nil {
+		return models.Session{}, false, err
+	}
+
+	now := m.now()
+
+	if !now.Before(s.ExpiresAt) {
+		return models.Session{}, false, ErrNotFound
+	}
+
+	if now.Sub(s.LastSeenAt) < touchInterval {
+		return s, false, nil
+	}
+
+	s.LastSeenAt = now
+	s.ExpiresAt = now.Add(m.ttl)
+
+	if err := m.store.Save(ctx, s); err != nil {
+		return models.Session{}, false, err
+	}
+
+	return s, true, nil
+}
+
+func (m *Manager) List(ctx context.Context, userID uint) ([]models.Session, error) {
+	sessions, err := m.store.ListByUser(ctx, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.now()
+	active := sessions[:0]
+
+	for _, s := range sessions {
+		if now.Before(s.ExpiresAt) {
+			active = append(active, s)
+		}
+	}
+
+	return active, nil
+}
+
+// Revoke ends the session id of the user.
+func (m *Manager) Revoke(ctx context.Context, userID uint, id string) error {
+	return m.store.Delete(ctx, userID, id)
+}
+
+// RevokeAll ends every session of the user.
+func (m *Manager) RevokeAll(ctx context.Context, userID uint) error {
+	return m.store.DeleteByUser(ctx, userID)
+}
+
+// Run removes expired sessions every hour until ctx is done.
+func (m *Manager) Run(ctx context.Context) {
+	utils.RunCleanup(ctx, "expired sessions", func(ctx context.Context, _ time.Time) error {
+		return m.store.DeleteExpired(ctx, m.now())
+	})
+}
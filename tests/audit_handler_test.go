@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"product/handlers"
+	"product/models"
+	"product/services/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var auditService = mocks.AuditService{}
+var auditHandler = handlers.NewAuditHandler(&auditService)
+
+var auditLogModel = models.AuditLog{
+	ID:         1,
+	ActorID:    1,
+	ActorEmail: "aqsa@gmail.com",
+	Action:     models.AuditUpdate,
+	EntityType: models.EntityProduct,
+	EntityID:   1,
+	Changes:    `{"Price":{"before":1000,"after":2000}}`,
+}
+
+func TestGetAllAuditLog(t *testing.T) {
+	t.Run("GetAll | Success with filters", func(t *testing.T) {
+		from, _ := time.Parse(time.RFC3339, "2023-01-01T00:00:00Z")
+		filter := models.AuditFilter{
+			EntityType: models.EntityProduct,
+			EntityID:   1,
+			ActorID:    1,
+			From:       from,
+		}
+
+		auditService.On("Search", mock.Anything, filter, 1, 10).Return([]models.AuditLog{auditLogModel}, int64(1), nil).Once()
+
+		app.Get("/admin/audit-logs", auditHandler.GetAll)
+
+		req := httptest.NewRequest("GET", "/admin/audit-logs?entity_type=product&entity_id=1&actor_id=1&from=2023-01-01T00:00:00Z", nil)
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "successfully get audit logs", bodyResponse.Message)
+	})
+
+	t.Run("GetAll | Error, bad time range", func(t *testing.T) {
+		app.Get("/admin/audit-logs", auditHandler.GetAll)
+
+		req := httptest.NewRequest("GET", "/admin/audit-logs?to=yesterday", nil)
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 400, resp.StatusCode)
+		assert.Equal(t, "to must be an RFC3339 time", bodyResponse.Message)
+	})
+}
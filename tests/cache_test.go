@@ -0,0 +1,286 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"product/cache"
+	"product/handlers"
+	"product/metrics"
+	"product/middleware"
+	"product/models"
+	"product/services"
+	"product/services/mocks"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// failingStore is a cache store whose every call fails.
+type failingStore struct{}
+
+func (failingStore) Get(ctx context.Context, key string, now time.Time) ([]byte, bool, error) {
+	return nil, false, errors.New("cache is down")
+}
+
+func (failingStore) Set(ctx context.Context, key string, value []byte, expiresAt time.Time) error {
+	return errors.New("cache is down")
+}
+
+func (failingStore) DeleteExpired(ctx context.Context, now time.Time) error {
+	return errors.New("cache is down")
+}
+
+func newTestCache(store cache.Store, ttl time.Duration) *cache.Cache {
+	return cache.New("products", store, func() time.Duration { return ttl }, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestCacheMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("Memory | Evicts the least recently used", func(t *testing.T) {
+		store := cache.NewMemoryStore(2)
+
+		store.Set(ctx, "a", []byte("1"), now.Add(time.Minute))
+		store.Set(ctx, "b", []byte("2"), now.Add(time.Minute))
+		store.Get(ctx, "a", now)
+		store.Set(ctx, "c", []byte("3"), now.Add(time.Minute))
+
+		_, okA, _ := store.Get(ctx, "a", now)
+		_, okB, _ := store.Get(ctx, "b", now)
+		value, okC, _ := store.Get(ctx, "c", now)
+
+		assert.True(t, okA)
+		assert.False(t, okB)
+		assert.True(t, okC)
+		assert.Equal(t, []byte("3"), value)
+	})
+
+	t.Run("Memory | Expires entries", func(t *testing.T) {
+		store := cache.NewMemoryStore(10)
+
+		store.Set(ctx, "a", []byte("1"), now.Add(time.Minute))
+
+		_, ok, _ := store.Get(ctx, "a", now.Add(time.Minute))
+
+		assert.False(t, ok)
+	})
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Cache | Hit, miss and invalidation", func(t *testing.T) {
+		productCache := newTestCache(cache.NewMemoryStore(10), time.Minute)
+		loads := 0
+		load := func(ctx context.Context) ([]string, error) {
+			loads++
+			return []string{"Permen"}, nil
+		}
+
+		first, err := cache.Fetch(ctx, productCache, "all", load)
+		assert.NoError(t, err)
+
+		second, _ := cache.Fetch(ctx, productCache, "all", load)
+
+		assert.Equal(t, []string{"Permen"}, first)
+		assert.Equal(t, first, second)
+		assert.Equal(t, 1, loads)
+		assert.Equal(t, cache.Stats{Hits: 1, Misses: 1}, productCache.Stats())
+
+		productCache.Invalidate(ctx)
+		cache.Fetch(ctx, productCache, "all", load)
+
+		assert.Equal(t, 2, loads)
+	})
+
+	t.Run("Cache | Disabled without TTL", func(t *testing.T) {
+		productCache := newTestCache(cache.NewMemoryStore(10), 0)
+		loads := 0
+		load := func(ctx context.Context) (int, error) {
+			loads++
+			return loads, nil
+		}
+
+		cache.Fetch(ctx, productCache, "all", load)
+		value, _ := cache.Fetch(ctx, productCache, "all", load)
+
+		assert.Equal(t, 2, value)
+		assert.Equal(t, cache.Stats{}, productCache.Stats())
+	})
+
+	t.Run("Cache | Errors are not cached", func(t *testing.T) {
+		productCache := newTestCache(cache.NewMemoryStore(10), time.Minute)
+		loads := 0
+		load := func(ctx context.Context) (int, error) {
+			loads++
+
+			if loads == 1 {
+				return 0, errors.New("database is down")
+			}
+
+			return loads, nil
+		}
+
+		_, err := cache.Fetch(ctx, productCache, "all", load)
+		assert.Error(t, err)
+
+		value, err := cache.Fetch(ctx, productCache, "all", load)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, value)
+	})
+
+	t.Run("Cache | Loads directly when the store fails", func(t *testing.T) {
+		productCache := newTestCache(failingStore{}, time.Minute)
+
+		value, err := cache.Fetch(ctx, productCache, "all", func(ctx context.Context) (string, error) {
+			return "fresh", nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "fresh", value)
+	})
+
+	t.Run("Cache | Concurrent misses load once", func(t *testing.T) {
+		productCache := newTestCache(cache.NewMemoryStore(10), time.Minute)
+		release := make(chan struct{})
+		var loads atomic.Int32
+		var wg sync.WaitGroup
+
+		// The generation is started first so every reader shares the key.
+		cache.Fetch(ctx, productCache, "warm", func(ctx context.Context) (int, error) { return 0, nil })
+
+		values := make([]int, 10)
+
+		for i := range values {
+			wg.Add(1)
+
+			go func(i int) {
+				defer wg.Done()
+
+				values[i], _ = cache.Fetch(ctx, productCache, "all", func(ctx context.Context) (int, error) {
+					loads.Add(1)
+					<-release
+					return 42, nil
+				})
+			}(i)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), loads.Load())
+
+		for _, value := range values {
+			assert.Equal(t, 42, value)
+		}
+	})
+
+	t.Run("Cache | Metrics", func(t *testing.T) {
+		productCache := newTestCache(cache.NewMemoryStore(10), time.Minute)
+		appMetrics := metrics.New()
+
+		assert.NoError(t, appMetrics.RegisterCacheStats(productCache))
+
+		cache.Fetch(ctx, productCache, "all", func(ctx context.Context) (int, error) { return 1, nil })
+		cache.Fetch(ctx, productCache, "all", func(ctx context.Context) (int, error) { return 1, nil })
+
+		app.Get("/cache/metrics", appMetrics.Handler())
+
+		resp, _ := app.Test(httptest.NewRequest("GET", "/cache/metrics", nil), 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Contains(t, string(body), `product_cache_hits_total{cache="products"} 1`)
+		assert.Contains(t, string(body), `product_cache_misses_total{cache="products"} 1`)
+	})
+}
+
+func TestCachedProductService(t *testing.T) {
+	ctx := context.Background()
+	actor := models.Actor{ID: 1}
+
+	t.Run("Cached | Writes invalidate the listings", func(t *testing.T) {
+		productService := mocks.ProductService{}
+		cached := services.NewCachedProductService(&productService, newTestCache(cache.NewMemoryStore(10), time.Minute))
+
+		productService.On("GetAll", mock.Anything).Return([]models.Product{{ID: 1, Name: "Permen"}}, nil).Twice()
+		productService.On("Create", mock.Anything, actor, mock.Anything).Return(models.Product{ID: 2}, nil).Once()
+
+		cached.GetAll(ctx)
+		products, _ := cached.GetAll(ctx)
+
+		assert.Equal(t, "Permen", products[0].Name)
+
+		cached.Create(ctx, actor, models.Product{Name: "Coklat"})
+		cached.GetAll(ctx)
+
+		productService.AssertExpectations(t)
+	})
+
+	t.Run("Cached | Search pages are cached apart", func(t *testing.T) {
+		productService := mocks.ProductService{}
+		cached := services.NewCachedProductService(&productService, newTestCache(cache.NewMemoryStore(10), time.Minute))
+
+		productService.On("Search", mock.Anything, "permen", 1, 10).Return([]models.Product{{ID: 1}}, int64(11), nil).Once()
+		productService.On("Search", mock.Anything, "permen", 2, 10).Return([]models.Product{{ID: 11}}, int64(11), nil).Once()
+
+		cached.Search(ctx, "permen", 1, 10)
+		cached.Search(ctx, "permen", 2, 10)
+		products, total, _ := cached.Search(ctx, "permen", 1, 10)
+
+		assert.Equal(t, uint(1), products[0].ID)
+		assert.Equal(t, int64(11), total)
+		productService.AssertExpectations(t)
+	})
+
+	t.Run("Cached | Imports invalidate unless dry run", func(t *testing.T) {
+		productService := mocks.ProductService{}
+		importService := mocks.ProductImportService{}
+		productCache := newTestCache(cache.NewMemoryStore(10), time.Minute)
+		cached := services.NewCachedProductService(&productService, productCache)
+		cachedImport := services.NewCachedProductImportService(&importService, productCache)
+
+		productService.On("GetAll", mock.Anything).Return([]models.Product{}, nil).Twice()
+		importService.On("Import", mock.Anything, actor, mock.Anything, mock.Anything, mock.Anything).Return(models.ImportReport{}, nil).Twice()
+
+		cached.GetAll(ctx)
+		cachedImport.Import(ctx, actor, nil, models.ImportOptions{DryRun: true}, func(int) {})
+		cached.GetAll(ctx)
+		cachedImport.Import(ctx, actor, nil, models.ImportOptions{}, func(int) {})
+		cached.GetAll(ctx)
+
+		productService.AssertExpectations(t)
+	})
+
+	t.Run("Cached | Cache-Control", func(t *testing.T) {
+		productService := mocks.ProductService{}
+		cachedHandler := handlers.NewProductHandler(services.NewCachedProductService(&productService, newTestCache(cache.NewMemoryStore(10), time.Minute)), &mocks.CurrencyService{}, usd)
+		maxAge := 30 * time.Second
+
+		productService.On("Search", mock.Anything, "", 1, 10).Return([]models.Product{{ID: 1}}, int64(1), nil).Once()
+
+		app.Get("/cached/products", middleware.CacheControl(func() time.Duration { return maxAge }), cachedHandler.Search)
+
+		resp, _ := app.Test(httptest.NewRequest("GET", "/cached/products", nil), 300000)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "public, max-age=30", resp.Header.Get("Cache-Control"))
+		assert.Equal(t, "API-Version, Accept-Currency", resp.Header.Get("Vary"))
+
+		maxAge = 0
+
+		resp, _ = app.Test(httptest.NewRequest("GET", "/cached/products", nil), 300000)
+
+		assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+		productService.AssertExpectations(t)
+	})
+}
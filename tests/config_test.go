@@ -1,6 +1,8 @@
 package tests
 
 import (
+	"product/config"
+	"product/middleware"
 	"product/models"
 
 	"github.com/gofiber/fiber/v2"
@@ -12,3 +14,17 @@ type ResponseFormat struct {
 }
 
 var app = fiber.New()
+
+func init() {
+	config.Cfg = &config.Config{
+		Auth: config.AuthConfig{JWTSecret: "secret"},
+	}
+}
+
+// withUser stands in for the jwt and CurrentUser middlewares.
+func withUser(user models.User) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(middleware.CurrentUserKey, user)
+		return c.Next()
+	}
+}
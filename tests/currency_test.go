@@ -0,0 +1,358 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"product/cache"
+	"product/config"
+	"product/handlers"
+	"product/models"
+	"product/money"
+	"product/services"
+	"product/services/mocks"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func mustLookup(code string) money.Currency {
+	currency, err := money.Lookup(code)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return currency
+}
+
+func TestMoney(t *testing.T) {
+	eur := mustLookup("EUR")
+	jpy := mustLookup("JPY")
+	chf := mustLookup("CHF")
+
+	t.Run("Money | Lookup", func(t *testing.T) {
+		assert.Equal(t, money.Currency{Code: "EUR", Digits: 2}, mustLookup("eur"))
+		assert.Equal(t, 0, jpy.Digits)
+		assert.Equal(t, 3, mustLookup("KWD").Digits)
+
+		_, err := money.Lookup("EURO")
+		assert.ErrorIs(t, err, money.ErrUnknownCurrency)
+	})
+
+	t.Run("Money | Format", func(t *testing.T) {
+		assert.Equal(t, "USD 12,345.67", money.New(1234567, usd).String())
+		assert.Equal(t, "USD 0.05", money.New(5, usd).String())
+		assert.Equal(t, "JPY -1,235", money.New(-1235, jpy).String())
+		assert.Equal(t, "KWD 0.005", money.New(5, mustLookup("KWD")).String())
+	})
+
+	t.Run("Money | Rates", func(t *testing.T) {
+		rate, err := money.ParseRate("0.9200")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "0.92", money.FormatRate(rate))
+
+		_, err = money.ParseRate("-1")
+		assert.ErrorIs(t, err, money.ErrInvalidRate)
+
+		_, err = money.ParseRate("one")
+		assert.ErrorIs(t, err, money.ErrInvalidRate)
+	})
+
+	t.Run("Money | Convert", func(t *testing.T) {
+		rate, _ := money.ParseRate("0.92")
+
+		// 12.34 * 0.92 = 11.3528
+		assert.Equal(t, money.New(1135, eur), money.Convert(money.New(1234, usd), eur, rate, money.Rounding{}))
+		assert.Equal(t, money.New(1136, eur), money.Convert(money.New(1234, usd), eur, rate, money.Rounding{Mode: money.Up}))
+
+		// 12.34 * 151.5 = 1869.51 yen
+		yenRate, _ := money.ParseRate("151.5")
+		assert.Equal(t, money.New(1870, jpy), money.Convert(money.New(1234, usd), jpy, yenRate, money.Rounding{}))
+		assert.Equal(t, money.New(1900, jpy), money.Convert(money.New(1234, usd), jpy, yenRate, money.Rounding{Increment: 100}))
+	})
+
+	t.Run("Money | Rounding modes", func(t *testing.T) {
+		tie := big.NewRat(25, 10)
+		negativeTie := big.NewRat(-25, 10)
+
+		assert.Equal(t, int64(3), money.Rounding{}.Round(tie))
+		assert.Equal(t, int64(-3), money.Rounding{}.Round(negativeTie))
+		assert.Equal(t, int64(2), money.Rounding{Mode: money.HalfEven}.Round(tie))
+		assert.Equal(t, int64(4), money.Rounding{Mode: money.HalfEven}.Round(big.NewRat(35, 10)))
+		assert.Equal(t, int64(2), money.Rounding{Mode: money.Down}.Round(big.NewRat(29, 10)))
+		assert.Equal(t, int64(-3), money.Rounding{Mode: money.Up}.Round(big.NewRat(-21, 10)))
+		assert.Equal(t, int64(1135), money.Rounding{Increment: 5}.Round(big.NewRat(11352, 10)))
+		assert.Equal(t, int64(1140), money.Rounding{Increment: 5}.Round(big.NewRat(11375, 10)))
+	})
+
+	t.Run("Money | Rules", func(t *testing.T) {
+		rules, err := money.NewRules(map[string]money.Rounding{"chf": {Increment: 5}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, money.Rounding{Increment: 5}, rules.For(chf))
+		assert.Equal(t, money.Rounding{}, rules.For(eur))
+
+		_, err = money.NewRules(map[string]money.Rounding{"xyz": {}})
+		assert.ErrorIs(t, err, money.ErrUnknownCurrency)
+	})
+}
+
+func TestMoneyConfig(t *testing.T) {
+	write := func(money string) string {
+		configFile := filepath.Join(t.TempDir(), "config.yaml")
+
+		os.WriteFile(configFile, []byte(`
+database:
+  username: root
+  name: product
+auth:
+  jwt_secret: secret
+money:
+`+money), 0o600)
+
+		return configFile
+	}
+
+	t.Run("Money | Rounding by currency", func(t *testing.T) {
+		cfg, _, err := config.Load([]string{"--config", write("  base_currency: EUR\n  rounding:\n    CHF:\n      increment: 5\n")})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "EUR", cfg.Money.BaseCurrency)
+		assert.Equal(t, int64(5), cfg.Money.Rounding["chf"].Increment)
+	})
+
+	t.Run("Money | Error, unknown currency", func(t *testing.T) {
+		_, _, err := config.Load([]string{"--config", write("  rounding:\n    ABC:\n      mode: half-up\n")})
+
+		assert.ErrorIs(t, err, money.ErrUnknownCurrency)
+	})
+
+	t.Run("Money | Error, unknown rounding mode", func(t *testing.T) {
+		_, _, err := config.Load([]string{"--config", write("  rounding:\n    CHF:\n      mode: nearest\n")})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestProductCurrency(t *testing.T) {
+	productService := mocks.ProductService{}
+	currencyService := mocks.CurrencyService{}
+	productHandler := handlers.NewProductHandler(&productService, &currencyService, usd)
+	eur := mustLookup("EUR")
+
+	app.Get("/currency/products", productHandler.GetAll)
+	app.Get("/currency/v2/products", productHandler.Search)
+
+	products := []models.Product{{ID: 1, Name: "Permen", Price: 1234}}
+
+	priceMoney := func(body []byte) models.MoneyResponse {
+		var bodyResponse struct {
+			Data []models.ProductResponse `json:"data"`
+		}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		return *bodyResponse.Data[0].PriceMoney
+	}
+
+	t.Run("Currency | Base currency by default", func(t *testing.T) {
+		productService.On("GetAll", mock.Anything).Return(products, nil).Once()
+
+		resp, _ := app.Test(httptest.NewRequest("GET", "/currency/products", nil), 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, models.MoneyResponse{Amount: 1234, Currency: "USD", Formatted: "USD 12.34"}, priceMoney(body))
+		currencyService.AssertNotCalled(t, "Convert", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Currency | Query parameter", func(t *testing.T) {
+		productService.On("GetAll", mock.Anything).Return(products, nil).Once()
+		currencyService.On("Convert", mock.Anything, eur, products).Return([]money.Money{money.New(1135, eur)}, nil).Once()
+
+		resp, _ := app.Test(httptest.NewRequest("GET", "/currency/products?currency=eur", nil), 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, models.MoneyResponse{Amount: 1135, Currency: "EUR", Formatted: "EUR 11.35"}, priceMoney(body))
+	})
+
+	t.Run("Currency | Accept-Currency header", func(t *testing.T) {
+		productService.On("Search", mock.Anything, "", 1, 10).Return(products, int64(1), nil).Once()
+		currencyService.On("Convert", mock.Anything, eur, products).Return([]money.Money{money.New(1135, eur)}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/currency/v2/products", nil)
+		req.Header.Set("Accept-Currency", "EUR")
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Contains(t, string(body), `"formatted":"EUR 11.35"`)
+	})
+
+	t.Run("Currency | Unknown currency", func(t *testing.T) {
+		resp, _ := app.Test(httptest.NewRequest("GET", "/currency/products?currency=XYZ", nil), 300000)
+
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	t.Run("Currency | No exchange rate", func(t *testing.T) {
+		gbp := mustLookup("GBP")
+
+		productService.On("GetAll", mock.Anything).Return(products, nil).Once()
+		currencyService.On("Convert", mock.Anything, gbp, products).Return(nil, services.ErrNoRate).Once()
+
+		resp, _ := app.Test(httptest.NewRequest("GET", "/currency/products?currency=GBP", nil), 300000)
+
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+}
+
+func TestCurrencyHandler(t *testing.T) {
+	productService := mocks.ProductService{}
+	currencyService := mocks.CurrencyService{}
+	currencyHandler := handlers.NewCurrencyHandler(&productService, &currencyService, usd)
+
+	app.Get("/currency/rates", currencyHandler.GetRates)
+	app.Put("/currency/rates/:currency", currencyHandler.SetRate)
+	app.Delete("/currency/rates/:currency", currencyHandler.DeleteRate)
+	app.Get("/currency/products/:id/price-list", currencyHandler.GetPriceList)
+	app.Put("/currency/products/:id/price-list/:currency", currencyHandler.SetPrice)
+	app.Delete("/currency/products/:id/price-list/:currency", currencyHandler.DeletePrice)
+
+	t.Run("Rates | List", func(t *testing.T) {
+		currencyService.On("GetRates", mock.Anything).Return([]models.ExchangeRate{{ID: 1, Currency: "EUR", Rate: "0.9200000000"}}, nil).Once()
+
+		resp, _ := app.Test(httptest.NewRequest("GET", "/currency/rates", nil), 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Contains(t, string(body), `"rate":"0.92"`)
+	})
+
+	t.Run("Rates | Set", func(t *testing.T) {
+		currencyService.On("SetRate", mock.Anything, mock.Anything, models.ExchangeRate{Currency: "EUR", Rate: "0.92"}).
+			Return(models.ExchangeRate{ID: 1, Currency: "EUR", Rate: "0.92"}, nil).Once()
+
+		req := httptest.NewRequest("PUT", "/currency/rates/eur", strings.NewReader(`{"rate":0.9200}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := app.Test(req, 300000)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		currencyService.AssertExpectations(t)
+	})
+
+	t.Run("Rates | Invalid rate", func(t *testing.T) {
+		req := httptest.NewRequest("PUT", "/currency/rates/EUR", strings.NewReader(`{"rate":"-0.5"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := app.Test(req, 300000)
+
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	t.Run("Rates | Base currency", func(t *testing.T) {
+		req := httptest.NewRequest("PUT", "/currency/rates/USD", strings.NewReader(`{"rate":"1"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := app.Test(req, 300000)
+
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	t.Run("Rates | Delete a missing rate", func(t *testing.T) {
+		currencyService.On("DeleteRate", mock.Anything, mock.Anything, "GBP").Return(gorm.ErrRecordNotFound).Once()
+
+		resp, _ := app.Test(httptest.NewRequest("DELETE", "/currency/rates/GBP", nil), 300000)
+
+		assert.Equal(t, 404, resp.StatusCode)
+	})
+
+	t.Run("Price list | List", func(t *testing.T) {
+		productService.On("GetByCondition", mock.Anything, "id", "1").Return(models.Product{ID: 1}, nil).Once()
+		currencyService.On("GetPriceList", mock.Anything, uint(1)).Return([]models.ProductPrice{{ID: 3, ProductID: 1, Currency: "JPY", Amount: 1800}}, nil).Once()
+
+		resp, _ := app.Test(httptest.NewRequest("GET", "/currency/products/1/price-list", nil), 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Contains(t, string(body), `"formatted":"JPY 1,800"`)
+	})
+
+	t.Run("Price list | Set", func(t *testing.T) {
+		productService.On("GetByCondition", mock.Anything, "id", "1").Return(models.Product{ID: 1}, nil).Once()
+		currencyService.On("SetPrice", mock.Anything, mock.Anything, models.ProductPrice{ProductID: 1, Currency: "EUR", Amount: 1099}).
+			Return(models.ProductPrice{ID: 4, ProductID: 1, Currency: "EUR", Amount: 1099}, nil).Once()
+
+		req := httptest.NewRequest("PUT", "/currency/products/1/price-list/EUR", strings.NewReader(`{"amount":1099}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Contains(t, string(body), `"formatted":"EUR 10.99"`)
+	})
+
+	t.Run("Price list | Set for a missing product", func(t *testing.T) {
+		productService.On("GetByCondition", mock.Anything, "id", "9").Return(models.Product{}, gorm.ErrRecordNotFound).Once()
+
+		req := httptest.NewRequest("PUT", "/currency/products/9/price-list/EUR", strings.NewReader(`{"amount":1099}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := app.Test(req, 300000)
+
+		assert.Equal(t, 404, resp.StatusCode)
+	})
+
+	t.Run("Price list | Delete a missing price", func(t *testing.T) {
+		productService.On("GetByCondition", mock.Anything, "id", "1").Return(models.Product{ID: 1}, nil).Once()
+		currencyService.On("DeletePrice", mock.Anything, mock.Anything, uint(1), "GBP").Return(gorm.ErrRecordNotFound).Once()
+
+		resp, _ := app.Test(httptest.NewRequest("DELETE", "/currency/products/1/price-list/GBP", nil), 300000)
+
+		assert.Equal(t, 404, resp.StatusCode)
+	})
+}
+
+func TestCachedCurrencyService(t *testing.T) {
+	ctx := context.Background()
+	eur := mustLookup("EUR")
+
+	t.Run("Cached | Rate changes invalidate the conversions", func(t *testing.T) {
+		currencyService := mocks.CurrencyService{}
+		cached := services.NewCachedCurrencyService(&currencyService, newTestCache(cache.NewMemoryStore(10), time.Minute))
+		products := []models.Product{{ID: 1, Price: 1234}}
+
+		currencyService.On("Convert", mock.Anything, eur, products).Return([]money.Money{money.New(1135, eur)}, nil).Twice()
+		currencyService.On("SetRate", mock.Anything, mock.Anything, mock.Anything).Return(models.ExchangeRate{}, nil).Once()
+
+		cached.Convert(ctx, eur, products)
+		prices, _ := cached.Convert(ctx, eur, products)
+
+		assert.Equal(t, []money.Money{money.New(1135, eur)}, prices)
+
+		cached.SetRate(ctx, models.Actor{ID: 1}, models.ExchangeRate{Currency: "EUR", Rate: "0.93"})
+		cached.Convert(ctx, eur, products)
+
+		currencyService.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"product/db"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newMockDB returns a MySQL gorm.DB whose statements are checked by mock.
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	conn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+
+	assert.NoError(t, err)
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{Conn: conn, SkipInitializeWithVersion: true}), &gorm.Config{
+		Logger: logger.Discard,
+	})
+
+	assert.NoError(t, err)
+
+	return gormDB, mock
+}
+
+func TestMigrateCurrency(t *testing.T) {
+	const (
+		countUnconverted = "SELECT count(*) FROM `products` WHERE currency IS NULL OR currency = '' FOR UPDATE"
+		otherCurrencies  = "SELECT DISTINCT `currency` FROM `products` WHERE currency <> ?"
+	)
+
+	t.Run("Currency | Convert products stored before currencies", func(t *testing.T) {
+		gormDB, mock := newMockDB(t)
+
+		// Two products were stored with whole dollars and a NULL currency.
+		mock.ExpectBegin()
+		mock.ExpectQuery(countUnconverted).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectExec("UPDATE `price_changes` SET `old_price`=old_price * ?,`price`=price * ? WHERE product_id IN (SELECT `id` FROM `products` WHERE currency IS NULL OR currency = '')").
+			WithArgs(100, 100).WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec("UPDATE `price_schedules` SET `previous_price`=previous_price * ?,`price`=price * ? WHERE product_id IN (SELECT `id` FROM `products` WHERE currency IS NULL OR currency = '')").
+			WithArgs(100, 100).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE `products` SET `currency`=?,`price`=price * ? WHERE currency IS NULL OR currency = ''").
+			WithArgs("USD", 100).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+		mock.ExpectQuery(otherCurrencies).WithArgs("USD").WillReturnRows(sqlmock.NewRows([]string{"currency"}))
+
+		assert.NoError(t, db.MigrateCurrency(gormDB, "usd"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Currency | Nothing to convert", func(t *testing.T) {
+		gormDB, mock := newMockDB(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(countUnconverted).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectCommit()
+		mock.ExpectQuery(otherCurrencies).WithArgs("JPY").WillReturnRows(sqlmock.NewRows([]string{"currency"}))
+
+		assert.NoError(t, db.MigrateCurrency(gormDB, "JPY"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Currency | Changed base currency", func(t *testing.T) {
+		gormDB, mock := newMockDB(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(countUnconverted).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectCommit()
+		mock.ExpectQuery(otherCurrencies).WithArgs("EUR").WillReturnRows(sqlmock.NewRows([]string{"currency"}).AddRow("USD"))
+
+		err := db.MigrateCurrency(gormDB, "EUR")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "prices are stored in USD, not in the base currency EUR")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Currency | Unknown base currency", func(t *testing.T) {
+		gormDB, _ := newMockDB(t)
+
+		assert.Error(t, db.MigrateCurrency(gormDB, "dollar"))
+	})
+}
@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"product/handlers"
+	"product/services/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var healthService = mocks.HealthService{}
+var healthHandler = handlers.NewHealthHandler(&healthService)
+
+func TestHealth(t *testing.T) {
+	app.Get("/healthz", healthHandler.Live)
+	app.Get("/readyz", healthHandler.Ready)
+
+	t.Run("Live | Success", func(t *testing.T) {
+		resp, _ := app.Test(httptest.NewRequest("GET", "/healthz", nil), 300000)
+
+		assert.Equal(t, 200, resp.StatusCode)
+	})
+
+	t.Run("Ready | Success", func(t *testing.T) {
+		healthService.On("Ping", mock.Anything).Return(nil).Once()
+		healthService.On("CheckMigrations", mock.Anything).Return(nil).Once()
+
+		resp, _ := app.Test(httptest.NewRequest("GET", "/readyz", nil), 300000)
+
+		assert.Equal(t, 200, resp.StatusCode)
+	})
+
+	t.Run("Ready | Error, database is unreachable", func(t *testing.T) {
+		healthService.On("Ping", mock.Anything).Return(errors.New("error")).Once()
+
+		resp, _ := app.Test(httptest.NewRequest("GET", "/readyz", nil), 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 503, resp.StatusCode)
+		assert.Equal(t, "database is unreachable", bodyResponse.Message)
+	})
+
+	t.Run("Ready | Error, shutting down", func(t *testing.T) {
+		healthHandler.Drain()
+
+		resp, _ := app.Test(httptest.NewRequest("GET", "/readyz", nil), 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 503, resp.StatusCode)
+		assert.Equal(t, "shutting down", bodyResponse.Message)
+	})
+}
@@ -0,0 +1,265 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"product/config"
+	"product/idempotency"
+	"product/middleware"
+	"product/models"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotency(t *testing.T) {
+	store := idempotency.NewMemoryStore()
+	idempotencyConfig := config.IdempotencyConfig{TTL: time.Hour, Lease: time.Minute}
+	idempotent := middleware.Idempotency(store, func() config.IdempotencyConfig { return idempotencyConfig })
+	calls := 0
+	failures := 0
+
+	app.Post("/idempotent/create", withUser(models.User{ID: 1}), idempotent, func(c *fiber.Ctx) error {
+		calls++
+		c.Set(fiber.HeaderLocation, "/created/"+strconv.Itoa(calls))
+
+		return c.Status(fiber.StatusCreated).SendString("created " + strconv.Itoa(calls))
+	})
+	app.Post("/idempotent/other-user", withUser(models.User{ID: 2}), idempotent, func(c *fiber.Ctx) error {
+		calls++
+
+		return c.Status(fiber.StatusCreated).SendString("created " + strconv.Itoa(calls))
+	})
+	app.Post("/idempotent/flaky", withUser(models.User{ID: 1}), idempotent, func(c *fiber.Ctx) error {
+		failures++
+
+		if failures == 1 {
+			return c.Status(fiber.StatusInternalServerError).SendString("failed")
+		}
+
+		return c.Status(fiber.StatusCreated).SendString("created after " + strconv.Itoa(failures))
+	})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	app.Post("/idempotent/slow", withUser(models.User{ID: 1}), idempotent, func(c *fiber.Ctx) error {
+		started <- struct{}{}
+		<-release
+
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	stuck := 0
+
+	app.Post("/idempotent/stuck", withUser(models.User{ID: 1}), idempotent, func(c *fiber.Ctx) error {
+		stuck++
+
+		if stuck == 1 {
+			started <- struct{}{}
+			<-release
+		}
+
+		return c.Status(fiber.StatusCreated).SendString("created after " + strconv.Itoa(stuck))
+	})
+
+	// The store refuses cancelled contexts like the database store does.
+	app.Post("/idempotent/timeout", withUser(models.User{ID: 1}), middleware.Idempotency(contextStore{store}, func() config.IdempotencyConfig {
+		return idempotencyConfig
+	}), middleware.Timeout(func() time.Duration { return time.Millisecond }), func(c *fiber.Ctx) error {
+		<-c.UserContext().Done()
+
+		return c.Status(fiber.StatusGatewayTimeout).SendString("timed out")
+	})
+
+	send := func(path string, key string, body string) (int, string, http.Header) {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+
+		if key != "" {
+			req.Header.Set(middleware.HeaderIdempotencyKey, key)
+		}
+
+		resp, _ := app.Test(req, 300000)
+
+		respBody, _ := io.ReadAll(resp.Body)
+
+		return resp.StatusCode, string(respBody), resp.Header
+	}
+
+	t.Run("Idempotency | Retry replays the first response", func(t *testing.T) {
+		status, body, _ := send("/idempotent/create", "key-1", `{"name":"Permen"}`)
+
+		assert.Equal(t, 201, status)
+		assert.Equal(t, "created 1", body)
+
+		status, body, header := send("/idempotent/create", "key-1", `{"name":"Permen"}`)
+
+		assert.Equal(t, 201, status)
+		assert.Equal(t, "created 1", body)
+		assert.Equal(t, "/created/1", header.Get(fiber.HeaderLocation))
+		assert.Equal(t, "true", header.Get(middleware.HeaderIdempotentReplayed))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Idempotency | Different request with the same key", func(t *testing.T) {
+		status, body, _ := send("/idempotent/create", "key-1", `{"name":"Coklat"}`)
+
+		assert.Equal(t, 422, status)
+		assert.Contains(t, body, "idempotency key was already used for a different request")
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Idempotency | Keys are scoped to the user", func(t *testing.T) {
+		status, body, _ := send("/idempotent/other-user", "key-1", `{"name":"Permen"}`)
+
+		assert.Equal(t, 201, status)
+		assert.Equal(t, "created 2", body)
+	})
+
+	t.Run("Idempotency | Without key", func(t *testing.T) {
+		send("/idempotent/create", "", `{"name":"Permen"}`)
+		send("/idempotent/create", "", `{"name":"Permen"}`)
+
+		assert.Equal(t, 4, calls)
+	})
+
+	t.Run("Idempotency | Server errors are not kept", func(t *testing.T) {
+		status, _, _ := send("/idempotent/flaky", "key-2", "{}")
+
+		assert.Equal(t, 500, status)
+
+		status, body, _ := send("/idempotent/flaky", "key-2", "{}")
+
+		assert.Equal(t, 201, status)
+		assert.Equal(t, "created after 2", body)
+	})
+
+	t.Run("Idempotency | Request in progress", func(t *testing.T) {
+		done := make(chan int)
+
+		go func() {
+			status, _, _ := send("/idempotent/slow", "key-3", "{}")
+			done <- status
+		}()
+
+		<-started
+
+		status, body, _ := send("/idempotent/slow", "key-3", "{}")
+
+		assert.Equal(t, 409, status)
+		assert.Contains(t, body, "a request with this idempotency key is in progress")
+
+		close(release)
+
+		assert.Equal(t, 201, <-done)
+	})
+
+	t.Run("Idempotency | Timed out request is released", func(t *testing.T) {
+		status, _, _ := send("/idempotent/timeout", "key-4", "{}")
+
+		assert.Equal(t, 504, status)
+
+		status, _, _ = send("/idempotent/timeout", "key-4", "{}")
+
+		assert.Equal(t, 504, status)
+	})
+
+	t.Run("Idempotency | Lease of a stuck request runs out", func(t *testing.T) {
+		release = make(chan struct{})
+		idempotencyConfig.Lease = 50 * time.Millisecond
+		done := make(chan int)
+
+		go func() {
+			status, _, _ := send("/idempotent/stuck", "key-5", "{}")
+			done <- status
+		}()
+
+		<-started
+		time.Sleep(60 * time.Millisecond)
+
+		status, body, _ := send("/idempotent/stuck", "key-5", "{}")
+
+		assert.Equal(t, 201, status)
+		assert.Equal(t, "created after 2", body)
+
+		close(release)
+		<-done
+
+		// The stuck request no longer holds the key, so it does not replace
+		// the response of the request that took it over.
+		status, body, _ = send("/idempotent/stuck", "key-5", "{}")
+
+		assert.Equal(t, 201, status)
+		assert.Equal(t, "created after 2", body)
+		assert.Equal(t, 2, stuck)
+
+		idempotencyConfig.Lease = time.Minute
+	})
+
+	t.Run("Idempotency | Keys expire", func(t *testing.T) {
+		now := time.Now()
+		record := models.IdempotencyKey{Key: "expiring", Fingerprint: "f", CreatedAt: now, ExpiresAt: now.Add(time.Minute)}
+
+		record, _, _ = store.Reserve(context.Background(), record, now)
+		record.Status = 201
+		assert.NoError(t, store.Complete(context.Background(), record))
+
+		_, reserved, _ := store.Reserve(context.Background(), record, now)
+		assert.False(t, reserved)
+
+		_, reserved, _ = store.Reserve(context.Background(), record, now.Add(2*time.Minute))
+		assert.True(t, reserved)
+	})
+
+	t.Run("Idempotency | Taken over reservation is kept", func(t *testing.T) {
+		now := time.Now()
+		first, _, _ := store.Reserve(context.Background(), models.IdempotencyKey{Key: "taken", Fingerprint: "f", CreatedAt: now, ExpiresAt: now.Add(time.Second)}, now)
+		later := now.Add(2 * time.Second)
+		second, reserved, _ := store.Reserve(context.Background(), models.IdempotencyKey{Key: "taken", Fingerprint: "f", CreatedAt: later, ExpiresAt: later.Add(time.Second)}, later)
+
+		assert.True(t, reserved)
+		assert.ErrorIs(t, store.Release(context.Background(), first), idempotency.ErrNotReserved)
+
+		first.Status = 201
+		assert.ErrorIs(t, store.Complete(context.Background(), first), idempotency.ErrNotReserved)
+
+		existing, reserved, _ := store.Reserve(context.Background(), second, later)
+
+		assert.False(t, reserved)
+		assert.Equal(t, 0, existing.Status)
+		assert.True(t, existing.CreatedAt.Equal(later))
+	})
+
+	t.Run("Idempotency | Key too long", func(t *testing.T) {
+		status, _, _ := send("/idempotent/create", strings.Repeat("k", 256), "{}")
+
+		assert.Equal(t, 400, status)
+	})
+}
+
+// contextStore fails like a database store once the context is done.
+type contextStore struct {
+	idempotency.Store
+}
+
+func (cs contextStore) Reserve(ctx context.Context, record models.IdempotencyKey, now time.Time) (models.IdempotencyKey, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return models.IdempotencyKey{}, false, err
+	}
+
+	return cs.Store.Reserve(ctx, record, now)
+}
+
+func (cs contextStore) Release(ctx context.Context, record models.IdempotencyKey) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return cs.Store.Release(ctx, record)
+}
@@ -0,0 +1,370 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"product/config"
+	"product/handlers"
+	"product/jobs"
+	"product/models"
+	"product/services"
+	"product/services/mocks"
+	"product/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type thumbnail struct {
+	ProductID uint `json:"product_id"`
+	Width     int  `json:"width"`
+}
+
+const makeThumbnail jobs.Type[thumbnail] = "thumbnail.make"
+
+func TestJobQueue(t *testing.T) {
+	cfg := config.JobsConfig{
+		Concurrency:    2,
+		PollInterval:   10 * time.Millisecond,
+		Timeout:        time.Second,
+		MaxAttempts:    3,
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+		Retention:      time.Hour,
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// newQueue registers a thumbnail handler returning the errors of fail in
+	// turn, then nil.
+	newQueue := func(jobService *mocks.JobService, fail ...error) (*jobs.Queue, *[]thumbnail) {
+		queue := jobs.NewQueue(jobService, cfg, logger)
+		handled := []thumbnail{}
+
+		jobs.Register(queue, makeThumbnail, func(ctx context.Context, payload thumbnail) error {
+			handled = append(handled, payload)
+
+			if len(fail) == 0 {
+				return nil
+			}
+
+			err := fail[0]
+			fail = fail[1:]
+
+			return err
+		})
+
+		return queue, &handled
+	}
+
+	claimed := func(attempts int, payload string) models.Job {
+		lockedAt := time.Now()
+
+		return models.Job{ID: 7, Type: string(makeThumbnail), Payload: payload, Status: models.JobRunning, Attempts: attempts, MaxAttempts: 3, LockedBy: "host:1/1", LockedAt: &lockedAt}
+	}
+
+	updated := func(jobService *mocks.JobService) *models.Job {
+		job := &models.Job{}
+
+		jobService.On("Update", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			*job = args.Get(1).(models.Job)
+		}).Return(nil).Once()
+
+		return job
+	}
+
+	t.Run("Queue | Enqueue a delayed job", func(t *testing.T) {
+		jobService := mocks.JobService{}
+		queue, _ := newQueue(&jobService)
+		runAt := time.Now().Add(time.Hour)
+
+		jobService.On("Enqueue", mock.Anything, models.Job{
+			Type:        "thumbnail.make",
+			Payload:     `{"product_id":3,"width":200}`,
+			RunAt:       runAt,
+			MaxAttempts: 3,
+		}).Return(models.Job{ID: 1, Status: models.JobQueued}, nil).Once()
+
+		job, err := jobs.Enqueue(context.Background(), queue, makeThumbnail, thumbnail{ProductID: 3, Width: 200}, runAt)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), job.ID)
+		jobService.AssertExpectations(t)
+	})
+
+	t.Run("Queue | Enqueue a type without handler", func(t *testing.T) {
+		jobService := mocks.JobService{}
+		queue := jobs.NewQueue(&jobService, cfg, logger)
+
+		_, err := jobs.Enqueue(context.Background(), queue, makeThumbnail, thumbnail{}, time.Time{})
+
+		assert.ErrorIs(t, err, jobs.ErrUnknownType)
+		jobService.AssertNotCalled(t, "Enqueue", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Queue | Success", func(t *testing.T) {
+		jobService := mocks.JobService{}
+		queue, handled := newQueue(&jobService)
+		job := updated(&jobService)
+
+		queue.Process(context.Background(), claimed(1, `{"product_id":3,"width":200}`))
+
+		assert.Equal(t, []thumbnail{{ProductID: 3, Width: 200}}, *handled)
+		assert.Equal(t, models.JobSucceeded, job.Status)
+		assert.NotNil(t, job.FinishedAt)
+		assert.Nil(t, job.LockedAt)
+		assert.Empty(t, job.LockedBy)
+	})
+
+	t.Run("Queue | Failure is retried with backoff", func(t *testing.T) {
+		jobService := mocks.JobService{}
+		queue, _ := newQueue(&jobService, errors.New("storage is down"))
+		job := updated(&jobService)
+
+		queue.Process(context.Background(), claimed(2, `{"product_id":3}`))
+
+		assert.Equal(t, models.JobQueued, job.Status)
+		assert.Equal(t, 2, job.Attempts)
+		assert.Equal(t, "storage is down", job.LastError)
+		assert.WithinDuration(t, time.Now().Add(2*time.Second), job.RunAt, 500*time.Millisecond)
+		assert.Nil(t, job.FinishedAt)
+	})
+
+	t.Run("Queue | Dead letter after the last attempt", func(t *testing.T) {
+		jobService := mocks.JobService{}
+		queue, _ := newQueue(&jobService, errors.New("storage is down"))
+		job := updated(&jobService)
+
+		queue.Process(context.Background(), claimed(3, `{"product_id":3}`))
+
+		assert.Equal(t, models.JobDead, job.Status)
+		assert.NotNil(t, job.FinishedAt)
+	})
+
+	t.Run("Queue | Permanent failure skips the retries", func(t *testing.T) {
+		jobService := mocks.JobService{}
+		queue, _ := newQueue(&jobService, jobs.Permanent(errors.New("product is gone")))
+		job := updated(&jobService)
+
+		queue.Process(context.Background(), claimed(1, `{"product_id":3}`))
+
+		assert.Equal(t, models.JobDead, job.Status)
+		assert.Equal(t, "product is gone", job.LastError)
+	})
+
+	t.Run("Queue | Unreadable payload", func(t *testing.T) {
+		jobService := mocks.JobService{}
+		queue, handled := newQueue(&jobService)
+		job := updated(&jobService)
+
+		queue.Process(context.Background(), claimed(1, `{"product_id":"three"}`))
+
+		assert.Empty(t, *handled)
+		assert.Equal(t, models.JobDead, job.Status)
+	})
+
+	t.Run("Queue | Panic", func(t *testing.T) {
+		jobService := mocks.JobService{}
+		queue := jobs.NewQueue(&jobService, cfg, logger)
+		jobs.Register(queue, makeThumbnail, func(ctx context.Context, payload thumbnail) error {
+			panic("nil image")
+		})
+		job := updated(&jobService)
+
+		queue.Process(context.Background(), claimed(1, `{}`))
+
+		assert.Equal(t, models.JobQueued, job.Status)
+		assert.Equal(t, "job panicked: nil image", job.LastError)
+	})
+
+	t.Run("Queue | Interrupted by shutdown", func(t *testing.T) {
+		jobService := mocks.JobService{}
+		ctx, cancel := context.WithCancel(context.Background())
+		queue, _ := newQueue(&jobService, context.Canceled)
+		job := updated(&jobService)
+
+		cancel()
+		queue.Process(ctx, claimed(2, `{}`))
+
+		assert.Equal(t, models.JobQueued, job.Status)
+		assert.Equal(t, 1, job.Attempts)
+		assert.Empty(t, job.LastError)
+	})
+
+	t.Run("Queue | Workers run claimed jobs and stop", func(t *testing.T) {
+		jobService := mocks.JobService{}
+		queue, _ := newQueue(&jobService)
+		done := make(chan struct{})
+		ctx, cancel := context.WithCancel(context.Background())
+
+		jobService.On("RequeueStale", mock.Anything, mock.Anything).Return(int64(0), nil)
+		jobService.On("DeleteFinished", mock.Anything, mock.Anything).Return(nil)
+		jobService.On("Claim", mock.Anything, mock.Anything, []string{"thumbnail.make"}, mock.Anything).Return(claimed(1, `{}`), true, nil).Once()
+		jobService.On("Claim", mock.Anything, mock.Anything, []string{"thumbnail.make"}, mock.Anything).Return(models.Job{}, false, nil)
+		jobService.On("Update", mock.Anything, mock.MatchedBy(func(job models.Job) bool {
+			return job.Status == models.JobSucceeded
+		})).Run(func(args mock.Arguments) { cancel() }).Return(nil).Once()
+
+		go func() {
+			queue.Run(ctx)
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("queue did not stop")
+		}
+
+		jobService.AssertExpectations(t)
+	})
+
+	t.Run("Queue | User mailer enqueues a reference to the user", func(t *testing.T) {
+		jobService := mocks.JobService{}
+		queue := jobs.NewQueue(&jobService, cfg, logger)
+		jobs.Register(queue, jobs.SendUserMail, func(ctx context.Context, mail jobs.UserMail) error { return nil })
+
+		jobService.On("Enqueue", mock.Anything, mock.MatchedBy(func(job models.Job) bool {
+			return job.Type == "user.mail" && job.Payload == `{"user_id":2,"template":"temporary_password"}`
+		})).Return(models.Job{ID: 2}, nil).Once()
+
+		assert.NoError(t, jobs.NewUserMailer(queue).SendTemporaryPassword(context.Background(), 2))
+		jobService.AssertExpectations(t)
+	})
+}
+
+func TestSendUserMails(t *testing.T) {
+	t.Run("UserMail | Temporary password is made and mailed", func(t *testing.T) {
+		userService := mocks.UserService{}
+		mailer := mocks.Mailer{}
+		var saved models.User
+		var body string
+
+		onUpdateUser(&userService, "2", models.User{ID: 2, Email: "andi@gmail.com", MustResetPassword: true}, &saved)
+		mailer.On("Send", mock.Anything, "andi@gmail.com", "Your account has been created", mock.Anything).Run(func(args mock.Arguments) {
+			body = args.String(3)
+		}).Return(nil).Once()
+
+		err := jobs.SendUserMails(&userService, &mailer)(context.Background(), jobs.UserMail{UserID: 2, Template: jobs.MailTemporaryPassword})
+
+		assert.NoError(t, err)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(saved.Password), []byte(strings.TrimPrefix(body, "Your temporary password: "))))
+	})
+
+	t.Run("UserMail | Verification token is stored hashed", func(t *testing.T) {
+		userService := mocks.UserService{}
+		mailer := mocks.Mailer{}
+		var saved models.User
+		var body string
+
+		onUpdateUser(&userService, "1", models.User{ID: 1, PendingEmail: "new@gmail.com"}, &saved)
+		mailer.On("Send", mock.Anything, "new@gmail.com", "Verify your new email", mock.Anything).Run(func(args mock.Arguments) {
+			body = args.String(3)
+		}).Return(nil).Once()
+
+		err := jobs.SendUserMails(&userService, &mailer)(context.Background(), jobs.UserMail{UserID: 1, Template: jobs.MailEmailVerification})
+
+		assert.NoError(t, err)
+		assert.NotContains(t, body, saved.EmailToken)
+		assert.Equal(t, saved.EmailToken, utils.HashToken(strings.TrimPrefix(body, "Your verification token: ")))
+		assert.True(t, saved.EmailTokenExpiresAt.After(time.Now()))
+	})
+
+	t.Run("UserMail | Skips a mail no longer wanted", func(t *testing.T) {
+		userService := mocks.UserService{}
+		mailer := mocks.Mailer{}
+
+		onUpdateUser(&userService, "2", models.User{ID: 2, Email: "andi@gmail.com"}, nil).Twice()
+
+		send := jobs.SendUserMails(&userService, &mailer)
+
+		assert.NoError(t, send(context.Background(), jobs.UserMail{UserID: 2, Template: jobs.MailTemporaryPassword}))
+		assert.NoError(t, send(context.Background(), jobs.UserMail{UserID: 2, Template: jobs.MailEmailVerification}))
+		mailer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("UserMail | Missing user fails permanently", func(t *testing.T) {
+		userService := mocks.UserService{}
+
+		userService.On("Update", mock.Anything, models.Actor{}, "9", mock.Anything).Return(models.User{}, gorm.ErrRecordNotFound).Once()
+
+		err := jobs.SendUserMails(&userService, &mocks.Mailer{})(context.Background(), jobs.UserMail{UserID: 9, Template: jobs.MailTemporaryPassword})
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.Equal(t, err, jobs.Permanent(gorm.ErrRecordNotFound))
+	})
+}
+
+func TestJobHandler(t *testing.T) {
+	jobService := mocks.JobService{}
+	jobHandler := handlers.NewJobHandler(&jobService)
+
+	app.Get("/jobs", jobHandler.GetAll)
+	app.Get("/jobs/:id", jobHandler.Get)
+	app.Post("/jobs/:id/retry", jobHandler.Retry)
+	app.Post("/jobs/:id/cancel", jobHandler.Cancel)
+
+	dead := models.Job{ID: 4, Type: "mail.send", Payload: `{"to":"andi@gmail.com"}`, Status: models.JobDead, Attempts: 5, MaxAttempts: 5, LastError: "smtp timeout"}
+
+	t.Run("Jobs | Search", func(t *testing.T) {
+		jobService.On("Search", mock.Anything, models.JobFilter{Status: models.JobDead, Type: "mail.send"}, 1, 10).Return([]models.Job{dead}, int64(1), nil).Once()
+
+		req := httptest.NewRequest("GET", "/jobs?status=dead&type=mail.send", nil)
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Contains(t, string(body), `"payload":{"to":"andi@gmail.com"}`)
+		assert.Contains(t, string(body), `"last_error":"smtp timeout"`)
+	})
+
+	t.Run("Jobs | Search with an unknown status", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/jobs?status=lost", nil)
+
+		resp, _ := app.Test(req, 300000)
+
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	t.Run("Jobs | Get a missing job", func(t *testing.T) {
+		jobService.On("GetByID", mock.Anything, "9").Return(models.Job{}, gorm.ErrRecordNotFound).Once()
+
+		req := httptest.NewRequest("GET", "/jobs/9", nil)
+
+		resp, _ := app.Test(req, 300000)
+
+		assert.Equal(t, 404, resp.StatusCode)
+	})
+
+	t.Run("Jobs | Retry", func(t *testing.T) {
+		jobService.On("Retry", mock.Anything, "4").Return(models.Job{ID: 4, Type: "mail.send", Payload: "{}", Status: models.JobQueued}, nil).Once()
+
+		req := httptest.NewRequest("POST", "/jobs/4/retry", nil)
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Contains(t, string(body), `"status":"queued"`)
+	})
+
+	t.Run("Jobs | Cancel a running job", func(t *testing.T) {
+		jobService.On("Cancel", mock.Anything, "5").Return(models.Job{}, services.ErrJobStatus).Once()
+
+		req := httptest.NewRequest("POST", "/jobs/5/cancel", nil)
+
+		resp, _ := app.Test(req, 300000)
+
+		assert.Equal(t, 409, resp.StatusCode)
+	})
+}
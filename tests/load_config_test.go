@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"product/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+
+	os.WriteFile(configFile, []byte(`
+server:
+  port: 4000
+  route_timeouts:
+    admin: 15s
+database:
+  username: root
+  name: from_file
+auth:
+  jwt_secret: from_file
+logging:
+  level: debug
+`), 0o600)
+
+	t.Run("Load | Flags over environment over file over defaults", func(t *testing.T) {
+		t.Setenv("DB_NAME", "from_env")
+		t.Setenv("LOG_LEVEL", "warn")
+
+		cfg, args, err := config.Load([]string{"--config", configFile, "--log-level", "error", "config", "print"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"config", "print"}, args)
+		assert.Equal(t, 4000, cfg.Server.Port)
+		assert.Equal(t, "from_env", cfg.Database.Name)
+		assert.Equal(t, "error", cfg.Logging.Level)
+		assert.Equal(t, "json", cfg.Logging.Format)
+		assert.Equal(t, 15*time.Second, cfg.RouteTimeout("admin"))
+		assert.Equal(t, 5*time.Second, cfg.RouteTimeout("products"))
+	})
+
+	t.Run("Load | Route timeouts from environment", func(t *testing.T) {
+		t.Setenv("ROUTE_TIMEOUTS", "products=3s")
+
+		cfg, _, err := config.Load([]string{"--config", configFile})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3*time.Second, cfg.RouteTimeout("products"))
+	})
+
+	t.Run("Load | Error, missing jwt secret", func(t *testing.T) {
+		t.Setenv("DB_USERNAME", "root")
+		t.Setenv("DB_NAME", "product")
+
+		_, _, err := config.Load(nil)
+
+		assert.ErrorContains(t, err, "JWTSecret")
+	})
+
+	t.Run("Print | Redact secrets", func(t *testing.T) {
+		t.Setenv("DB_PASSWORD", "database-password")
+
+		cfg, _, err := config.Load([]string{"--config", configFile})
+
+		output := bytes.Buffer{}
+		cfg.Print(&output)
+
+		assert.NoError(t, err)
+		assert.Contains(t, output.String(), "port: 4000")
+		assert.Contains(t, output.String(), "admin: 15s")
+		assert.NotContains(t, output.String(), "database-password")
+		assert.Contains(t, output.String(), "jwt_secret: '******'")
+	})
+}
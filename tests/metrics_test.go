@@ -0,0 +1,43 @@
+package tests
+
+import (
+	"io"
+	"net/http/httptest"
+	"product/metrics"
+	"product/models"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMetrics(t *testing.T) {
+	appMetrics := metrics.New()
+	metricsApp := fiber.New()
+
+	metricsApp.Use(appMetrics.Middleware())
+	metricsApp.Get("/metrics", appMetrics.Handler())
+	metricsApp.Get("/products/:id", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNotFound)
+	})
+
+	productService.On("GetStats", mock.Anything).Return(models.ProductStats{Count: 2, StockValue: 15000}, nil).Once()
+
+	assert.NoError(t, appMetrics.RegisterProductStats(&productService))
+
+	t.Run("Metrics | Label requests with route template", func(t *testing.T) {
+		metricsApp.Test(httptest.NewRequest("GET", "/products/1", nil), 300000)
+		metricsApp.Test(httptest.NewRequest("GET", "/products/2", nil), 300000)
+
+		resp, _ := metricsApp.Test(httptest.NewRequest("GET", "/metrics", nil), 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Contains(t, string(body), `product_http_requests_total{method="GET",route="/products/:id",status="4xx"} 2`)
+		assert.NotContains(t, string(body), `route="/products/1"`)
+		assert.Contains(t, string(body), "product_products 2")
+		assert.Contains(t, string(body), "product_stock_value 15000")
+	})
+}
@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"product/middleware"
+	"product/utils"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIDAndLogger(t *testing.T) {
+	logs := bytes.Buffer{}
+	logApp := fiber.New()
+
+	logApp.Use(middleware.RequestID())
+	logApp.Use(middleware.Logger(utils.NewLogger(&logs, slog.LevelInfo, "json")))
+	logApp.Get("/ping", func(c *fiber.Ctx) error {
+		return c.SendString("pong")
+	})
+
+	t.Run("RequestID | Honor incoming header", func(t *testing.T) {
+		logs.Reset()
+
+		req := httptest.NewRequest("GET", "/ping", nil)
+		req.Header.Set("X-Request-ID", "abc-123")
+
+		resp, _ := logApp.Test(req, 300000)
+
+		entry := map[string]any{}
+
+		json.Unmarshal(logs.Bytes(), &entry)
+
+		assert.Equal(t, "abc-123", resp.Header.Get("X-Request-ID"))
+		assert.Equal(t, "abc-123", entry["request_id"])
+		assert.Equal(t, float64(200), entry["status"])
+		assert.Equal(t, "/ping", entry["path"])
+	})
+
+	t.Run("RequestID | Generate when missing", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/ping", nil)
+
+		resp, _ := logApp.Test(req, 300000)
+
+		assert.Len(t, resp.Header.Get("X-Request-ID"), 32)
+	})
+}
@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"product/openapi"
+	"product/router"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAPI(t *testing.T) {
+	routerApp := newRouterApp(securityConfig())
+
+	t.Run("OpenAPI | Every route is documented", func(t *testing.T) {
+		assert.Empty(t, openapi.Undocumented(routerApp, router.Operations(nil)), "document the routes in router/docs.go")
+	})
+
+	t.Run("OpenAPI | Serve document", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/openapi.json", nil)
+
+		resp, _ := routerApp.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		doc := openapi.Document{}
+
+		json.Unmarshal(body, &doc)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "3.1.0", doc.OpenAPI)
+		assert.Contains(t, doc.Paths, "/products/{id}")
+		assert.Contains(t, doc.Paths["/products/{id}"], "put")
+
+		changePassword := doc.Components.Schemas["ChangePasswordRequest"]
+
+		assert.ElementsMatch(t, []string{"current_password", "new_password"}, changePassword.Required)
+		assert.Equal(t, 6, *changePassword.Properties["new_password"].MinLength)
+		assert.Equal(t, "email", doc.Components.Schemas["UserRequest"].Properties["email"].Format)
+		assert.Equal(t, []any{"user", "admin"}, doc.Components.Schemas["CreateUserRequest"].Properties["role"].Enum)
+	})
+
+	t.Run("OpenAPI | Serve docs page", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/docs", nil)
+
+		resp, _ := routerApp.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Contains(t, string(body), "/openapi.json")
+		assert.Contains(t, resp.Header.Get("Content-Security-Policy"), "https://unpkg.com")
+	})
+}
@@ -0,0 +1,288 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"product/config"
+	"product/events"
+	"product/handlers"
+	"product/models"
+	"product/outbox"
+	"product/services"
+	"product/services/mocks"
+	"product/webhook"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// sinkFunc publishes to a function, failing the events it returns an
+// error for.
+type sinkFunc func(event events.Event) error
+
+func (f sinkFunc) Publish(ctx context.Context, event events.Event) error {
+	return f(event)
+}
+
+func outboxMessage(id uint, aggregateID uint, eventType string) models.OutboxMessage {
+	event, _ := events.New(eventType, aggregateID, events.ProductData{})
+	payload, _ := json.Marshal(event)
+
+	return models.OutboxMessage{
+		ID:            id,
+		EventID:       event.ID,
+		Type:          event.Type,
+		Aggregate:     event.Aggregate,
+		AggregateID:   aggregateID,
+		Payload:       string(payload),
+		Status:        models.OutboxPending,
+		NextAttemptAt: time.Now().Add(-time.Second),
+	}
+}
+
+func TestOutboxRelay(t *testing.T) {
+	cfg := config.OutboxConfig{
+		BatchSize:      100,
+		MaxAttempts:    3,
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	withLock := func(outboxService *mocks.OutboxService) {
+		outboxService.On("WithRelayLock", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			args.Get(1).(func(ctx context.Context) error)(context.Background())
+		}).Return(true, nil).Once()
+	}
+
+	t.Run("Relay | Publishes in order and holds back a failing aggregate", func(t *testing.T) {
+		outboxService := mocks.OutboxService{}
+		withLock(&outboxService)
+
+		messages := []models.OutboxMessage{
+			outboxMessage(1, 1, events.ProductCreated),
+			outboxMessage(2, 2, events.ProductCreated),
+			outboxMessage(3, 1, events.ProductUpdated),
+			outboxMessage(4, 2, events.ProductUpdated),
+		}
+		outboxService.On("GetPending", mock.Anything, 100).Return(messages, nil).Once()
+
+		var published []uint
+		var updated []models.OutboxMessage
+
+		record := func(args mock.Arguments) {
+			updated = append(updated, args.Get(1).(models.OutboxMessage))
+		}
+
+		outboxService.On("MarkPublished", mock.Anything, mock.Anything).Run(record).Return(nil)
+		outboxService.On("Update", mock.Anything, mock.Anything).Run(record).Return(nil)
+
+		sink := sinkFunc(func(event events.Event) error {
+			if event.AggregateID == 2 {
+				return errors.New("sink is down")
+			}
+
+			published = append(published, event.AggregateID)
+			return nil
+		})
+
+		before := time.Now()
+		err := outbox.NewRelay(&outboxService, sink, cfg, logger).Poll(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{1, 1}, published)
+		assert.Len(t, updated, 3)
+		assert.Equal(t, uint(1), updated[0].ID)
+		assert.Equal(t, models.OutboxPublished, updated[0].Status)
+		assert.NotNil(t, updated[0].PublishedAt)
+		assert.Equal(t, uint(2), updated[1].ID)
+		assert.Equal(t, models.OutboxPending, updated[1].Status)
+		assert.Equal(t, 1, updated[1].Attempts)
+		assert.Equal(t, "sink is down", updated[1].LastError)
+		assert.True(t, updated[1].NextAttemptAt.After(before))
+		assert.Equal(t, uint(3), updated[2].ID)
+		outboxService.AssertNumberOfCalls(t, "MarkPublished", 2)
+		outboxService.AssertExpectations(t)
+	})
+
+	t.Run("Relay | Waits for a message not yet due", func(t *testing.T) {
+		outboxService := mocks.OutboxService{}
+		withLock(&outboxService)
+
+		later := outboxMessage(1, 5, events.ProductCreated)
+		later.NextAttemptAt = time.Now().Add(time.Hour)
+
+		outboxService.On("GetPending", mock.Anything, 100).Return([]models.OutboxMessage{later, outboxMessage(2, 5, events.ProductUpdated)}, nil).Once()
+
+		sink := sinkFunc(func(event events.Event) error {
+			t.Errorf("unexpected publish of %s", event.Type)
+			return nil
+		})
+
+		assert.NoError(t, outbox.NewRelay(&outboxService, sink, cfg, logger).Poll(context.Background()))
+		outboxService.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Relay | Fails a message out of attempts", func(t *testing.T) {
+		outboxService := mocks.OutboxService{}
+		withLock(&outboxService)
+
+		message := outboxMessage(1, 7, events.StockLow)
+		message.Attempts = 2
+
+		outboxService.On("GetPending", mock.Anything, 100).Return([]models.OutboxMessage{message}, nil).Once()
+		outboxService.On("Update", mock.Anything, mock.MatchedBy(func(message models.OutboxMessage) bool {
+			return message.Status == models.OutboxFailed && message.Attempts == 3
+		})).Return(nil).Once()
+
+		sink := sinkFunc(func(event events.Event) error { return errors.New("rejected") })
+
+		assert.NoError(t, outbox.NewRelay(&outboxService, sink, cfg, logger).Poll(context.Background()))
+		outboxService.AssertExpectations(t)
+	})
+
+	t.Run("Relay | Skips the poll without the lock", func(t *testing.T) {
+		outboxService := mocks.OutboxService{}
+		outboxService.On("WithRelayLock", mock.Anything, mock.Anything).Return(false, nil).Once()
+
+		assert.NoError(t, outbox.NewRelay(&outboxService, outbox.NewBusSink(events.NewBus()), cfg, logger).Poll(context.Background()))
+		outboxService.AssertNotCalled(t, "GetPending", mock.Anything, mock.Anything)
+	})
+}
+
+func TestOutboxSink(t *testing.T) {
+	event, _ := events.New(events.ProductDeleted, 3, events.ProductData{})
+
+	t.Run("Sink | File appends JSON lines", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "events.jsonl")
+		sink := outbox.NewFileSink(path)
+
+		assert.NoError(t, sink.Publish(context.Background(), event))
+		assert.NoError(t, sink.Publish(context.Background(), event))
+
+		content, _ := os.ReadFile(path)
+		lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+
+		assert.Len(t, lines, 2)
+
+		written := events.Event{}
+
+		assert.NoError(t, json.Unmarshal([]byte(lines[0]), &written))
+		assert.Equal(t, event.ID, written.ID)
+		assert.Equal(t, events.ProductDeleted, written.Type)
+	})
+
+	t.Run("Sink | HTTP signs the event and fails on errors", func(t *testing.T) {
+		status := http.StatusServiceUnavailable
+		var received *http.Request
+		var body []byte
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r
+			body, _ = io.ReadAll(r.Body)
+			w.WriteHeader(status)
+		}))
+		defer server.Close()
+
+		sink := outbox.NewHTTPSink(server.URL, "0123456789abcdef", time.Second)
+
+		assert.Error(t, sink.Publish(context.Background(), event))
+
+		status = http.StatusAccepted
+
+		assert.NoError(t, sink.Publish(context.Background(), event))
+		assert.Equal(t, event.ID, received.Header.Get(webhook.HeaderDelivery))
+		assert.Equal(t, webhook.Sign("0123456789abcdef", received.Header.Get(webhook.HeaderTimestamp), body), received.Header.Get(webhook.HeaderSignature))
+	})
+}
+
+func TestOutboxHandler(t *testing.T) {
+	outboxService := mocks.OutboxService{}
+	outboxHandler := handlers.NewOutboxHandler(&outboxService)
+
+	app.Get("/outbox", outboxHandler.GetAll)
+	app.Get("/outbox/stats", outboxHandler.GetStats)
+	app.Post("/outbox/:id/retry", outboxHandler.Retry)
+	app.Post("/outbox/:id/discard", outboxHandler.Discard)
+
+	t.Run("Outbox | Search", func(t *testing.T) {
+		failed := outboxMessage(4, 2, events.ProductUpdated)
+		failed.Status = models.OutboxFailed
+
+		outboxService.On("Search", mock.Anything, models.OutboxFilter{Status: models.OutboxFailed, Aggregate: "product", AggregateID: 2}, 1, 10).Return([]models.OutboxMessage{failed}, int64(1), nil).Once()
+
+		req := httptest.NewRequest("GET", "/outbox?status=failed&aggregate=product&aggregate_id=2", nil)
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Contains(t, string(body), `"status":"failed"`)
+		assert.Contains(t, string(body), `"total":1`)
+	})
+
+	t.Run("Outbox | Search with an unknown status", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/outbox?status=stuck", nil)
+
+		resp, _ := app.Test(req, 300000)
+
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	t.Run("Outbox | Stats", func(t *testing.T) {
+		outboxService.On("GetStats", mock.Anything).Return(models.OutboxStats{Pending: 3, Retrying: 1, Failed: 1}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/outbox/stats", nil)
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Contains(t, string(body), `"pending":3,"retrying":1,"failed":1`)
+	})
+
+	t.Run("Outbox | Retry", func(t *testing.T) {
+		retried := outboxMessage(4, 2, events.ProductUpdated)
+
+		outboxService.On("Retry", mock.Anything, "4").Return(retried, nil).Once()
+
+		req := httptest.NewRequest("POST", "/outbox/4/retry", nil)
+
+		resp, _ := app.Test(req, 300000)
+
+		assert.Equal(t, 200, resp.StatusCode)
+	})
+
+	t.Run("Outbox | Retry a message not failed", func(t *testing.T) {
+		outboxService.On("Retry", mock.Anything, "5").Return(models.OutboxMessage{}, services.ErrOutboxStatus).Once()
+
+		req := httptest.NewRequest("POST", "/outbox/5/retry", nil)
+
+		resp, _ := app.Test(req, 300000)
+
+		assert.Equal(t, 409, resp.StatusCode)
+	})
+
+	t.Run("Outbox | Discard a missing message", func(t *testing.T) {
+		outboxService.On("Discard", mock.Anything, "6").Return(models.OutboxMessage{}, gorm.ErrRecordNotFound).Once()
+
+		req := httptest.NewRequest("POST", "/outbox/6/discard", nil)
+
+		resp, _ := app.Test(req, 300000)
+
+		assert.Equal(t, 404, resp.StatusCode)
+	})
+}
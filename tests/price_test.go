@@ -0,0 +1,214 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"product/cache"
+	"product/handlers"
+	"product/models"
+	"product/services"
+	"product/services/mocks"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestPriceSchedule(t *testing.T) {
+	start := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := func(n int) *time.Time {
+		at := start.AddDate(0, 0, n)
+		return &at
+	}
+
+	t.Run("Schedule | Promotions overlap", func(t *testing.T) {
+		promo := models.PriceSchedule{StartsAt: start, EndsAt: day(7)}
+
+		assert.True(t, promo.Overlaps(models.PriceSchedule{StartsAt: *day(3), EndsAt: day(10)}))
+		assert.False(t, promo.Overlaps(models.PriceSchedule{StartsAt: *day(7), EndsAt: day(10)}))
+	})
+
+	t.Run("Schedule | Change during a promotion", func(t *testing.T) {
+		promo := models.PriceSchedule{StartsAt: start, EndsAt: day(7)}
+
+		assert.True(t, promo.Overlaps(models.PriceSchedule{StartsAt: *day(2)}))
+		assert.False(t, promo.Overlaps(models.PriceSchedule{StartsAt: *day(8)}))
+	})
+
+	t.Run("Schedule | Changes at the same time", func(t *testing.T) {
+		change := models.PriceSchedule{StartsAt: start}
+
+		assert.True(t, change.Overlaps(models.PriceSchedule{StartsAt: start}))
+		assert.False(t, change.Overlaps(models.PriceSchedule{StartsAt: *day(1)}))
+	})
+
+	t.Run("Schedule | Validate", func(t *testing.T) {
+		valid := models.PriceScheduleRequest{Price: 1000, StartsAt: start, EndsAt: day(1)}
+		endsFirst := models.PriceScheduleRequest{Price: 1000, StartsAt: start, EndsAt: day(-1)}
+		free := models.PriceScheduleRequest{StartsAt: start}
+
+		assert.NoError(t, valid.Validate())
+		assert.Error(t, endsFirst.Validate())
+		assert.Error(t, free.Validate())
+	})
+}
+
+func TestPriceHandler(t *testing.T) {
+	productService := mocks.ProductService{}
+	priceService := mocks.PriceService{}
+	priceHandler := handlers.NewPriceHandler(&productService, &priceService)
+
+	app.Get("/pricing/:id/prices", priceHandler.GetHistory)
+	app.Get("/pricing/:id/price", priceHandler.GetPriceAt)
+	app.Get("/pricing/:id/price-schedules", priceHandler.GetSchedules)
+	app.Post("/pricing/:id/price-schedules", priceHandler.CreateSchedule)
+	app.Delete("/pricing/:id/price-schedules/:scheduleId", priceHandler.CancelSchedule)
+
+	product := models.Product{ID: 1, Name: "Permen", Price: 1500}
+
+	t.Run("Prices | History", func(t *testing.T) {
+		productService.On("GetByCondition", mock.Anything, "id", "1").Return(product, nil).Once()
+		priceService.On("GetHistory", mock.Anything, uint(1), 1, 10).Return([]models.PriceChange{
+			{ID: 2, ProductID: 1, OldPrice: 1000, Price: 1500, Source: models.PriceUpdated, ActorEmail: "andi@gmail.com"},
+		}, int64(2), nil).Once()
+
+		resp, _ := app.Test(httptest.NewRequest("GET", "/pricing/1/prices", nil), 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Contains(t, string(body), `"old_price":1000`)
+		assert.Contains(t, string(body), `"actor_email":"andi@gmail.com"`)
+		assert.Contains(t, string(body), `"total":2`)
+	})
+
+	t.Run("Prices | History of a missing product", func(t *testing.T) {
+		productService.On("GetByCondition", mock.Anything, "id", "9").Return(models.Product{}, gorm.ErrRecordNotFound).Once()
+
+		resp, _ := app.Test(httptest.NewRequest("GET", "/pricing/9/prices", nil), 300000)
+
+		assert.Equal(t, 404, resp.StatusCode)
+	})
+
+	t.Run("Prices | Price at a time", func(t *testing.T) {
+		at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+		productService.On("GetByCondition", mock.Anything, "id", "1").Return(product, nil).Once()
+		priceService.On("GetPriceAt", mock.Anything, product, at).Return(1000, nil).Once()
+
+		resp, _ := app.Test(httptest.NewRequest("GET", "/pricing/1/price?at=2024-05-01T12:00:00Z", nil), 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Contains(t, string(body), `"price":1000`)
+	})
+
+	t.Run("Prices | Price at an invalid time", func(t *testing.T) {
+		resp, _ := app.Test(httptest.NewRequest("GET", "/pricing/1/price?at=yesterday", nil), 300000)
+
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	t.Run("Prices | Price before the product existed", func(t *testing.T) {
+		productService.On("GetByCondition", mock.Anything, "id", "1").Return(product, nil).Once()
+		priceService.On("GetPriceAt", mock.Anything, product, mock.Anything).Return(0, services.ErrNoPrice).Once()
+
+		resp, _ := app.Test(httptest.NewRequest("GET", "/pricing/1/price?at=2001-01-01T00:00:00Z", nil), 300000)
+
+		assert.Equal(t, 404, resp.StatusCode)
+	})
+
+	t.Run("Prices | Schedule", func(t *testing.T) {
+		startsAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		endsAt := startsAt.Add(24 * time.Hour)
+
+		productService.On("GetByCondition", mock.Anything, "id", "1").Return(product, nil).Once()
+		priceService.On("CreateSchedule", mock.Anything, mock.Anything, mock.MatchedBy(func(schedule models.PriceSchedule) bool {
+			return schedule.ProductID == 1 && schedule.Price == 1200 && schedule.StartsAt.Equal(startsAt) && schedule.EndsAt.Equal(endsAt)
+		})).Return(models.PriceSchedule{ID: 3, ProductID: 1, Price: 1200, StartsAt: startsAt, EndsAt: &endsAt, Status: models.ScheduleScheduled}, nil).Once()
+
+		req := httptest.NewRequest("POST", "/pricing/1/price-schedules", strings.NewReader(
+			`{"price":1200,"starts_at":"`+startsAt.Format(time.RFC3339)+`","ends_at":"`+endsAt.Format(time.RFC3339)+`"}`,
+		))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, 201, resp.StatusCode)
+		assert.Contains(t, string(body), `"status":"scheduled"`)
+		priceService.AssertExpectations(t)
+	})
+
+	t.Run("Prices | Schedule in the past", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/pricing/1/price-schedules", strings.NewReader(`{"price":1200,"starts_at":"2020-01-01T00:00:00Z"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := app.Test(req, 300000)
+
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	t.Run("Prices | Overlapping schedule", func(t *testing.T) {
+		startsAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+		productService.On("GetByCondition", mock.Anything, "id", "1").Return(product, nil).Once()
+		priceService.On("CreateSchedule", mock.Anything, mock.Anything, mock.Anything).Return(models.PriceSchedule{}, services.ErrScheduleConflict).Once()
+
+		req := httptest.NewRequest("POST", "/pricing/1/price-schedules", strings.NewReader(`{"price":1200,"starts_at":"`+startsAt+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := app.Test(req, 300000)
+
+		assert.Equal(t, 409, resp.StatusCode)
+	})
+
+	t.Run("Prices | Cancel a started schedule", func(t *testing.T) {
+		productService.On("GetByCondition", mock.Anything, "id", "1").Return(product, nil).Once()
+		priceService.On("CancelSchedule", mock.Anything, mock.Anything, uint(1), "3").Return(models.PriceSchedule{}, services.ErrScheduleStatus).Once()
+
+		resp, _ := app.Test(httptest.NewRequest("DELETE", "/pricing/1/price-schedules/3", nil), 300000)
+
+		assert.Equal(t, 409, resp.StatusCode)
+	})
+
+	t.Run("Prices | Cancel a missing schedule", func(t *testing.T) {
+		productService.On("GetByCondition", mock.Anything, "id", "1").Return(product, nil).Once()
+		priceService.On("CancelSchedule", mock.Anything, mock.Anything, uint(1), "8").Return(models.PriceSchedule{}, gorm.ErrRecordNotFound).Once()
+
+		resp, _ := app.Test(httptest.NewRequest("DELETE", "/pricing/1/price-schedules/8", nil), 300000)
+
+		assert.Equal(t, 404, resp.StatusCode)
+	})
+}
+
+func TestCachedPriceService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Cached | Applied schedules invalidate the listings", func(t *testing.T) {
+		productService := mocks.ProductService{}
+		priceService := mocks.PriceService{}
+		productCache := newTestCache(cache.NewMemoryStore(10), time.Minute)
+		cached := services.NewCachedProductService(&productService, productCache)
+		cachedPrice := services.NewCachedPriceService(&priceService, productCache)
+
+		productService.On("GetAll", mock.Anything).Return([]models.Product{}, nil).Twice()
+		priceService.On("ApplyDue", mock.Anything, mock.Anything).Return(0, nil).Once()
+		priceService.On("ApplyDue", mock.Anything, mock.Anything).Return(1, errors.New("database is down")).Once()
+
+		cached.GetAll(ctx)
+		cachedPrice.ApplyDue(ctx, time.Now())
+		cached.GetAll(ctx)
+		cachedPrice.ApplyDue(ctx, time.Now())
+		cached.GetAll(ctx)
+
+		productService.AssertExpectations(t)
+	})
+}
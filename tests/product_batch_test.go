@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"product/handlers"
+	"product/models"
+	"product/services/mocks"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type BatchResponseFormat struct {
+	Data    models.BatchReport `json:"data"`
+	Message string             `json:"message"`
+}
+
+func TestProductBatch(t *testing.T) {
+	batchService := mocks.ProductService{}
+	batchHandler := handlers.NewProductBatchHandler(&batchService, 2)
+	operator := models.User{ID: 9, Email: "operator@example.com", Role: models.RoleUser}
+
+	app.Post("/catalog/batch", withUser(operator), batchHandler.Apply)
+
+	send := func(query string, body string) (int, BatchResponseFormat) {
+		req := httptest.NewRequest("POST", "/catalog/batch"+query, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := app.Test(req, 300000)
+
+		respBody, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := BatchResponseFormat{}
+
+		json.Unmarshal(respBody, &bodyResponse)
+
+		return resp.StatusCode, bodyResponse
+	}
+
+	operations := `[{"op":"update","id":1,"product":{"name":"Permen","description":"permen terenak","price":1200,"stock":10}},{"op":"delete","id":2}]`
+
+	t.Run("Batch | Validate operations", func(t *testing.T) {
+		update := models.BatchOperation{Op: models.BatchUpdate, ID: 1}
+		deletion := models.BatchOperation{Op: models.BatchDelete, ID: 1}
+		creation := models.BatchOperation{Op: models.BatchCreate, Product: &models.ProductRequest{Name: "Permen"}}
+
+		assert.Error(t, update.Validate())
+		assert.NoError(t, deletion.Validate())
+		assert.Error(t, creation.Validate())
+	})
+
+	t.Run("Batch | All or nothing succeeds", func(t *testing.T) {
+		batchService.On("Batch", mock.Anything, mock.MatchedBy(func(actor models.Actor) bool { return actor.ID == 9 }), mock.MatchedBy(func(operations []models.BatchOperation) bool {
+			return len(operations) == 2 && operations[0].Product.Price == 1200 && operations[1].ID == 2
+		}), models.BatchAllOrNothing).Return(models.BatchReport{Mode: models.BatchAllOrNothing, Succeeded: 2}, nil).Once()
+
+		status, body := send("", operations)
+
+		assert.Equal(t, 200, status)
+		assert.Equal(t, 2, body.Data.Succeeded)
+	})
+
+	t.Run("Batch | All or nothing rolled back", func(t *testing.T) {
+		batchService.On("Batch", mock.Anything, mock.Anything, mock.Anything, models.BatchAllOrNothing).Return(models.BatchReport{Mode: models.BatchAllOrNothing, Failed: 2, Results: []models.BatchResult{
+			{Index: 0, Op: models.BatchUpdate, Status: 424},
+			{Index: 1, Op: models.BatchDelete, Status: 404, Error: "product is not found"},
+		}}, nil).Once()
+
+		status, body := send("", operations)
+
+		assert.Equal(t, 422, status)
+		assert.Equal(t, "batch rejected, no operation was applied", body.Message)
+		assert.Equal(t, 404, body.Data.Results[1].Status)
+	})
+
+	t.Run("Batch | Per item answers multi-status", func(t *testing.T) {
+		batchService.On("Batch", mock.Anything, mock.Anything, mock.Anything, models.BatchPerItem).Return(models.BatchReport{Mode: models.BatchPerItem, Succeeded: 1, Failed: 1}, nil).Once()
+
+		status, body := send("?mode=per_item", operations)
+
+		assert.Equal(t, 207, status)
+		assert.Equal(t, "1 of 2 operations applied", body.Message)
+	})
+
+	t.Run("Batch | Too many operations", func(t *testing.T) {
+		status, body := send("", `[{"op":"delete","id":1},{"op":"delete","id":2},{"op":"delete","id":3}]`)
+
+		assert.Equal(t, 413, status)
+		assert.Equal(t, "batch is limited to 2 operations", body.Message)
+	})
+
+	t.Run("Batch | Empty", func(t *testing.T) {
+		status, _ := send("", `[]`)
+
+		assert.Equal(t, 400, status)
+	})
+
+	t.Run("Batch | Unknown mode", func(t *testing.T) {
+		status, _ := send("?mode=sometimes", operations)
+
+		assert.Equal(t, 400, status)
+	})
+
+	batchService.AssertExpectations(t)
+}
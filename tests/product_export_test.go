@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"product/catalog"
+	"product/config"
+	"product/handlers"
+	"product/models"
+	"product/services/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func eachProduct(products ...models.Product) func(args mock.Arguments) {
+	return func(args mock.Arguments) {
+		fn := args.Get(2).(func(models.Product) error)
+
+		for _, product := range products {
+			fn(product)
+		}
+	}
+}
+
+func TestProductExport(t *testing.T) {
+	exportService := mocks.ProductService{}
+	exportHandler := handlers.NewProductHandler(&exportService, &mocks.CurrencyService{}, usd)
+	sku := "P-1"
+	exported := []models.Product{
+		{ID: 1, SKU: &sku, Name: "Permen", Description: "permen, terenak", Price: 1000, Stock: 10, UserID: 9},
+		{ID: 2, Name: "Coklat", Price: 2500, Stock: 3, UserID: 9},
+	}
+
+	app.Get("/catalog/export", exportHandler.Export)
+
+	t.Run("Export | CSV", func(t *testing.T) {
+		exportService.On("Each", mock.Anything, "", mock.Anything).Run(eachProduct(exported...)).Return(nil).Once()
+
+		req := httptest.NewRequest("GET", "/catalog/export", nil)
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+		assert.Contains(t, resp.Header.Get("Content-Disposition"), `attachment; filename="products-`)
+		assert.Equal(t, "id,sku,name,description,price,stock,user_id\n1,P-1,Permen,\"permen, terenak\",1000,10,9\n2,,Coklat,,2500,3,9\n", string(body))
+	})
+
+	t.Run("Export | JSON Lines with selected columns and filter", func(t *testing.T) {
+		exportService.On("Each", mock.Anything, "coklat", mock.Anything).Run(eachProduct(exported[1])).Return(nil).Once()
+
+		req := httptest.NewRequest("GET", "/catalog/export?format=jsonl&columns=name,price&q=coklat", nil)
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+		assert.Equal(t, "{\"name\":\"Coklat\",\"price\":2500}\n", string(body))
+	})
+
+	t.Run("Export | XLSX", func(t *testing.T) {
+		exportService.On("Each", mock.Anything, "", mock.Anything).Run(eachProduct(exported...)).Return(nil).Once()
+
+		req := httptest.NewRequest("GET", "/catalog/export?format=xlsx&columns=sku,name", nil)
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, 200, resp.StatusCode)
+
+		archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+
+		assert.NoError(t, err)
+
+		sheet, err := archive.Open("xl/worksheets/sheet1.xml")
+
+		assert.NoError(t, err)
+
+		content, _ := io.ReadAll(sheet)
+
+		assert.Contains(t, string(content), `<t xml:space="preserve">P-1</t></is></c><c t="inlineStr"><is><t xml:space="preserve">Permen</t>`)
+		assert.Contains(t, string(content), `<t xml:space="preserve">Coklat</t></is></c></row>`)
+		assert.NotContains(t, string(content), "2500")
+	})
+
+	t.Run("Export | Unknown format", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/catalog/export?format=pdf", nil)
+
+		resp, _ := app.Test(req, 300000)
+
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	t.Run("Export | Unknown column", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/catalog/export?columns=name,colour", nil)
+
+		resp, _ := app.Test(req, 300000)
+
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	t.Run("Export | Scheduled", func(t *testing.T) {
+		exportService.On("Each", mock.Anything, "permen", mock.Anything).Run(eachProduct(exported[0])).Return(nil).Once()
+
+		dir := t.TempDir()
+		scheduler := catalog.NewScheduler(&exportService, config.ExportConfig{
+			Interval:  time.Hour,
+			Directory: dir,
+			Format:    catalog.FormatCSV,
+			Columns:   []string{"sku", "stock"},
+			Query:     "permen",
+		}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+		path, err := scheduler.Export(context.Background(), time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC))
+
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "products-20240501T103000Z.csv"), path)
+
+		content, _ := os.ReadFile(path)
+		entries, _ := os.ReadDir(dir)
+
+		assert.Equal(t, "sku,stock\nP-1,10\n", string(content))
+		assert.Len(t, entries, 1)
+	})
+}
@@ -8,14 +8,19 @@ import (
 	"net/http/httptest"
 	"product/handlers"
 	"product/models"
+	"product/money"
 	"product/services/mocks"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 var productService = mocks.ProductService{}
-var productHandler = handlers.NewProductHandler(&productService)
+var currencyService = mocks.CurrencyService{}
+var usd, _ = money.Lookup("USD")
+var productHandler = handlers.NewProductHandler(&productService, &currencyService, usd)
 
 var productModel = models.Product{
 	ID:          1,
@@ -34,7 +39,7 @@ var productRequest = models.ProductRequest{
 
 func TestGetAllProduct(t *testing.T) {
 	t.Run("GetAll | Success", func(t *testing.T) {
-		productService.On("GetAll").Return([]models.Product{productModel}, nil).Once()
+		productService.On("GetAll", mock.Anything).Return([]models.Product{productModel}, nil).Once()
 
 		app.Get("/products", productHandler.GetAll)
 
@@ -53,7 +58,7 @@ func TestGetAllProduct(t *testing.T) {
 	})
 
 	t.Run("GetAll | Success but empty", func(t *testing.T) {
-		productService.On("GetAll").Return([]models.Product{}, nil).Once()
+		productService.On("GetAll", mock.Anything).Return([]models.Product{}, nil).Once()
 
 		app.Get("/products", productHandler.GetAll)
 
@@ -74,7 +79,7 @@ func TestGetAllProduct(t *testing.T) {
 
 func TestCreateProduct(t *testing.T) {
 	t.Run("Create | Success", func(t *testing.T) {
-		productService.On("Create", productRequest.ConvertToProduct()).Return(productModel, nil).Once()
+		productService.On("Create", mock.Anything, mock.Anything, productRequest.ConvertToProduct()).Return(productModel, nil).Once()
 
 		app.Post("/create", productHandler.Create)
 
@@ -95,8 +100,32 @@ func TestCreateProduct(t *testing.T) {
 		assert.Equal(t, "successfully create product", bodyResponse.Message)
 	})
 
+	t.Run("Create | Error, sku has been used", func(t *testing.T) {
+		duplicate := models.ProductRequest{Name: "Permen", Description: "permen terenak", Price: 1000, Stock: 10, SKU: "DUP-1"}
+
+		productService.On("Create", mock.Anything, mock.Anything, duplicate.ConvertToProduct()).Return(models.Product{}, gorm.ErrDuplicatedKey).Once()
+
+		app.Post("/duplicate", productHandler.Create)
+
+		productReq, _ := json.Marshal(duplicate)
+
+		req := httptest.NewRequest("POST", "/duplicate", bytes.NewBuffer(productReq))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 409, resp.StatusCode)
+		assert.Equal(t, "sku has been used", bodyResponse.Message)
+	})
+
 	t.Run("Create | Error, bed request body", func(t *testing.T) {
-		productService.On("Create", "1", productModel).Return(productModel, nil).Once()
+		productService.On("Create", mock.Anything, mock.Anything, "1", productModel).Return(productModel, nil).Once()
 
 		app.Post("/products/:id", productHandler.Create)
 
@@ -116,7 +145,7 @@ func TestCreateProduct(t *testing.T) {
 	})
 
 	t.Run("Create | Error internal server error", func(t *testing.T) {
-		productService.On("Create", productRequest.ConvertToProduct()).Return(models.Product{}, errors.New("error")).Once()
+		productService.On("Create", mock.Anything, mock.Anything, productRequest.ConvertToProduct()).Return(models.Product{}, errors.New("error")).Once()
 
 		app.Post("/create", productHandler.Create)
 
@@ -140,8 +169,7 @@ func TestCreateProduct(t *testing.T) {
 
 func TestUpdateProduct(t *testing.T) {
 	t.Run("Update | Success", func(t *testing.T) {
-		productService.On("Update", "1", productModel).Return(productModel, nil).Once()
-		productService.On("GetByCondition", "id", "1").Return(productModel, nil).Once()
+		productService.On("Update", mock.Anything, mock.Anything, "1", productRequest).Return(productModel, nil).Once()
 
 		app.Put("/products/:id", productHandler.Update)
 
@@ -163,9 +191,6 @@ func TestUpdateProduct(t *testing.T) {
 	})
 
 	t.Run("Update | Error, bed request body", func(t *testing.T) {
-		productService.On("Update", "1", productModel).Return(productModel, nil).Once()
-		productService.On("GetByCondition", "id", "1").Return(productModel, nil).Once()
-
 		app.Put("/products/:id", productHandler.Update)
 
 		req := httptest.NewRequest("PUT", "/products/2", nil)
@@ -184,8 +209,7 @@ func TestUpdateProduct(t *testing.T) {
 	})
 
 	t.Run("Update | Error, bad request id param", func(t *testing.T) {
-		productService.On("Update", "1", productModel).Return(models.Product{}, errors.New("error")).Once()
-		productService.On("GetByCondition", "id", "2").Return(models.Product{}, errors.New("error")).Once()
+		productService.On("Update", mock.Anything, mock.Anything, "2", productRequest).Return(models.Product{}, gorm.ErrRecordNotFound).Once()
 
 		app.Put("/products/:id", productHandler.Update)
 
@@ -209,8 +233,8 @@ func TestUpdateProduct(t *testing.T) {
 
 func TestDeleteProduct(t *testing.T) {
 	t.Run("Delete | Success", func(t *testing.T) {
-		productService.On("Delete", productModel).Return(nil).Once()
-		productService.On("GetByCondition", "id", "1").Return(productModel, nil).Once()
+		productService.On("Delete", mock.Anything, mock.Anything, productModel).Return(nil).Once()
+		productService.On("GetByCondition", mock.Anything, "id", "1").Return(productModel, nil).Once()
 
 		app.Delete("/products/:id", productHandler.Delete)
 
@@ -230,8 +254,8 @@ func TestDeleteProduct(t *testing.T) {
 	})
 
 	t.Run("Delete | Error, bad request id param", func(t *testing.T) {
-		productService.On("Delete", models.Product{}).Return(nil).Once()
-		productService.On("GetByCondition", "id", "2").Return(models.Product{}, errors.New("error")).Once()
+		productService.On("Delete", mock.Anything, mock.Anything, models.Product{}).Return(nil).Once()
+		productService.On("GetByCondition", mock.Anything, "id", "2").Return(models.Product{}, errors.New("error")).Once()
 
 		app.Delete("/products/:id", productHandler.Delete)
 
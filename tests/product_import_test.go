@@ -0,0 +1,233 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"os"
+	"product/catalog"
+	"product/handlers"
+	"product/middleware"
+	"product/models"
+	"product/services/mocks"
+	"product/utils"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type ImportResponseFormat struct {
+	Data    models.ImportReport `json:"data"`
+	Message string              `json:"message"`
+}
+
+type ImportJobResponseFormat struct {
+	Data    models.ImportJobResponse `json:"data"`
+	Message string                   `json:"message"`
+}
+
+const importCSV = "sku,name,description,price,stock\nP-1,Permen,permen terenak,1000,10\nP-2,Coklat,,abc,5\n"
+
+func TestProductImport(t *testing.T) {
+	importService := mocks.ProductImportService{}
+	background := utils.NewBackground()
+	importHandler := handlers.NewProductImportHandler(&importService, background, 1000)
+	importer := models.User{ID: 9, Email: "importer@example.com", Role: models.RoleUser}
+
+	app.Post("/catalog/import", withUser(importer), importHandler.Import)
+	app.Get("/catalog/imports/:id", withUser(importer), importHandler.GetJob)
+
+	t.Run("Import | Parse CSV", func(t *testing.T) {
+		rows := spoolRows(t, importCSV, catalog.FormatCSV)
+
+		assert.Len(t, rows, 2)
+		assert.Equal(t, models.ProductRequest{SKU: "P-1", Name: "Permen", Description: "permen terenak", Price: 1000, Stock: 10}, rows[0].Request)
+		assert.Equal(t, 3, rows[1].Line)
+		assert.Equal(t, "price must be an integer", rows[1].Error)
+	})
+
+	t.Run("Import | Parse JSON Lines", func(t *testing.T) {
+		rows := spoolRows(t, "{\"sku\":\"P-1\",\"name\":\"Permen\",\"price\":1000}\n\n{\"colour\":\"red\"}\n", catalog.FormatJSONL)
+
+		assert.Len(t, rows, 2)
+		assert.Equal(t, "Permen", rows[0].Request.Name)
+		assert.Equal(t, 3, rows[1].Line)
+		assert.Contains(t, rows[1].Error, "unknown field")
+	})
+
+	t.Run("Import | Per row", func(t *testing.T) {
+		report := models.ImportReport{Mode: models.ImportPerRow, Total: 2, Created: 1, Failed: 1}
+		importService.On("Import", mock.Anything, mock.Anything, mock.MatchedBy(func(rows models.ImportSource) bool { return countRows(rows) == 2 }), models.ImportOptions{Mode: models.ImportPerRow}, mock.Anything).Return(report, nil).Once()
+
+		req := httptest.NewRequest("POST", "/catalog/import?mode=per_row", strings.NewReader(importCSV))
+		req.Header.Set("Content-Type", "text/csv")
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ImportResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "successfully import products", bodyResponse.Message)
+		assert.Equal(t, 1, bodyResponse.Data.Created)
+	})
+
+	t.Run("Import | Streamed body", func(t *testing.T) {
+		streamApp := fiber.New(fiber.Config{StreamRequestBody: true, DisablePreParseMultipartForm: true, BodyLimit: 1 << 20})
+		streamApp.Use(middleware.BodyLimit(1 << 20))
+		streamApp.Post("/catalog/import", withUser(importer), importHandler.Import)
+
+		upload := "sku,name,price\n" + strings.Repeat("P-1,Permen,1000\n", 900)
+		report := models.ImportReport{Mode: models.ImportPerRow, Total: 900, Updated: 900}
+		importService.On("Import", mock.Anything, mock.Anything, mock.MatchedBy(func(rows models.ImportSource) bool { return countRows(rows) == 900 }), models.ImportOptions{Mode: models.ImportPerRow}, mock.Anything).Return(report, nil).Once()
+
+		req := httptest.NewRequest("POST", "/catalog/import?mode=per_row", strings.NewReader(upload))
+		req.Header.Set("Content-Type", "text/csv")
+
+		resp, _ := streamApp.Test(req, 300000)
+
+		assert.Equal(t, 200, resp.StatusCode)
+
+		req = httptest.NewRequest("POST", "/catalog/import?mode=per_row", strings.NewReader(strings.Repeat(upload, 100)))
+		req.Header.Set("Content-Type", "text/csv")
+
+		resp, _ = streamApp.Test(req, 300000)
+
+		assert.Equal(t, 413, resp.StatusCode)
+	})
+
+	t.Run("Import | Reject all or nothing", func(t *testing.T) {
+		report := models.ImportReport{Mode: models.ImportAllOrNothing, Total: 2, Failed: 1, Errors: []models.ImportRowError{{Line: 3, SKU: "P-2", Message: "price must be an integer"}}}
+		importService.On("Import", mock.Anything, mock.Anything, mock.Anything, models.ImportOptions{Mode: models.ImportAllOrNothing, DryRun: true}, mock.Anything).Return(report, nil).Once()
+
+		req := httptest.NewRequest("POST", "/catalog/import?dry_run=true", strings.NewReader(importCSV))
+		req.Header.Set("Content-Type", "text/csv")
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ImportResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 422, resp.StatusCode)
+		assert.Equal(t, "import rejected, no product was saved", bodyResponse.Message)
+		assert.Equal(t, 3, bodyResponse.Data.Errors[0].Line)
+	})
+
+	t.Run("Import | Invalid mode", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/catalog/import?mode=some", strings.NewReader(importCSV))
+		req.Header.Set("Content-Type", "text/csv")
+
+		resp, _ := app.Test(req, 300000)
+
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	t.Run("Import | Unknown format", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/catalog/import", strings.NewReader(importCSV))
+		req.Header.Set("Content-Type", "application/xml")
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 400, resp.StatusCode)
+		assert.Equal(t, catalog.ErrUnknownFormat.Error(), bodyResponse.Message)
+	})
+
+	t.Run("Import | Background job", func(t *testing.T) {
+		job := models.ImportJob{ID: 5, UserID: importer.ID, Status: models.ImportPending, Report: models.ImportReport{Mode: models.ImportPerRow, Total: 2}}
+		report := models.ImportReport{Mode: models.ImportPerRow, Total: 2, Created: 1, Failed: 1}
+
+		importService.On("CreateJob", mock.Anything, mock.Anything).Return(job, nil).Once()
+		var spooled string
+
+		importService.On("Import", mock.Anything, mock.Anything, mock.Anything, models.ImportOptions{Mode: models.ImportPerRow}, mock.Anything).Run(func(args mock.Arguments) {
+			// The upload is read from its spooled file after the request.
+			source := args.Get(2).(catalog.FileSource)
+			spooled = source.Path
+
+			assert.Equal(t, 2, countRows(source))
+			args.Get(4).(func(int))(2)
+		}).Return(report, nil).Once()
+		importService.On("UpdateJob", mock.Anything, mock.MatchedBy(func(job models.ImportJob) bool { return job.Status == models.ImportRunning })).Return(nil).Once()
+		importService.On("UpdateJob", mock.Anything, mock.MatchedBy(func(job models.ImportJob) bool {
+			return job.Status == models.ImportSucceeded && job.Processed == 2 && job.FinishedAt != nil
+		})).Return(nil).Once()
+
+		req := httptest.NewRequest("POST", "/catalog/import?mode=per_row&async=true", strings.NewReader(importCSV))
+		req.Header.Set("Content-Type", "text/csv")
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ImportJobResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 202, resp.StatusCode)
+		assert.Equal(t, "/catalog/imports/5", resp.Header.Get("Location"))
+		assert.Equal(t, models.ImportPending, bodyResponse.Data.Status)
+
+		background.Shutdown(context.Background())
+
+		importService.AssertExpectations(t)
+
+		_, err := os.Stat(spooled)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("Import | Job of another user", func(t *testing.T) {
+		importService.On("GetJob", mock.Anything, "6").Return(models.ImportJob{ID: 6, UserID: 1}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/catalog/imports/6", nil)
+
+		resp, _ := app.Test(req, 300000)
+
+		assert.Equal(t, 404, resp.StatusCode)
+	})
+}
+
+// spoolRows reads the rows of an upload through its spooled file.
+func spoolRows(t *testing.T, upload string, format string) []models.ImportRow {
+	source, err := catalog.Spool(strings.NewReader(upload), format)
+
+	assert.NoError(t, err)
+
+	defer source.Remove()
+
+	var rows []models.ImportRow
+
+	assert.NoError(t, source.Each(func(row models.ImportRow) error {
+		rows = append(rows, row)
+		return nil
+	}))
+
+	return rows
+}
+
+// countRows reads rows through.
+func countRows(rows models.ImportSource) int {
+	count := 0
+
+	rows.Each(func(models.ImportRow) error {
+		count++
+		return nil
+	})
+
+	return count
+}
@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"product/middleware"
+	"product/models"
+	"product/ratelimit"
+	"product/utils"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	t.Run("RateLimit | Reject requests over the limit", func(t *testing.T) {
+		limited := fiber.New()
+		policy := ratelimit.Policy{Limit: 2, Period: time.Minute, Key: ratelimit.KeyIP}
+
+		limited.Get("/limited", middleware.RateLimit(ratelimit.NewMemoryStore(), "test", func() ratelimit.Policy { return policy }), func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusOK)
+		})
+
+		for i := 0; i < 2; i++ {
+			resp, _ := limited.Test(httptest.NewRequest("GET", "/limited", nil), 300000)
+
+			assert.Equal(t, 200, resp.StatusCode)
+			assert.Equal(t, "2", resp.Header.Get("RateLimit-Limit"))
+		}
+
+		resp, _ := limited.Test(httptest.NewRequest("GET", "/limited", nil), 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 429, resp.StatusCode)
+		assert.Equal(t, "too many requests", bodyResponse.Message)
+		assert.Equal(t, "0", resp.Header.Get("RateLimit-Remaining"))
+		assert.Equal(t, "30", resp.Header.Get(fiber.HeaderRetryAfter))
+	})
+
+	t.Run("RateLimit | Count users separately", func(t *testing.T) {
+		limited := fiber.New()
+		store := ratelimit.NewMemoryStore()
+		policy := ratelimit.Policy{Limit: 1, Period: time.Minute, Key: ratelimit.KeyUser}
+		handler := func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusOK)
+		}
+
+		limited.Get("/first", withUser(models.User{ID: 1}), middleware.RateLimit(store, "test", func() ratelimit.Policy { return policy }), handler)
+		limited.Get("/second", withUser(models.User{ID: 2}), middleware.RateLimit(store, "test", func() ratelimit.Policy { return policy }), handler)
+
+		resp, _ := limited.Test(httptest.NewRequest("GET", "/first", nil), 300000)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		resp, _ = limited.Test(httptest.NewRequest("GET", "/second", nil), 300000)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		resp, _ = limited.Test(httptest.NewRequest("GET", "/first", nil), 300000)
+		assert.Equal(t, 429, resp.StatusCode)
+	})
+
+	t.Run("RateLimit | Disabled policy", func(t *testing.T) {
+		limited := fiber.New()
+
+		limited.Get("/unlimited", middleware.RateLimit(ratelimit.NewMemoryStore(), "test", func() ratelimit.Policy { return ratelimit.Policy{} }), func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusOK)
+		})
+
+		for i := 0; i < 3; i++ {
+			resp, _ := limited.Test(httptest.NewRequest("GET", "/unlimited", nil), 300000)
+
+			assert.Equal(t, 200, resp.StatusCode)
+			assert.Empty(t, resp.Header.Get("RateLimit-Limit"))
+		}
+	})
+
+	t.Run("RateLimit | Count recognised API keys separately", func(t *testing.T) {
+		limited := fiber.New()
+		policy := ratelimit.Policy{Limit: 1, Period: time.Minute, Key: ratelimit.KeyAPIKey}
+
+		limited.Use(middleware.APIKey(func() []string { return []string{utils.HashToken("first"), utils.HashToken("second")} }))
+		limited.Get("/keyed", middleware.RateLimit(ratelimit.NewMemoryStore(), "test", func() ratelimit.Policy { return policy }), func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusOK)
+		})
+
+		request := func(apiKey string) int {
+			req := httptest.NewRequest("GET", "/keyed", nil)
+			req.Header.Set(middleware.HeaderAPIKey, apiKey)
+
+			resp, _ := limited.Test(req, 300000)
+
+			return resp.StatusCode
+		}
+
+		assert.Equal(t, 200, request("first"))
+		assert.Equal(t, 200, request("second"))
+		assert.Equal(t, 429, request("first"))
+
+		// Made up keys share the bucket of the IP.
+		assert.Equal(t, 200, request("made-up-1"))
+		assert.Equal(t, 429, request("made-up-2"))
+	})
+
+	t.Run("RateLimit | Delete full buckets", func(t *testing.T) {
+		store := ratelimit.NewMemoryStore()
+		policy := ratelimit.Policy{Limit: 1, Period: time.Minute, Key: ratelimit.KeyIP}
+		ctx := context.Background()
+
+		result, _ := store.Take(ctx, "bucket", policy)
+		assert.True(t, result.Allowed)
+
+		store.DeleteExpired(ctx, time.Now())
+
+		result, _ = store.Take(ctx, "bucket", policy)
+		assert.False(t, result.Allowed)
+
+		store.DeleteExpired(ctx, time.Now().Add(time.Minute))
+
+		result, _ = store.Take(ctx, "bucket", policy)
+		assert.True(t, result.Allowed)
+	})
+}
@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"product/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloadConfig(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig := func(level string, port int, requestTimeout string) {
+		os.WriteFile(configFile, []byte(fmt.Sprintf(`
+server:
+  port: %d
+  request_timeout: %s
+database:
+  username: root
+  name: product
+auth:
+  jwt_secret: secret
+logging:
+  level: %s
+`, port, requestTimeout, level)), 0o600)
+	}
+
+	writeConfig("info", 3000, "5s")
+
+	args := []string{"--config", configFile}
+	cfg, _, err := config.Load(args)
+
+	assert.NoError(t, err)
+
+	logs := bytes.Buffer{}
+	reloader := config.NewReloader(cfg, args, slog.New(slog.NewJSONHandler(&logs, nil)))
+
+	var published *config.Config
+	reloader.Subscribe(func(cfg *config.Config) {
+		published = cfg
+	})
+
+	t.Run("Reload | Apply reloadable settings only", func(t *testing.T) {
+		writeConfig("debug", 4000, "7s")
+
+		err := reloader.Reload()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "debug", reloader.Current().Logging.Level)
+		assert.Equal(t, 7*time.Second, reloader.Current().Server.RequestTimeout)
+		assert.Equal(t, 3000, reloader.Current().Server.Port)
+		assert.Same(t, reloader.Current(), published)
+		assert.Contains(t, logs.String(), `"key":"logging.level","old":"info","new":"debug"`)
+		assert.Contains(t, logs.String(), "configuration changes require a restart")
+	})
+
+	t.Run("Reload | Error, keep current config when invalid", func(t *testing.T) {
+		published = nil
+		writeConfig("loud", 3000, "5s")
+
+		err := reloader.Reload()
+
+		assert.Error(t, err)
+		assert.Equal(t, "debug", reloader.Current().Logging.Level)
+		assert.Nil(t, published)
+	})
+}
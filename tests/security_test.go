@@ -0,0 +1,191 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"product/config"
+	"product/handlers"
+	"product/middleware"
+	"product/ratelimit"
+	"product/router"
+	"product/session"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// newRouterApp builds the full router with cfg.
+func newRouterApp(cfg *config.Config) *fiber.App {
+	routerApp := fiber.New()
+	sessions := session.NewManager(session.NewMemoryStore(), time.Hour)
+
+	hl := router.HandlerList{
+		UserHandler:    userHandler,
+		ProductHandler: productHandler,
+		AuditHandler:   auditHandler,
+		HealthHandler:  healthHandler,
+		SessionHandler: handlers.NewSessionHandler(&userService, sessions, cfg.Auth),
+		UserService:    &userService,
+		MetricsHandler: func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) },
+		RateLimitStore: ratelimit.NewMemoryStore(),
+		Sessions:       sessions,
+		Config:         config.NewReloader(cfg, nil, slog.New(slog.NewTextHandler(io.Discard, nil))),
+	}
+
+	hl.InitRoute(routerApp)
+
+	return routerApp
+}
+
+func securityConfig() *config.Config {
+	return &config.Config{
+		Server: config.ServerConfig{RequestTimeout: 5 * time.Second},
+		Auth:   config.AuthConfig{JWTSecret: "secret", SessionCookie: "session"},
+		Security: config.SecurityConfig{
+			CORS: config.CORSConfig{
+				AllowedOrigins:   []string{"https://app.example.com"},
+				AllowedMethods:   []string{"GET", "POST"},
+				AllowedHeaders:   []string{"Authorization", "Content-Type", "X-CSRF-Token"},
+				AllowCredentials: true,
+				MaxAge:           10 * time.Minute,
+			},
+			Headers: config.HeadersConfig{
+				HSTSMaxAge:            time.Hour,
+				ContentSecurityPolicy: "default-src 'none'",
+				FrameOptions:          "DENY",
+				ContentTypeNosniff:    true,
+			},
+			CSRF: config.CSRFConfig{
+				Enabled:        true,
+				CookieName:     "csrf_token",
+				HeaderName:     "X-CSRF-Token",
+				CookieSameSite: "Lax",
+				Expiration:     time.Hour,
+			},
+		},
+	}
+}
+
+func TestSecurity(t *testing.T) {
+	routerApp := newRouterApp(securityConfig())
+
+	t.Run("CORS | Allowed origin preflight", func(t *testing.T) {
+		req := httptest.NewRequest("OPTIONS", "/products", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+
+		resp, _ := routerApp.Test(req, 300000)
+
+		assert.Equal(t, 204, resp.StatusCode)
+		assert.Equal(t, "https://app.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", resp.Header.Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "GET,POST", resp.Header.Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "600", resp.Header.Get("Access-Control-Max-Age"))
+	})
+
+	t.Run("CORS | Unknown origin", func(t *testing.T) {
+		req := httptest.NewRequest("OPTIONS", "/products", nil)
+		req.Header.Set("Origin", "https://evil.example.com")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+
+		resp, _ := routerApp.Test(req, 300000)
+
+		assert.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Headers | Security headers", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/healthz", nil)
+
+		resp, _ := routerApp.Test(req, 300000)
+
+		assert.Equal(t, "nosniff", resp.Header.Get("X-Content-Type-Options"))
+		assert.Equal(t, "DENY", resp.Header.Get("X-Frame-Options"))
+		assert.Equal(t, "default-src 'none'", resp.Header.Get("Content-Security-Policy"))
+		// HSTS is only sent over HTTPS.
+		assert.Empty(t, resp.Header.Get("Strict-Transport-Security"))
+	})
+
+	t.Run("CSRF | Issue token to session", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/healthz", nil)
+		req.Header.Set("Cookie", "session=abc")
+
+		resp, _ := routerApp.Test(req, 300000)
+
+		assert.Contains(t, resp.Header.Get("Set-Cookie"), "csrf_token=")
+	})
+
+	t.Run("CSRF | Reject session request without token", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/products", nil)
+		req.Header.Set("Cookie", "session=abc; csrf_token=token")
+
+		resp, _ := routerApp.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 403, resp.StatusCode)
+		assert.Equal(t, "invalid csrf token", bodyResponse.Message)
+	})
+
+	t.Run("CSRF | Accept session request with token", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/products", nil)
+		req.Header.Set("Cookie", "session=abc; csrf_token=token")
+		req.Header.Set("X-CSRF-Token", "token")
+
+		resp, _ := routerApp.Test(req, 300000)
+
+		// Past the CSRF check the request fails authentication instead.
+		assert.NotEqual(t, 403, resp.StatusCode)
+	})
+
+	t.Run("CSRF | Ignore requests without session", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/products", nil)
+
+		resp, _ := routerApp.Test(req, 300000)
+
+		assert.NotEqual(t, 403, resp.StatusCode)
+	})
+}
+
+func TestReloadCORS(t *testing.T) {
+	corsConfig := config.CORSConfig{AllowedOrigins: []string{"https://app.example.com"}}
+	corsApp := fiber.New()
+
+	corsApp.Use(middleware.CORS(func() config.CORSConfig { return corsConfig }))
+	corsApp.Get("/ping", func(c *fiber.Ctx) error {
+		return c.SendString("pong")
+	})
+
+	preflight := func(origin string) string {
+		req := httptest.NewRequest("OPTIONS", "/ping", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", "GET")
+
+		resp, _ := corsApp.Test(req, 300000)
+
+		return resp.Header.Get("Access-Control-Allow-Origin")
+	}
+
+	t.Run("CORS | Apply reloaded origins", func(t *testing.T) {
+		assert.Equal(t, "https://app.example.com", preflight("https://app.example.com"))
+		assert.Empty(t, preflight("https://admin.example.com"))
+
+		corsConfig.AllowedOrigins = []string{"https://admin.example.com"}
+
+		assert.Empty(t, preflight("https://app.example.com"))
+		assert.Equal(t, "https://admin.example.com", preflight("https://admin.example.com"))
+	})
+
+	t.Run("CORS | Disabled without origins", func(t *testing.T) {
+		corsConfig.AllowedOrigins = nil
+
+		assert.Empty(t, preflight("https://admin.example.com"))
+	})
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"product/config"
+	"product/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type SessionsResponseFormat struct {
+	Data    []models.SessionResponse `json:"data"`
+	Message string                   `json:"message"`
+}
+
+func TestSession(t *testing.T) {
+	routerApp := newRouterApp(&config.Config{
+		Server: config.ServerConfig{RequestTimeout: 5 * time.Second},
+		Auth: config.AuthConfig{
+			JWTSecret:             "secret",
+			SessionCookie:         "session",
+			SessionCookieSecure:   true,
+			SessionCookieSameSite: "Lax",
+		},
+	})
+
+	password, _ := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	sessionUser := models.User{ID: 70, Name: "Session", Email: "session@example.com", Password: string(password), Role: models.RoleUser}
+
+	userService.On("GetByCondition", mock.Anything, "email", "session@example.com").Return(sessionUser, nil)
+	userService.On("GetByCondition", mock.Anything, "id", "70").Return(sessionUser, nil)
+
+	var cookie string
+
+	t.Run("Session | Login sets cookie", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/login/session", strings.NewReader(`{"email":"session@example.com","password":"secret123"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := routerApp.Test(req, 300000)
+
+		setCookie := resp.Header.Get("Set-Cookie")
+		cookie = strings.Split(setCookie, ";")[0]
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.True(t, strings.HasPrefix(cookie, "session="))
+		assert.Contains(t, setCookie, "HttpOnly")
+		assert.Contains(t, setCookie, "secure")
+		assert.Contains(t, setCookie, "SameSite=Lax")
+	})
+
+	var sessionID string
+
+	t.Run("Session | List sessions with cookie", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/me/sessions", nil)
+		req.Header.Set("Cookie", cookie)
+
+		resp, _ := routerApp.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := SessionsResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Len(t, bodyResponse.Data, 1)
+		assert.True(t, bodyResponse.Data[0].Current)
+
+		sessionID = bodyResponse.Data[0].ID
+	})
+
+	t.Run("Session | Unknown session", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/me/sessions", nil)
+		req.Header.Set("Cookie", "session=unknown")
+
+		resp, _ := routerApp.Test(req, 300000)
+
+		assert.Equal(t, 401, resp.StatusCode)
+	})
+
+	t.Run("Session | Revoke session", func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", "/me/sessions/"+sessionID, nil)
+		req.Header.Set("Cookie", cookie)
+
+		resp, _ := routerApp.Test(req, 300000)
+
+		assert.Equal(t, 200, resp.StatusCode)
+
+		req = httptest.NewRequest("GET", "/me/sessions", nil)
+		req.Header.Set("Cookie", cookie)
+
+		resp, _ = routerApp.Test(req, 300000)
+
+		assert.Equal(t, 401, resp.StatusCode)
+	})
+
+	t.Run("Session | Logout", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/login/session", strings.NewReader(`{"email":"session@example.com","password":"secret123"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := routerApp.Test(req, 300000)
+
+		cookie = strings.Split(resp.Header.Get("Set-Cookie"), ";")[0]
+
+		req = httptest.NewRequest("POST", "/me/logout", nil)
+		req.Header.Set("Cookie", cookie)
+
+		resp, _ = routerApp.Test(req, 300000)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("Set-Cookie"), "session=;")
+
+		req = httptest.NewRequest("GET", "/me/sessions", nil)
+		req.Header.Set("Cookie", cookie)
+
+		resp, _ = routerApp.Test(req, 300000)
+
+		assert.Equal(t, 401, resp.StatusCode)
+	})
+}
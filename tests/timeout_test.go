@@ -0,0 +1,37 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"product/middleware"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRequestTimeout(t *testing.T) {
+	t.Run("Timeout | Cancel slow service call", func(t *testing.T) {
+		productService.On("GetAll", mock.Anything).Run(func(args mock.Arguments) {
+			<-args.Get(0).(context.Context).Done()
+		}).Return(nil, context.DeadlineExceeded).Once()
+
+		app.Get("/slow-products", middleware.Timeout(func() time.Duration { return 20 * time.Millisecond }), productHandler.GetAll)
+
+		req := httptest.NewRequest("GET", "/slow-products", nil)
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 504, resp.StatusCode)
+		assert.Equal(t, "request timed out", bodyResponse.Message)
+	})
+}
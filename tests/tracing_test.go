@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"net/http/httptest"
+	"product/middleware"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	tracingApp := fiber.New()
+
+	tracingApp.Use(middleware.Tracing())
+	tracingApp.Get("/products/:id", func(c *fiber.Ctx) error {
+		_, span := otel.Tracer("test").Start(c.UserContext(), "ProductService.GetByCondition")
+		span.End()
+
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	t.Run("Tracing | Continue incoming trace", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/products/1", nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+		resp, _ := tracingApp.Test(req, 300000)
+
+		spans := recorder.Ended()
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Len(t, spans, 2)
+
+		child, server := spans[0], spans[1]
+
+		assert.Equal(t, "GET /products/:id", server.Name())
+		assert.Equal(t, trace.SpanKindServer, server.SpanKind())
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+		assert.Equal(t, server.SpanContext().SpanID(), child.Parent().SpanID())
+	})
+}
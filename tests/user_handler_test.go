@@ -2,20 +2,29 @@ package tests
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
+	"log/slog"
 	"net/http/httptest"
 	"product/handlers"
 	"product/models"
+	"product/services"
 	"product/services/mocks"
+	"product/utils"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 var userService = mocks.UserService{}
-var userHandler = handlers.NewUserHandler(&userService)
+var mailer = mocks.UserMailer{}
+var userHandler = handlers.NewUserHandler(&userService, &mailer)
 
 var userModel = models.User{
 	ID:       1,
@@ -30,9 +39,30 @@ var userRequest = models.UserRequest{
 	Password: "12345678",
 }
 
+// onUpdateUser expects UserService.Update of the user with id, which answers
+// with user changed as asked and stores it in saved when it is not nil.
+func onUpdateUser(userService *mocks.UserService, id string, user models.User, saved *models.User) *mock.Call {
+	call := userService.On("Update", mock.Anything, mock.Anything, id, mock.Anything)
+
+	return call.Run(func(args mock.Arguments) {
+		changed := user
+
+		if err := args.Get(3).(func(user *models.User) error)(&changed); err != nil {
+			call.ReturnArguments = mock.Arguments{models.User{}, err}
+			return
+		}
+
+		if saved != nil {
+			*saved = changed
+		}
+
+		call.ReturnArguments = mock.Arguments{changed, nil}
+	}).Once()
+}
+
 func TestGetAllUser(t *testing.T) {
 	t.Run("GetAll | Success", func(t *testing.T) {
-		userService.On("GetAll").Return([]models.User{userModel}, nil).Once()
+		userService.On("Search", mock.Anything, "", 1, 10).Return([]models.User{userModel}, int64(1), nil).Once()
 
 		app.Get("/users", userHandler.GetAll)
 
@@ -51,7 +81,7 @@ func TestGetAllUser(t *testing.T) {
 	})
 
 	t.Run("GetAll | Success but empty", func(t *testing.T) {
-		userService.On("GetAll").Return([]models.User{}, nil).Once()
+		userService.On("Search", mock.Anything, "", 1, 10).Return([]models.User{}, int64(0), nil).Once()
 
 		app.Get("/users", userHandler.GetAll)
 
@@ -70,10 +100,110 @@ func TestGetAllUser(t *testing.T) {
 	})
 }
 
+func TestCreateUser(t *testing.T) {
+	createRequest := models.CreateUserRequest{
+		Name:  "Andi",
+		Email: "andi@gmail.com",
+	}
+
+	t.Run("Create | Success", func(t *testing.T) {
+		createdUser := models.User{ID: 2, Name: "Andi", Email: "andi@gmail.com", Role: models.RoleUser, MustResetPassword: true}
+
+		userService.On("GetByCondition", mock.Anything, "email", "andi@gmail.com").Return(models.User{}, errors.New("error")).Once()
+		userService.On("Create", mock.Anything, mock.Anything, mock.Anything).Return(createdUser, nil).Once()
+		mailer.On("SendTemporaryPassword", mock.Anything, uint(2)).Return(nil).Once()
+
+		app.Post("/admin/users", userHandler.Create)
+
+		createReq, _ := json.Marshal(createRequest)
+
+		req := httptest.NewRequest("POST", "/admin/users", bytes.NewBuffer(createReq))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "successfully create user", bodyResponse.Message)
+	})
+
+	t.Run("Create | Error, email has been registered", func(t *testing.T) {
+		userService.On("GetByCondition", mock.Anything, "email", "andi@gmail.com").Return(userModel, nil).Once()
+
+		app.Post("/admin/users", userHandler.Create)
+
+		createReq, _ := json.Marshal(createRequest)
+
+		req := httptest.NewRequest("POST", "/admin/users", bytes.NewBuffer(createReq))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 409, resp.StatusCode)
+		assert.Equal(t, "email has been registered", bodyResponse.Message)
+	})
+}
+
+func TestDeleteUser(t *testing.T) {
+	newOwner := models.User{ID: 3, Name: "Andi", Email: "andi@gmail.com"}
+
+	t.Run("Delete | Success with reassignment", func(t *testing.T) {
+		userService.On("GetByCondition", mock.Anything, "id", "1").Return(userModel, nil).Once()
+		userService.On("GetByCondition", mock.Anything, "id", "3").Return(newOwner, nil).Once()
+		userService.On("Delete", mock.Anything, mock.Anything, userModel, uint(3)).Return(nil).Once()
+
+		app.Delete("/admin/users/:id", userHandler.Delete)
+
+		req := httptest.NewRequest("DELETE", "/admin/users/1?reassign_to=3", nil)
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "successfully delete user", bodyResponse.Message)
+	})
+
+	t.Run("Delete | Error, reassign to the same user", func(t *testing.T) {
+		userService.On("GetByCondition", mock.Anything, "id", "1").Return(userModel, nil).Twice()
+
+		app.Delete("/admin/users/:id", userHandler.Delete)
+
+		req := httptest.NewRequest("DELETE", "/admin/users/1?reassign_to=1", nil)
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 400, resp.StatusCode)
+		assert.Equal(t, "reassigned user is not found", bodyResponse.Message)
+	})
+}
+
 func TestUpdateUser(t *testing.T) {
 	t.Run("Update | Success", func(t *testing.T) {
-		userService.On("Update", "1", userModel).Return(userModel, nil).Once()
-		userService.On("GetByCondition", "id", "1").Return(userModel, nil).Once()
+		var saved models.User
+
+		onUpdateUser(&userService, "1", models.User{ID: 1, Name: "Old", Email: "old@gmail.com", TokenVersion: 4}, &saved)
 
 		app.Put("/users/:id", userHandler.Update)
 
@@ -92,12 +222,10 @@ func TestUpdateUser(t *testing.T) {
 
 		assert.Equal(t, 200, resp.StatusCode)
 		assert.Equal(t, "successfully update user", bodyResponse.Message)
+		assert.Equal(t, models.User{ID: 1, Name: userRequest.Name, Email: userRequest.Email, TokenVersion: 4}, saved)
 	})
 
 	t.Run("Update | Error, bad request body", func(t *testing.T) {
-		userService.On("Update", "1", userModel).Return(userModel, nil).Once()
-		userService.On("GetByCondition", "id", "1").Return(userModel, nil).Once()
-
 		app.Put("/users/:id", userHandler.Update)
 
 		req := httptest.NewRequest("PUT", "/users/1", nil)
@@ -116,8 +244,7 @@ func TestUpdateUser(t *testing.T) {
 	})
 
 	t.Run("Update | Error, bad request id param", func(t *testing.T) {
-		userService.On("Update", "1", userModel).Return(models.User{}, errors.New("error")).Once()
-		userService.On("GetByCondition", "id", "2").Return(models.User{}, errors.New("error")).Once()
+		userService.On("Update", mock.Anything, mock.Anything, "2", mock.Anything).Return(models.User{}, gorm.ErrRecordNotFound).Once()
 
 		app.Put("/users/:id", userHandler.Update)
 
@@ -138,3 +265,259 @@ func TestUpdateUser(t *testing.T) {
 		assert.Equal(t, "user is not found", bodyResponse.Message)
 	})
 }
+
+func TestAdminUserChanges(t *testing.T) {
+	app.Put("/admin/users/:id", userHandler.Update)
+	app.Put("/admin/users/:id/password", userHandler.SetPassword)
+
+	t.Run("Update | Error, email has been registered", func(t *testing.T) {
+		userService.On("Update", mock.Anything, mock.Anything, "5", mock.Anything).Return(models.User{}, services.ErrEmailRegistered).Once()
+
+		userReq, _ := json.Marshal(models.UserRequest{Name: "Budi", Email: "taken@gmail.com", Password: "12345678"})
+
+		req := httptest.NewRequest("PUT", "/admin/users/5", bytes.NewBuffer(userReq))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 409, resp.StatusCode)
+		assert.Equal(t, "email has been registered", bodyResponse.Message)
+	})
+
+	t.Run("SetPassword | Clears a forced reset", func(t *testing.T) {
+		var saved models.User
+
+		onUpdateUser(&userService, "6", models.User{ID: 6, MustResetPassword: true, TokenVersion: 2}, &saved)
+
+		passwordReq, _ := json.Marshal(models.SetPasswordRequest{Password: "87654321"})
+
+		req := httptest.NewRequest("PUT", "/admin/users/6/password", bytes.NewBuffer(passwordReq))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := app.Test(req, 300000)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.False(t, saved.MustResetPassword)
+		assert.Equal(t, 3, saved.TokenVersion)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(saved.Password), []byte("87654321")))
+	})
+}
+
+func TestChangePassword(t *testing.T) {
+	app.Put("/me/password", withUser(userModel), userHandler.ChangePassword)
+
+	t.Run("ChangePassword | Success", func(t *testing.T) {
+		onUpdateUser(&userService, "1", userModel, nil)
+
+		passwordReq, _ := json.Marshal(models.ChangePasswordRequest{
+			CurrentPassword: userRequest.Password,
+			NewPassword:     "87654321",
+		})
+
+		req := httptest.NewRequest("PUT", "/me/password", bytes.NewBuffer(passwordReq))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "successfully change password", bodyResponse.Message)
+	})
+
+	t.Run("ChangePassword | Error, wrong current password", func(t *testing.T) {
+		passwordReq, _ := json.Marshal(models.ChangePasswordRequest{
+			CurrentPassword: "wrong password",
+			NewPassword:     "87654321",
+		})
+
+		req := httptest.NewRequest("PUT", "/me/password", bytes.NewBuffer(passwordReq))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 400, resp.StatusCode)
+		assert.Equal(t, "password invalid", bodyResponse.Message)
+	})
+}
+
+func TestChangeEmail(t *testing.T) {
+	app.Put("/me/email", withUser(userModel), userHandler.ChangeEmail)
+
+	t.Run("ChangeEmail | Success", func(t *testing.T) {
+		userService.On("GetByCondition", mock.Anything, "email", "new@gmail.com").Return(models.User{}, errors.New("error")).Once()
+		// The token is made by the mailer; any earlier one is revoked.
+		var saved models.User
+		expiresAt := time.Now()
+
+		onUpdateUser(&userService, "1", models.User{ID: 1, EmailToken: "old", EmailTokenExpiresAt: &expiresAt}, &saved)
+		mailer.On("SendEmailVerification", mock.Anything, userModel.ID).Return(nil).Once()
+
+		emailReq, _ := json.Marshal(models.ChangeEmailRequest{
+			Email:    "new@gmail.com",
+			Password: userRequest.Password,
+		})
+
+		req := httptest.NewRequest("PUT", "/me/email", bytes.NewBuffer(emailReq))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "verification email has been sent", bodyResponse.Message)
+		assert.Equal(t, "new@gmail.com", saved.PendingEmail)
+		assert.Empty(t, saved.EmailToken)
+		assert.Nil(t, saved.EmailTokenExpiresAt)
+
+	})
+
+	t.Run("ChangeEmail | Error, email has been registered", func(t *testing.T) {
+		userService.On("GetByCondition", mock.Anything, "email", "taken@gmail.com").Return(userModel, nil).Once()
+
+		emailReq, _ := json.Marshal(models.ChangeEmailRequest{
+			Email:    "taken@gmail.com",
+			Password: userRequest.Password,
+		})
+
+		req := httptest.NewRequest("PUT", "/me/email", bytes.NewBuffer(emailReq))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 409, resp.StatusCode)
+		assert.Equal(t, "email has been registered", bodyResponse.Message)
+	})
+}
+
+func TestVerifyEmail(t *testing.T) {
+	app.Post("/verify-email", userHandler.VerifyEmail)
+
+	t.Run("VerifyEmail | Success", func(t *testing.T) {
+		userService.On("VerifyEmail", mock.Anything, mock.Anything, utils.HashToken("valid")).Return(userModel, nil).Once()
+
+		verifyReq, _ := json.Marshal(models.VerifyEmailRequest{Token: "valid"})
+
+		req := httptest.NewRequest("POST", "/verify-email", bytes.NewBuffer(verifyReq))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "successfully verify email", bodyResponse.Message)
+	})
+
+	t.Run("VerifyEmail | Error, email has been registered", func(t *testing.T) {
+		userService.On("VerifyEmail", mock.Anything, mock.Anything, utils.HashToken("taken")).Return(models.User{}, services.ErrEmailRegistered).Once()
+
+		verifyReq, _ := json.Marshal(models.VerifyEmailRequest{Token: "taken"})
+
+		req := httptest.NewRequest("POST", "/verify-email", bytes.NewBuffer(verifyReq))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 409, resp.StatusCode)
+		assert.Equal(t, "email has been registered", bodyResponse.Message)
+	})
+
+	t.Run("VerifyEmail | Error, token invalid", func(t *testing.T) {
+		userService.On("VerifyEmail", mock.Anything, mock.Anything, utils.HashToken("unknown")).Return(models.User{}, services.ErrTokenInvalid).Once()
+
+		verifyReq, _ := json.Marshal(models.VerifyEmailRequest{Token: "unknown"})
+
+		req := httptest.NewRequest("POST", "/verify-email", bytes.NewBuffer(verifyReq))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 400, resp.StatusCode)
+		assert.Equal(t, "token invalid", bodyResponse.Message)
+	})
+}
+
+func TestDeleteAccount(t *testing.T) {
+	t.Run("DeleteAccount | Success", func(t *testing.T) {
+		userService.On("Delete", mock.Anything, mock.Anything, userModel, uint(0)).Return(nil).Once()
+
+		app.Delete("/me", withUser(userModel), userHandler.DeleteAccount)
+
+		deleteReq, _ := json.Marshal(models.DeleteAccountRequest{Password: userRequest.Password})
+
+		req := httptest.NewRequest("DELETE", "/me", bytes.NewBuffer(deleteReq))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := app.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "successfully delete account", bodyResponse.Message)
+	})
+}
+
+func TestLogMailer(t *testing.T) {
+	t.Run("LogMailer | Body is not logged", func(t *testing.T) {
+		logs := bytes.Buffer{}
+		logMailer := services.NewMailer(utils.NewLogger(&logs, slog.LevelInfo, "json"))
+
+		assert.NoError(t, logMailer.Send(context.Background(), "andi@gmail.com", "Your account", "Your temporary password: secret"))
+
+		entry := map[string]any{}
+
+		json.Unmarshal(logs.Bytes(), &entry)
+
+		assert.Equal(t, "andi@gmail.com", entry["to"])
+		assert.Equal(t, float64(len("Your temporary password: secret")), entry["body_bytes"])
+		assert.NotContains(t, logs.String(), "secret")
+	})
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"product/config"
+	"product/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type ProductPageResponseFormat struct {
+	Data struct {
+		Items []models.ProductResponse `json:"items"`
+		Total int64                    `json:"total"`
+	} `json:"data"`
+	Message string `json:"message"`
+}
+
+func TestAPIVersion(t *testing.T) {
+	cfg := securityConfig()
+	cfg.API.Deprecations = map[string]config.DeprecationConfig{
+		"v1": {
+			Deprecation: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			Sunset:      time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+			Link:        "https://example.com/migrate-to-v2",
+		},
+	}
+
+	routerApp := newRouterApp(cfg)
+
+	t.Run("Version | v2 prefix", func(t *testing.T) {
+		productService.On("Search", mock.Anything, "", 1, 10).Return([]models.Product{productModel}, int64(1), nil).Once()
+
+		req := httptest.NewRequest("GET", "/v2/products", nil)
+
+		resp, _ := routerApp.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ProductPageResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "v2", resp.Header.Get("API-Version"))
+		assert.Empty(t, resp.Header.Get("Deprecation"))
+		assert.Equal(t, int64(1), bodyResponse.Data.Total)
+		assert.Len(t, bodyResponse.Data.Items, 1)
+	})
+
+	t.Run("Version | Header negotiation", func(t *testing.T) {
+		productService.On("Search", mock.Anything, "", 1, 10).Return([]models.Product{}, int64(0), nil).Once()
+
+		req := httptest.NewRequest("GET", "/products", nil)
+		req.Header.Set("API-Version", "2")
+
+		resp, _ := routerApp.Test(req, 300000)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "v2", resp.Header.Get("API-Version"))
+	})
+
+	t.Run("Version | Deprecated v1", func(t *testing.T) {
+		productService.On("GetAll", mock.Anything).Return([]models.Product{productModel}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/v1/products", nil)
+
+		resp, _ := routerApp.Test(req, 300000)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "v1", resp.Header.Get("API-Version"))
+		assert.Equal(t, "@1767225600", resp.Header.Get("Deprecation"))
+		assert.Equal(t, "Fri, 01 Jan 2027 00:00:00 GMT", resp.Header.Get("Sunset"))
+		assert.Equal(t, `<https://example.com/migrate-to-v2>; rel="deprecation"`, resp.Header.Get("Link"))
+	})
+
+	t.Run("Version | Unprefixed alias of v1", func(t *testing.T) {
+		productService.On("GetAll", mock.Anything).Return([]models.Product{productModel}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/products", nil)
+
+		resp, _ := routerApp.Test(req, 300000)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "v1", resp.Header.Get("API-Version"))
+		assert.NotEmpty(t, resp.Header.Get("Deprecation"))
+	})
+
+	t.Run("Version | Unsupported version", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/products", nil)
+		req.Header.Set("API-Version", "v9")
+
+		resp, _ := routerApp.Test(req, 300000)
+
+		body, _ := io.ReadAll(resp.Body)
+
+		bodyResponse := ResponseFormat{}
+
+		json.Unmarshal(body, &bodyResponse)
+
+		assert.Equal(t, 400, resp.StatusCode)
+		assert.Equal(t, "unsupported api version", bodyResponse.Message)
+	})
+
+	t.Run("Version | Operational routes are unversioned", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/healthz", nil)
+		req.Header.Set("API-Version", "v9")
+
+		resp, _ := routerApp.Test(req, 300000)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("API-Version"))
+	})
+}
@@ -0,0 +1,295 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"product/config"
+	"product/events"
+	"product/handlers"
+	"product/models"
+	"product/services/mocks"
+	"product/webhook"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestEventBus(t *testing.T) {
+	t.Run("Bus | Subscribers receive the types they asked for", func(t *testing.T) {
+		bus := events.NewBus()
+
+		var all, products []string
+
+		bus.Subscribe(func(ctx context.Context, event events.Event) { all = append(all, event.Type) })
+		bus.Subscribe(func(ctx context.Context, event events.Event) { products = append(products, event.Type) }, events.ProductCreated, events.StockLow)
+
+		created, _ := events.New(events.ProductCreated, 1, events.ProductData{})
+		registered, _ := events.New(events.UserRegistered, 2, events.UserData{})
+		low, _ := events.New(events.StockLow, 1, events.StockData{Threshold: 10})
+
+		bus.Publish(context.Background(), created, registered, low)
+
+		assert.Equal(t, []string{events.ProductCreated, events.UserRegistered, events.StockLow}, all)
+		assert.Equal(t, []string{events.ProductCreated, events.StockLow}, products)
+		assert.Equal(t, "user", registered.Aggregate)
+		assert.Equal(t, "product", low.Aggregate)
+		assert.NotEqual(t, created.ID, low.ID)
+	})
+}
+
+func TestWebhook(t *testing.T) {
+	t.Run("Webhook | Subscribed", func(t *testing.T) {
+		hook := models.Webhook{Events: []string{events.ProductCreated}}
+		catchAll := models.Webhook{Events: []string{models.WebhookAllEvents}}
+
+		assert.True(t, hook.Subscribed(events.ProductCreated))
+		assert.False(t, hook.Subscribed(events.ProductDeleted))
+		assert.True(t, catchAll.Subscribed(events.StockLow))
+	})
+
+	webhooksCfg := config.WebhooksConfig{
+		Workers:        2,
+		BatchSize:      100,
+		Timeout:        time.Second,
+		MaxAttempts:    3,
+		InitialBackoff: time.Minute,
+		MaxBackoff:     time.Hour,
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	withLock := func(webhookService *mocks.WebhookService) {
+		webhookService.On("WithDispatchLock", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			args.Get(1).(func(ctx context.Context) error)(context.Background())
+		}).Return(true, nil).Once()
+	}
+
+	webhookMessage := func(id uint, aggregateID uint, eventType string) (models.WebhookMessage, events.Event) {
+		event, _ := events.New(eventType, aggregateID, events.ProductData{Product: productModel.ConvertToResponse()})
+		payload, _ := json.Marshal(event)
+
+		return models.WebhookMessage{
+			ID:            id,
+			WebhookID:     3,
+			EventID:       event.ID,
+			Type:          event.Type,
+			Aggregate:     event.Aggregate,
+			AggregateID:   aggregateID,
+			Payload:       string(payload),
+			NextAttemptAt: time.Now().Add(-time.Second),
+		}, event
+	}
+
+	t.Run("Webhook | Deliveries are signed and retried", func(t *testing.T) {
+		var mu sync.Mutex
+		var received []*http.Request
+		var bodies [][]byte
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+
+			mu.Lock()
+			received = append(received, r)
+			bodies = append(bodies, body)
+			attempt := len(received)
+			mu.Unlock()
+
+			if attempt == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte("try later"))
+				return
+			}
+
+			w.Write([]byte("ok"))
+		}))
+		defer server.Close()
+
+		webhookService := mocks.WebhookService{}
+		hook := models.Webhook{ID: 3, URL: server.URL, Events: []string{events.ProductCreated}, Secret: "0123456789abcdef", Active: true}
+		message, event := webhookMessage(1, 1, events.ProductCreated)
+
+		var first, second models.WebhookDelivery
+		var rescheduled models.WebhookMessage
+
+		webhookService.On("GetAll", mock.Anything).Return([]models.Webhook{hook}, nil)
+		webhookService.On("GetPendingMessages", mock.Anything, 100).Return([]models.WebhookMessage{message}, nil).Once()
+		webhookService.On("RescheduleMessage", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			rescheduled = args.Get(1).(models.WebhookMessage)
+			first = args.Get(2).(models.WebhookDelivery)
+		}).Return(nil).Once()
+
+		dispatcher := webhook.NewDispatcher(&webhookService, webhooksCfg, logger)
+		before := time.Now()
+
+		withLock(&webhookService)
+		assert.NoError(t, dispatcher.Poll(context.Background()))
+
+		assert.Equal(t, 1, first.Attempt)
+		assert.False(t, first.Succeeded)
+		assert.Equal(t, 503, first.StatusCode)
+		assert.Equal(t, "try later", first.ResponseBody)
+		assert.Equal(t, 1, rescheduled.Attempts)
+		assert.True(t, rescheduled.NextAttemptAt.After(before.Add(time.Minute-time.Second)))
+
+		rescheduled.NextAttemptAt = time.Now().Add(-time.Second)
+
+		webhookService.On("GetPendingMessages", mock.Anything, 100).Return([]models.WebhookMessage{rescheduled}, nil).Once()
+		webhookService.On("CompleteMessage", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			second = args.Get(2).(models.WebhookDelivery)
+		}).Return(nil).Once()
+
+		withLock(&webhookService)
+		assert.NoError(t, dispatcher.Poll(context.Background()))
+
+		assert.Equal(t, 2, second.Attempt)
+		assert.True(t, second.Succeeded)
+		assert.Equal(t, event.ID, second.EventID)
+		webhookService.AssertExpectations(t)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		request := received[1]
+		timestamp := request.Header.Get(webhook.HeaderTimestamp)
+
+		assert.Equal(t, events.ProductCreated, request.Header.Get(webhook.HeaderEvent))
+		assert.Equal(t, event.ID, request.Header.Get(webhook.HeaderDelivery))
+		assert.Equal(t, webhook.Sign(hook.Secret, timestamp, bodies[1]), request.Header.Get(webhook.HeaderSignature))
+		assert.NotEqual(t, webhook.Sign("another secret!!", timestamp, bodies[1]), request.Header.Get(webhook.HeaderSignature))
+
+		sent := events.Event{}
+		json.Unmarshal(bodies[1], &sent)
+
+		assert.Equal(t, event.ID, sent.ID)
+		assert.Contains(t, string(sent.Data), `"name":"Permen"`)
+	})
+
+	t.Run("Webhook | Delivers in order and holds back a failing aggregate", func(t *testing.T) {
+		var mu sync.Mutex
+		var delivered []string
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sent := events.Event{}
+			json.NewDecoder(r.Body).Decode(&sent)
+
+			if sent.AggregateID == 2 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			mu.Lock()
+			delivered = append(delivered, sent.Type)
+			mu.Unlock()
+		}))
+		defer server.Close()
+
+		webhookService := mocks.WebhookService{}
+		hook := models.Webhook{ID: 3, URL: server.URL, Events: []string{models.WebhookAllEvents}, Secret: "0123456789abcdef", Active: true}
+
+		created, _ := webhookMessage(1, 1, events.ProductCreated)
+		failing, _ := webhookMessage(2, 2, events.ProductCreated)
+		updated, _ := webhookMessage(3, 1, events.ProductUpdated)
+		held, _ := webhookMessage(4, 2, events.ProductUpdated)
+		later, _ := webhookMessage(5, 3, events.ProductCreated)
+		later.NextAttemptAt = time.Now().Add(time.Hour)
+
+		withLock(&webhookService)
+		webhookService.On("GetAll", mock.Anything).Return([]models.Webhook{hook}, nil)
+		webhookService.On("GetPendingMessages", mock.Anything, 100).Return([]models.WebhookMessage{created, failing, updated, held, later}, nil).Once()
+		webhookService.On("CompleteMessage", mock.Anything, mock.Anything, mock.Anything).Return(nil).Twice()
+		webhookService.On("RescheduleMessage", mock.Anything, mock.MatchedBy(func(message models.WebhookMessage) bool {
+			return message.ID == failing.ID && message.Attempts == 1
+		}), mock.Anything).Return(nil).Once()
+
+		assert.NoError(t, webhook.NewDispatcher(&webhookService, webhooksCfg, logger).Poll(context.Background()))
+		assert.Equal(t, []string{events.ProductCreated, events.ProductUpdated}, delivered)
+		webhookService.AssertExpectations(t)
+	})
+
+	t.Run("Webhook | Gives up on a message out of attempts", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusGone)
+		}))
+		defer server.Close()
+
+		webhookService := mocks.WebhookService{}
+		hook := models.Webhook{ID: 3, URL: server.URL, Events: []string{models.WebhookAllEvents}, Secret: "0123456789abcdef", Active: true}
+
+		message, _ := webhookMessage(1, 1, events.StockLow)
+		message.Attempts = 2
+
+		withLock(&webhookService)
+		webhookService.On("GetAll", mock.Anything).Return([]models.Webhook{hook}, nil)
+		webhookService.On("GetPendingMessages", mock.Anything, 100).Return([]models.WebhookMessage{message}, nil).Once()
+		webhookService.On("CompleteMessage", mock.Anything, mock.Anything, mock.MatchedBy(func(delivery models.WebhookDelivery) bool {
+			return !delivery.Succeeded && delivery.Attempt == 3 && delivery.StatusCode == http.StatusGone
+		})).Return(nil).Once()
+
+		assert.NoError(t, webhook.NewDispatcher(&webhookService, webhooksCfg, logger).Poll(context.Background()))
+		webhookService.AssertExpectations(t)
+	})
+
+	t.Run("Webhook | Skips the poll without the lock", func(t *testing.T) {
+		webhookService := mocks.WebhookService{}
+		webhookService.On("WithDispatchLock", mock.Anything, mock.Anything).Return(false, nil).Once()
+
+		assert.NoError(t, webhook.NewDispatcher(&webhookService, webhooksCfg, logger).Poll(context.Background()))
+		webhookService.AssertNotCalled(t, "GetPendingMessages", mock.Anything, mock.Anything)
+	})
+
+	webhookService := mocks.WebhookService{}
+	webhookHandler := handlers.NewWebhookHandler(&webhookService)
+
+	app.Post("/hooks", webhookHandler.Create)
+	app.Get("/hooks/:id/deliveries", webhookHandler.GetDeliveries)
+
+	t.Run("Webhook | Create", func(t *testing.T) {
+		webhookService.On("Create", mock.Anything, mock.Anything, mock.MatchedBy(func(hook models.Webhook) bool {
+			return hook.Active && hook.Secret == "0123456789abcdef"
+		})).Return(models.Webhook{ID: 1, URL: "https://example.com/hook", Events: []string{"*"}, Secret: "0123456789abcdef", Active: true}, nil).Once()
+
+		body, _ := json.Marshal(models.WebhookRequest{URL: "https://example.com/hook", Events: []string{"*"}, Secret: "0123456789abcdef"})
+		req := httptest.NewRequest("POST", "/hooks", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := app.Test(req, 300000)
+
+		respBody, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, 201, resp.StatusCode)
+		assert.NotContains(t, string(respBody), "0123456789abcdef")
+	})
+
+	t.Run("Webhook | Create with unknown event", func(t *testing.T) {
+		body, _ := json.Marshal(models.WebhookRequest{URL: "https://example.com/hook", Events: []string{"order.paid"}, Secret: "0123456789abcdef"})
+		req := httptest.NewRequest("POST", "/hooks", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, _ := app.Test(req, 300000)
+
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	t.Run("Webhook | Deliveries", func(t *testing.T) {
+		webhookService.On("GetByID", mock.Anything, "1").Return(models.Webhook{ID: 1}, nil).Once()
+		webhookService.On("GetDeliveries", mock.Anything, uint(1), 1, 10).Return([]models.WebhookDelivery{
+			{ID: 7, WebhookID: 1, EventType: events.ProductCreated, Attempt: 2, Succeeded: true, StatusCode: 200, Duration: 1500 * time.Millisecond},
+		}, int64(1), nil).Once()
+
+		req := httptest.NewRequest("GET", "/hooks/1/deliveries", nil)
+
+		resp, _ := app.Test(req, 300000)
+
+		respBody, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Contains(t, string(respBody), `"duration_ms":1500`)
+	})
+}
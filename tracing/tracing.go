@@ -0,0 +1,84 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+const serviceName = "product"
+
+// Init installs the global tracer provider and the W3C trace context
+// propagator. exporter is one of "otlp", "stdout", "file" or "none"; "file"
+// writes to file. The OTLP exporter reads its endpoint from the standard
+// OTEL_EXPORTER_OTLP_* environment variables. The returned function flushes
+// pending spans and must be called on shutdown.
+func Init(ctx context.Context, exporter string, file string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	spanExporter, closer, err := newExporter(ctx, exporter, file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if spanExporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+
+		if closer != nil {
+			closer.Close()
+		}
+
+		return err
+	}, nil
+}
+
+func newExporter(ctx context.Context, exporter string, file string) (sdktrace.SpanExporter, io.Closer, error) {
+	switch exporter {
+	case "", "none":
+		return nil, nil, nil
+	case "otlp":
+		spanExporter, err := otlptracehttp.New(ctx)
+
+		return spanExporter, nil, err
+	case "stdout":
+		spanExporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+
+		return spanExporter, nil, err
+	case "file":
+		f, err := os.OpenFile(file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		spanExporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+
+		return spanExporter, f, err
+	default:
+		return nil, nil, fmt.Errorf("unknown trace exporter %q", exporter)
+	}
+}
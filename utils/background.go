@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// CleanupInterval is how often the stores drop their expired rows.
+const CleanupInterval = time.Hour
+
+// Background runs long lived goroutines, such as workers and schedulers, so
+// they can be stopped together and waited for on shutdown.
+type Background struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewBackground() *Background {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Background{
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Go runs fn in a new goroutine. The context passed to fn is cancelled when
+// Shutdown is called and fn is expected to return soon after.
+func (b *Background) Go(fn func(ctx context.Context)) {
+	b.wg.Add(1)
+
+	go func() {
+		defer b.wg.Done()
+		fn(b.ctx)
+	}()
+}
+
+// Shutdown cancels every goroutine and waits for them to return, or for ctx
+// to expire, whichever comes first.
+func (b *Background) Shutdown(ctx context.Context) error {
+	b.cancel()
+
+	done := make(chan struct{})
+
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RunCleanup calls deleteExpired with the current time every CleanupInterval
+// until ctx is done. Failures are logged as failing to delete what.
+func RunCleanup(ctx context.Context, what string, deleteExpired func(ctx context.Context, now time.Time) error) {
+	ticker := time.NewTicker(CleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := deleteExpired(ctx, now); err != nil {
+				slog.ErrorContext(ctx, "failed to delete "+what, "error", err)
+			}
+		}
+	}
+}
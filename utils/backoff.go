@@ -0,0 +1,15 @@
+package utils
+
+import "time"
+
+// Backoff is the delay after the given failed attempt, counted from 1:
+// initial, doubled on every attempt up to max.
+func Backoff(initial time.Duration, max time.Duration, attempt int) time.Duration {
+	delay := initial
+
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+
+	return min(delay, max)
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// NewLogger builds a logger writing to w. format is either "json" or "text";
+// unknown values fall back to json. Pass a *slog.LevelVar as level to be able
+// to change it at runtime.
+func NewLogger(w io.Writer, level slog.Leveler, format string) *slog.Logger {
+	options := &slog.HandlerOptions{
+		Level: level,
+	}
+
+	if strings.EqualFold(format, "text") {
+		return slog.New(slog.NewTextHandler(w, options))
+	}
+
+	return slog.New(slog.NewJSONHandler(w, options))
+}
+
+// ParseLogLevel converts debug, info, warn or error to a slog.Level,
+// defaulting to info.
+func ParseLogLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
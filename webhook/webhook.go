@@ -0,0 +1,241 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"product/config"
+	"product/models"
+	"product/services"
+	"product/utils"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// maxResponseBody caps the part of a response body kept in the delivery log.
+const maxResponseBody = 4 << 10
+
+// Sign returns the signature sent with a delivery: the HMAC-SHA256 of the
+// timestamp, a dot and the body, keyed with the secret of the webhook.
+// Receivers recompute it to check the delivery came from us, and reject old
+// timestamps to stop replays.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatcher delivers the messages queued for the webhooks by the outbox
+// relay, in order for each webhook and aggregate: a message is only sent
+// once the earlier messages of its aggregate have been delivered to the
+// webhook or given up on. Failed deliveries are retried with exponential
+// backoff, and every attempt is logged. Only the instance holding the
+// dispatch lock delivers.
+type Dispatcher struct {
+	webhooks services.WebhookService
+	cfg      config.WebhooksConfig
+	client   *http.Client
+	logger   *slog.Logger
+	now      func() time.Time
+}
+
+func NewDispatcher(webhooks services.WebhookService, cfg config.WebhooksConfig, logger *slog.Logger) *Dispatcher {
+	return &Dispatcher{
+		webhooks: webhooks,
+		cfg:      cfg,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// Run polls the queued messages every PollInterval until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.Poll(ctx); err != nil {
+				d.logger.ErrorContext(ctx, "webhook dispatch failed", "error", err)
+			}
+		}
+	}
+}
+
+// Poll delivers the queued messages due, up to BatchSize of them. The
+// queues of different webhooks and aggregates are delivered by up to
+// Workers workers at once.
+func (d *Dispatcher) Poll(ctx context.Context) error {
+	_, err := d.webhooks.WithDispatchLock(ctx, func(ctx context.Context) error {
+		messages, err := d.webhooks.GetPendingMessages(ctx, d.cfg.BatchSize)
+
+		if err != nil || len(messages) == 0 {
+			return err
+		}
+
+		all, err := d.webhooks.GetAll(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		webhooks := map[uint]*models.Webhook{}
+
+		for i := range all {
+			webhooks[all[i].ID] = &all[i]
+		}
+
+		// queues holds the messages of each webhook and aggregate in order.
+		var keys []string
+		queues := map[string][]models.WebhookMessage{}
+
+		for _, message := range messages {
+			key := fmt.Sprintf("%d:%s:%d", message.WebhookID, message.Aggregate, message.AggregateID)
+
+			if _, ok := queues[key]; !ok {
+				keys = append(keys, key)
+			}
+
+			queues[key] = append(queues[key], message)
+		}
+
+		var wg sync.WaitGroup
+		workers := make(chan struct{}, d.cfg.Workers)
+
+		for _, key := range keys {
+			queue := queues[key]
+			webhook := webhooks[queue[0].WebhookID]
+
+			if webhook == nil {
+				continue
+			}
+
+			wg.Add(1)
+			workers <- struct{}{}
+
+			go func() {
+				defer wg.Done()
+				defer func() { <-workers }()
+
+				d.deliverQueue(ctx, webhook, queue)
+			}()
+		}
+
+		wg.Wait()
+
+		return ctx.Err()
+	})
+
+	return err
+}
+
+// deliverQueue delivers messages in order, and stops at the first one not
+// yet due or not delivered.
+func (d *Dispatcher) deliverQueue(ctx context.Context, webhook *models.Webhook, messages []models.WebhookMessage) {
+	for _, message := range messages {
+		if message.NextAttemptAt.After(d.now()) || !d.deliver(ctx, webhook, message) {
+			return
+		}
+	}
+}
+
+// deliver makes one attempt and records the outcome. It reports whether the
+// message is done with, delivered or out of attempts, which lets the next
+// message of its aggregate through.
+func (d *Dispatcher) deliver(ctx context.Context, webhook *models.Webhook, message models.WebhookMessage) bool {
+	message.Attempts++
+
+	log := d.send(ctx, webhook, message)
+
+	if !log.Succeeded && ctx.Err() != nil {
+		return false
+	}
+
+	if log.Succeeded || message.Attempts >= d.cfg.MaxAttempts {
+		if !log.Succeeded {
+			d.logger.WarnContext(ctx, "webhook delivery failed, giving up", "webhook_id", webhook.ID, "event_id", message.EventID, "attempts", message.Attempts)
+		}
+
+		return d.webhooks.CompleteMessage(ctx, message, log) == nil
+	}
+
+	message.NextAttemptAt = d.now().Add(utils.Backoff(d.cfg.InitialBackoff, d.cfg.MaxBackoff, message.Attempts))
+
+	if err := d.webhooks.RescheduleMessage(ctx, message, log); err != nil {
+		d.logger.ErrorContext(ctx, "failed to reschedule webhook delivery", "error", err, "webhook_id", webhook.ID, "event_id", message.EventID)
+	}
+
+	return false
+}
+
+// send posts the message to the webhook and describes the outcome.
+func (d *Dispatcher) send(ctx context.Context, webhook *models.Webhook, message models.WebhookMessage) models.WebhookDelivery {
+	log := models.WebhookDelivery{
+		WebhookID: webhook.ID,
+		EventID:   message.EventID,
+		EventType: message.Type,
+		Attempt:   message.Attempts,
+	}
+
+	body := []byte(message.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+
+	if err != nil {
+		log.Error = err.Error()
+		return log
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "product-webhooks/1")
+	req.Header.Set(HeaderEvent, message.Type)
+	req.Header.Set(HeaderDelivery, message.EventID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, body))
+
+	start := time.Now()
+	resp, err := d.client.Do(req)
+	log.Duration = time.Since(start)
+
+	if err != nil {
+		log.Error = err.Error()
+		return log
+	}
+
+	defer resp.Body.Close()
+
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+
+	log.StatusCode = resp.StatusCode
+	log.ResponseBody = string(responseBody)
+	log.Succeeded = resp.StatusCode >= 200 && resp.StatusCode < 300
+
+	if !log.Succeeded {
+		log.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	}
+
+	return log
+}